@@ -0,0 +1,19 @@
+package example_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/cmd/graphql-go-gen/example"
+)
+
+func TestGeneratedResolvers(t *testing.T) {
+	sdl, err := ioutil.ReadFile("schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := graphql.ParseSchema(string(sdl), &example.Resolver{}); err != nil {
+		t.Fatalf("the generated resolvers do not match the schema: %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Code generated by graphql-go-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// CharacterResolver resolves the fields of the Character interface.
+//
+// A character from the Star Wars universe.
+type CharacterResolver interface {
+	ID(ctx context.Context) (graphql.ID, error)
+	Name(ctx context.Context) (string, error)
+	Friends(ctx context.Context, args CharacterFriendsArgs) (*[]CharacterResolver, error)
+	AppearsIn(ctx context.Context) ([]Episode, error)
+	ToHuman() (HumanResolver, bool)
+	ToDroid() (DroidResolver, bool)
+}
+
+// CharacterFriendsArgs are the arguments of Character.friends.
+type CharacterFriendsArgs struct {
+	First int32
+}
+
+// ColorInput is the ColorInput input object.
+type ColorInput struct {
+	Red   int32
+	Green int32
+	Blue  int32
+}
+
+// DroidResolver resolves the fields of the Droid type.
+type DroidResolver interface {
+	ID(ctx context.Context) (graphql.ID, error)
+	Name(ctx context.Context) (string, error)
+	Friends(ctx context.Context, args CharacterFriendsArgs) (*[]CharacterResolver, error)
+	AppearsIn(ctx context.Context) ([]Episode, error)
+	PrimaryFunction(ctx context.Context) (*string, error)
+}
+
+// Episode is the Episode enum.
+//
+// The episodes in the Star Wars trilogy.
+type Episode string
+
+// The values of the Episode enum.
+const (
+	// Star Wars Episode IV: A New Hope, released in 1977.
+	EpisodeNEWHOPE Episode = "NEWHOPE"
+	EpisodeEMPIRE  Episode = "EMPIRE"
+	EpisodeJEDI    Episode = "JEDI"
+)
+
+// HumanResolver resolves the fields of the Human type.
+type HumanResolver interface {
+	ID(ctx context.Context) (graphql.ID, error)
+	Name(ctx context.Context) (string, error)
+	Friends(ctx context.Context, args CharacterFriendsArgs) (*[]CharacterResolver, error)
+	AppearsIn(ctx context.Context) ([]Episode, error)
+	Height(ctx context.Context, args HumanHeightArgs) (float64, error)
+	Mass(ctx context.Context) (*float64, error)
+}
+
+// HumanHeightArgs are the arguments of Human.height.
+type HumanHeightArgs struct {
+	Unit LengthUnit
+}
+
+// LengthUnit is the LengthUnit enum.
+type LengthUnit string
+
+// The values of the LengthUnit enum.
+const (
+	LengthUnitMETER LengthUnit = "METER"
+	LengthUnitFOOT  LengthUnit = "FOOT"
+)
+
+// MutationResolver resolves the fields of the Mutation type.
+type MutationResolver interface {
+	CreateReview(ctx context.Context, args MutationCreateReviewArgs) (ReviewResolver, error)
+}
+
+// MutationCreateReviewArgs are the arguments of Mutation.createReview.
+type MutationCreateReviewArgs struct {
+	Episode Episode
+	Review  ReviewInput
+}
+
+// QueryResolver resolves the fields of the Query type.
+//
+// The query type, represents all of the entry points into our object graph.
+type QueryResolver interface {
+	// Returns the hero of an episode.
+	Hero(ctx context.Context, args QueryHeroArgs) (CharacterResolver, error)
+	Character(ctx context.Context, args QueryCharacterArgs) (CharacterResolver, error)
+	Search(ctx context.Context, args QuerySearchArgs) ([]SearchResultResolver, error)
+	Reviews(ctx context.Context, args QueryReviewsArgs) (*[]ReviewResolver, error)
+	Tags(ctx context.Context) (*[]string, error)
+}
+
+// QueryHeroArgs are the arguments of Query.hero.
+type QueryHeroArgs struct {
+	Episode Episode
+}
+
+// QueryCharacterArgs are the arguments of Query.character.
+type QueryCharacterArgs struct {
+	ID graphql.ID
+}
+
+// QuerySearchArgs are the arguments of Query.search.
+type QuerySearchArgs struct {
+	Text  string
+	First *int32
+}
+
+// QueryReviewsArgs are the arguments of Query.reviews.
+type QueryReviewsArgs struct {
+	Episode Episode
+	Since   *graphql.Time
+}
+
+// ReviewResolver resolves the fields of the Review type.
+type ReviewResolver interface {
+	Stars(ctx context.Context) (int32, error)
+	Commentary(ctx context.Context) (*string, error)
+	CreatedAt(ctx context.Context) (graphql.Time, error)
+}
+
+// ReviewInput is the ReviewInput input object.
+//
+// The input object sent when someone is creating a new review.
+type ReviewInput struct {
+	Stars         int32
+	Commentary    *string
+	FavoriteColor *ColorInput
+	Tags          []string
+}
+
+// SearchResultResolver resolves the SearchResult union.
+type SearchResultResolver interface {
+	ToHuman() (HumanResolver, bool)
+	ToDroid() (DroidResolver, bool)
+	ToStarship() (StarshipResolver, bool)
+}
+
+// StarshipResolver resolves the fields of the Starship type.
+type StarshipResolver interface {
+	ID(ctx context.Context) (graphql.ID, error)
+	Name(ctx context.Context) (string, error)
+	Length(ctx context.Context, args StarshipLengthArgs) (float64, error)
+	Coordinates(ctx context.Context) ([][]float64, error)
+}
+
+// StarshipLengthArgs are the arguments of Starship.length.
+type StarshipLengthArgs struct {
+	Unit LengthUnit
+}
+
+// SubscriptionResolver resolves the fields of the Subscription type.
+type SubscriptionResolver interface {
+	ReviewAdded(ctx context.Context, args SubscriptionReviewAddedArgs) (<-chan ReviewResolver, error)
+}
+
+// SubscriptionReviewAddedArgs are the arguments of Subscription.reviewAdded.
+type SubscriptionReviewAddedArgs struct {
+	Episode *Episode
+}
+
+var _ QueryResolver = (*Resolver)(nil)
+var _ MutationResolver = (*Resolver)(nil)
+var _ SubscriptionResolver = (*Resolver)(nil)
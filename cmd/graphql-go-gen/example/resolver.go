@@ -0,0 +1,12 @@
+// Package example contains the code generated by graphql-go-gen for schema.graphql.
+package example
+
+//go:generate go run .. -package example -root *Resolver -o generated.go schema.graphql
+
+// Resolver is the root resolver. It embeds the generated interfaces, so that the signatures of
+// the generated code can be checked against the schema without implementing them.
+type Resolver struct {
+	QueryResolver
+	MutationResolver
+	SubscriptionResolver
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/graph-gophers/graphql-go/schema"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+const graphqlPath = "github.com/graph-gophers/graphql-go"
+
+// config configures the generated code.
+type config struct {
+	// pkg is the name of the generated package.
+	pkg string
+	// root is the Go type of the root resolver which is asserted to implement the root types.
+	root string
+	// scalars maps custom scalars to Go types as "import/path.Type".
+	scalars map[string]string
+	// useStringDescriptions parses the schema like the UseStringDescriptions schema option.
+	useStringDescriptions bool
+}
+
+// builtinScalars are the Go types of the scalars which do not need to be configured.
+var builtinScalars = map[string]string{
+	"Int":     "int32",
+	"Float":   "float64",
+	"String":  "string",
+	"Boolean": "bool",
+	"ID":      graphqlPath + ".ID",
+	"Time":    graphqlPath + ".Time",
+}
+
+type generator struct {
+	cfg     config
+	schema  *types.Schema
+	imports map[string]string // import path to package name
+	buf     bytes.Buffer
+}
+
+// generate returns the Go source of the resolver interfaces, argument structs, input types and enums
+// of the schema.
+func generate(cfg config, sdl string) ([]byte, error) {
+	s := schema.New()
+	builtin := make(map[string]bool, len(s.Types))
+	for name := range s.Types {
+		builtin[name] = true
+	}
+	if err := schema.Parse(s, sdl, cfg.useStringDescriptions); err != nil {
+		return nil, err
+	}
+
+	g := &generator{cfg: cfg, schema: s, imports: make(map[string]string)}
+	var names []string
+	for name := range s.Types {
+		if !builtin[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.namedType(s.Types[name]); err != nil {
+			return nil, err
+		}
+	}
+	g.rootAssertions()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by graphql-go-gen. DO NOT EDIT.\n\npackage %s\n\n", cfg.pkg)
+	if len(g.imports) != 0 {
+		var paths []string
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		out.WriteString("import (\n")
+		std := true
+		for i, path := range paths {
+			if std && strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+				if i > 0 {
+					out.WriteString("\n") // separate the standard library
+				}
+				std = false
+			}
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %s", err)
+	}
+	return src, nil
+}
+
+func (g *generator) namedType(t types.NamedType) error {
+	switch t := t.(type) {
+	case *types.ObjectTypeDefinition:
+		return g.object(t)
+	case *types.InterfaceTypeDefinition:
+		return g.iface(t)
+	case *types.Union:
+		g.union(t)
+	case *types.InputObject:
+		return g.inputObject(t)
+	case *types.EnumTypeDefinition:
+		g.enum(t)
+	case *types.ScalarTypeDefinition:
+		if _, err := g.scalarType(t.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) object(t *types.ObjectTypeDefinition) error {
+	g.comment(t.Desc, fmt.Sprintf("%s resolves the fields of the %s type.", resolverName(t.Name), t.Name))
+	fmt.Fprintf(&g.buf, "type %s interface {\n", resolverName(t.Name))
+	var args []*types.FieldDefinition
+	for _, f := range t.Fields {
+		argsType := argsName(t.Name, f.Name)
+		shared := false
+		for _, iface := range t.Interfaces {
+			// share the arguments with the interface, so that a type can implement both
+			if ifaceField := iface.Fields.Get(f.Name); ifaceField != nil && len(ifaceField.Arguments) == len(f.Arguments) {
+				argsType = argsName(iface.Name, f.Name)
+				shared = true
+				break
+			}
+		}
+		if err := g.method(t.Name, f, argsType); err != nil {
+			return err
+		}
+		if len(f.Arguments) != 0 && !shared {
+			args = append(args, f)
+		}
+	}
+	g.buf.WriteString("}\n\n")
+	return g.argsStructs(t.Name, args)
+}
+
+func (g *generator) iface(t *types.InterfaceTypeDefinition) error {
+	g.comment(t.Desc, fmt.Sprintf("%s resolves the fields of the %s interface.", resolverName(t.Name), t.Name))
+	fmt.Fprintf(&g.buf, "type %s interface {\n", resolverName(t.Name))
+	var args []*types.FieldDefinition
+	for _, f := range t.Fields {
+		if err := g.method(t.Name, f, argsName(t.Name, f.Name)); err != nil {
+			return err
+		}
+		if len(f.Arguments) != 0 {
+			args = append(args, f)
+		}
+	}
+	g.typeAssertions(t.PossibleTypes)
+	g.buf.WriteString("}\n\n")
+	return g.argsStructs(t.Name, args)
+}
+
+func (g *generator) union(t *types.Union) {
+	g.comment(t.Desc, fmt.Sprintf("%s resolves the %s union.", resolverName(t.Name), t.Name))
+	fmt.Fprintf(&g.buf, "type %s interface {\n", resolverName(t.Name))
+	g.typeAssertions(t.UnionMemberTypes)
+	g.buf.WriteString("}\n\n")
+}
+
+func (g *generator) typeAssertions(possibleTypes []*types.ObjectTypeDefinition) {
+	for _, impl := range possibleTypes {
+		fmt.Fprintf(&g.buf, "\tTo%s() (%s, bool)\n", impl.Name, resolverName(impl.Name))
+	}
+}
+
+func (g *generator) method(typeName string, f *types.FieldDefinition, argsType string) error {
+	out, err := g.outputType(f.Type)
+	if err != nil {
+		return fmt.Errorf("field %q of %q: %s", f.Name, typeName, err)
+	}
+	if sub, ok := g.schema.RootOperationTypes["subscription"]; ok && sub.TypeName() == typeName {
+		out = "<-chan " + out
+	}
+
+	params := g.use("context") + ".Context"
+	if len(f.Arguments) != 0 {
+		params += ", args " + argsType
+	}
+	g.fieldComment(f.Desc)
+	fmt.Fprintf(&g.buf, "\t%s(ctx %s) (%s, error)\n", goName(f.Name), params, out)
+	return nil
+}
+
+func (g *generator) argsStructs(typeName string, fields []*types.FieldDefinition) error {
+	for _, f := range fields {
+		name := argsName(typeName, f.Name)
+		fmt.Fprintf(&g.buf, "// %s are the arguments of %s.%s.\n", name, typeName, f.Name)
+		fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+		if err := g.inputFields(f.Arguments); err != nil {
+			return fmt.Errorf("arguments of field %q of %q: %s", f.Name, typeName, err)
+		}
+		g.buf.WriteString("}\n\n")
+	}
+	return nil
+}
+
+func (g *generator) inputObject(t *types.InputObject) error {
+	g.comment(t.Desc, fmt.Sprintf("%s is the %s input object.", goName(t.Name), t.Name))
+	fmt.Fprintf(&g.buf, "type %s struct {\n", goName(t.Name))
+	if err := g.inputFields(t.Values); err != nil {
+		return fmt.Errorf("input object %q: %s", t.Name, err)
+	}
+	g.buf.WriteString("}\n\n")
+	return nil
+}
+
+func (g *generator) inputFields(values []*types.InputValueDefinition) error {
+	for _, v := range values {
+		typ, err := g.inputType(v.Type, v.Default != nil)
+		if err != nil {
+			return fmt.Errorf("field %q: %s", v.Name.Name, err)
+		}
+		g.fieldComment(v.Desc)
+		fmt.Fprintf(&g.buf, "\t%s %s\n", goName(v.Name.Name), typ)
+	}
+	return nil
+}
+
+func (g *generator) enum(t *types.EnumTypeDefinition) {
+	name := goName(t.Name)
+	g.comment(t.Desc, fmt.Sprintf("%s is the %s enum.", name, t.Name))
+	fmt.Fprintf(&g.buf, "type %s string\n\n", name)
+	fmt.Fprintf(&g.buf, "// The values of the %s enum.\nconst (\n", t.Name)
+	for _, v := range t.EnumValuesDefinition {
+		g.fieldComment(v.Desc)
+		fmt.Fprintf(&g.buf, "\t%s%s %s = %q\n", name, goName(v.EnumValue), name, v.EnumValue)
+	}
+	g.buf.WriteString(")\n\n")
+}
+
+func (g *generator) rootAssertions() {
+	if g.cfg.root == "" {
+		return
+	}
+	for _, op := range []string{"query", "mutation", "subscription"} {
+		if t, ok := g.schema.RootOperationTypes[op]; ok {
+			fmt.Fprintf(&g.buf, "var _ %s = (%s)(nil)\n", resolverName(t.TypeName()), g.cfg.root)
+		}
+	}
+}
+
+// outputType returns the Go type of a field resolving to t, as accepted by the resolvable package:
+// nullable values are pointers, objects, interfaces and unions are resolver interfaces.
+func (g *generator) outputType(t types.Type) (string, error) {
+	nonNull, ok := t.(*types.NonNull)
+	if ok {
+		return g.outputElemType(nonNull.OfType)
+	}
+	elem, err := g.outputElemType(t)
+	if err != nil {
+		return "", err
+	}
+	switch t.(type) {
+	case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition, *types.Union:
+		return elem, nil
+	}
+	return "*" + elem, nil
+}
+
+func (g *generator) outputElemType(t types.Type) (string, error) {
+	switch t := t.(type) {
+	case *types.List:
+		elem, err := g.outputType(t.OfType)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition, *types.Union:
+		return resolverName(t.(types.NamedType).TypeName()), nil
+	case *types.EnumTypeDefinition:
+		return goName(t.Name), nil
+	case *types.ScalarTypeDefinition:
+		return g.scalarType(t.Name)
+	default:
+		panic("invalid type: " + t.String())
+	}
+}
+
+// inputType returns the Go type of an argument or input field of type t, as accepted by the packer
+// package: nullable values without a default are pointers, input objects are structs.
+func (g *generator) inputType(t types.Type, hasDefault bool) (string, error) {
+	nonNull, ok := t.(*types.NonNull)
+	if ok {
+		return g.inputElemType(nonNull.OfType)
+	}
+	elem, err := g.inputElemType(t)
+	if err != nil || hasDefault {
+		return elem, err
+	}
+	return "*" + elem, nil
+}
+
+func (g *generator) inputElemType(t types.Type) (string, error) {
+	switch t := t.(type) {
+	case *types.List:
+		elem, err := g.inputType(t.OfType, false)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case *types.InputObject:
+		return goName(t.Name), nil
+	case *types.EnumTypeDefinition:
+		return goName(t.Name), nil
+	case *types.ScalarTypeDefinition:
+		return g.scalarType(t.Name)
+	default:
+		panic("invalid input type: " + t.String())
+	}
+}
+
+func (g *generator) scalarType(name string) (string, error) {
+	typ, ok := g.cfg.scalars[name]
+	if !ok {
+		typ, ok = builtinScalars[name]
+	}
+	if !ok {
+		return "", fmt.Errorf("no Go type for scalar %q, use -scalar %s=<import path>.<type>", name, name)
+	}
+	i := strings.LastIndex(typ, ".")
+	if i == -1 {
+		return typ, nil
+	}
+	return g.use(typ[:i]) + typ[i:], nil
+}
+
+// use imports the package and returns its name.
+func (g *generator) use(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if path == graphqlPath {
+		name = "graphql"
+	}
+	g.imports[path] = name
+	return name
+}
+
+// comment writes the doc comment of a type, followed by the description from the schema.
+func (g *generator) comment(desc string, summary string) {
+	fmt.Fprintf(&g.buf, "// %s\n", summary)
+	if desc == "" {
+		return
+	}
+	g.buf.WriteString("//\n")
+	for _, line := range strings.Split(strings.TrimSpace(desc), "\n") {
+		fmt.Fprintf(&g.buf, "// %s\n", strings.TrimSpace(line))
+	}
+}
+
+func (g *generator) fieldComment(desc string) {
+	if desc == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(desc), "\n") {
+		fmt.Fprintf(&g.buf, "\t// %s\n", strings.TrimSpace(line))
+	}
+}
+
+func resolverName(typeName string) string {
+	return goName(typeName) + "Resolver"
+}
+
+func argsName(typeName, fieldName string) string {
+	return goName(typeName) + goName(fieldName) + "Args"
+}
+
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// goName returns the exported Go name of a GraphQL name. The resolvable and packer packages match
+// names case-insensitively and ignore underscores, so any capitalization is accepted.
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		if initialisms[strings.ToUpper(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// splitWords splits a name at underscores and at lower to upper case transitions.
+func splitWords(name string) []string {
+	var words []string
+	start := 0
+	r := []rune(name)
+	for i := range r {
+		switch {
+		case r[i] == '_':
+			if i > start {
+				words = append(words, string(r[start:i]))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(r[i]) && unicode.IsLower(r[i-1]):
+			words = append(words, string(r[start:i]))
+			start = i
+		}
+	}
+	if start < len(r) {
+		words = append(words, string(r[start:]))
+	}
+	return words
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	sdl, err := ioutil.ReadFile("example/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("example/generated.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := generate(config{pkg: "example", root: "*Resolver"}, string(sdl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("example/generated.go is out of date, run go generate ./cmd/graphql-go-gen/example")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		sdl  string
+		err  string
+	}{
+		{
+			name: "unmapped scalar",
+			sdl:  "scalar Date\ntype Query { today: Date! }",
+			err:  `no Go type for scalar "Date", use -scalar Date=<import path>.<type>`,
+		},
+		{
+			name: "invalid schema",
+			sdl:  "type Query { hero: Character }",
+			err:  `Unknown type "Character"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generate(config{pkg: "example"}, tc.sdl)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("unexpected error\ngot:  %v\nwant: %s", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestGenerateScalar(t *testing.T) {
+	src, err := generate(config{
+		pkg:     "example",
+		scalars: map[string]string{"Date": "github.com/example/scalars.Date"},
+	}, "scalar Date\ntype Query { today: Date! user_id: ID }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"github.com/example/scalars"`,
+		"Today(ctx context.Context) (scalars.Date, error)",
+		"UserID(ctx context.Context) (*graphql.ID, error)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Command graphql-go-gen generates Go resolver interfaces from GraphQL schema files.
+//
+// For every object and interface type it generates an interface with one resolver method per field,
+// and an argument struct for every field with arguments. Input objects become structs and enums
+// become string types. The generated signatures are accepted by graphql.ParseSchema, so a root
+// resolver implementing the interfaces of the root types can not fail on startup:
+//
+//	//go:generate go run github.com/graph-gophers/graphql-go/cmd/graphql-go-gen -package resolvers -root *Resolver -o generated.go schema.graphql
+//
+// Custom scalars other than Time must be mapped to a Go type implementing them, for example
+// -scalar DateTime=github.com/example/scalars.DateTime.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// scalarFlag collects the -scalar flags.
+type scalarFlag map[string]string
+
+func (f scalarFlag) String() string {
+	return ""
+}
+
+func (f scalarFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 || i == len(value)-1 {
+		return fmt.Errorf("expected Name=import/path.Type, got %q", value)
+	}
+	f[value[:i]] = value[i+1:]
+	return nil
+}
+
+func main() {
+	cfg := config{scalars: make(scalarFlag)}
+	var output string
+	flag.StringVar(&output, "o", "", "output file, defaults to stdout")
+	flag.StringVar(&cfg.pkg, "package", "resolvers", "name of the generated package")
+	flag.StringVar(&cfg.root, "root", "", "pointer type of the root resolver which must implement the root types, e.g. *Resolver")
+	flag.Var(scalarFlag(cfg.scalars), "scalar", "Go type of a custom scalar as Name=import/path.Type, may be repeated")
+	flag.BoolVar(&cfg.useStringDescriptions, "use-string-descriptions", false, "parse descriptions like the UseStringDescriptions schema option")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: graphql-go-gen [flags] schema.graphql...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(cfg, flag.Args(), output); err != nil {
+		fmt.Fprintf(os.Stderr, "graphql-go-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config, files []string, output string) error {
+	var sdl strings.Builder
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		sdl.Write(b)
+		sdl.WriteString("\n")
+	}
+
+	src, err := generate(cfg, sdl.String())
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
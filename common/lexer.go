@@ -159,10 +159,16 @@ func (l *Lexer) SyntaxError(message string) {
 	panic(syntaxError(message))
 }
 
+// SetFilename sets the file name of the locations of the following tokens.
+func (l *Lexer) SetFilename(name string) {
+	l.sc.Filename = name
+}
+
 func (l *Lexer) Location() errors.Location {
 	return errors.Location{
 		Line:   l.sc.Line,
 		Column: l.sc.Column,
+		File:   l.sc.Filename,
 	}
 }
 
@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/graph-gophers/graphql-go/exec"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// DirectiveFunc implements a schema directive on FIELD_DEFINITION. It is called instead of the
+// resolver of every field the directive is applied to, with the argument values of the directive.
+// It may call next to invoke the resolver, or return a result on its own.
+type DirectiveFunc func(ctx context.Context, args map[string]interface{}, info FieldInfo, next Resolve) (interface{}, error)
+
+// Directives registers Go implementations of schema directives by name. The directives must be
+// defined in the schema. If a field has several implemented directives, they are called in the order
+// in which they are applied to the field, the first one being the outermost. The directives of a
+// field of an interface are called for the fields of the implementing object types too. Directives
+// are called after the field middlewares.
+func Directives(directives map[string]DirectiveFunc) SchemaOpt {
+	return func(s *Schema) {
+		if s.directives == nil {
+			s.directives = make(map[string]DirectiveFunc)
+		}
+		for name, fn := range directives {
+			s.directives[name] = fn
+		}
+	}
+}
+
+type fieldKey struct {
+	typeName  string
+	fieldName string
+}
+
+type boundDirective struct {
+	fn   DirectiveFunc
+	args map[string]interface{}
+}
+
+// directiveMiddleware returns a field middleware calling the implemented directives of the fields,
+// and reports whether a field of an object type has any. The directives of a field of an interface
+// apply to the fields of the implementing object types too, after the directives of the object
+// field itself. A directive applied to both is only called with the arguments of the object field.
+func (s *Schema) directiveMiddleware() (exec.FieldMiddleware, func(parentType, fieldName string) bool, error) {
+	var names []string
+	for name := range s.directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := s.schema.Directives[name]; !ok {
+			return nil, nil, fmt.Errorf("directive %q is implemented but not defined in the schema", name)
+		}
+	}
+
+	bound := make(map[fieldKey][]boundDirective)
+	for _, t := range s.schema.Types {
+		obj, ok := t.(*types.ObjectTypeDefinition)
+		if !ok {
+			continue
+		}
+		for _, f := range obj.Fields {
+			directives := append(types.DirectiveList(nil), f.Directives...)
+			for _, iface := range obj.Interfaces {
+				if iface := iface.Fields.Get(f.Name); iface != nil {
+					for _, d := range iface.Directives {
+						if f.Directives.Get(d.Name.Name) == nil {
+							directives = append(directives, d)
+						}
+					}
+				}
+			}
+			for _, d := range directives {
+				fn, ok := s.directives[d.Name.Name]
+				if !ok {
+					continue
+				}
+				key := fieldKey{obj.Name, f.Name}
+				bound[key] = append(bound[key], boundDirective{fn: fn, args: directiveArgs(d)})
+			}
+		}
+	}
+
+	m := func(ctx context.Context, info FieldInfo, next Resolve) (interface{}, error) {
+		directives := bound[fieldKey{info.ParentType, info.Field.Name}]
+		return callDirectives(ctx, directives, info, next)
+	}
+	fields := func(parentType, fieldName string) bool {
+		_, ok := bound[fieldKey{parentType, fieldName}]
+		return ok
+	}
+	return m, fields, nil
+}
+
+// directiveArgs returns the argument values of an applied directive. The arguments which are not
+// provided have been set to their default values when the schema was parsed.
+func directiveArgs(d *types.Directive) map[string]interface{} {
+	args := make(map[string]interface{}, len(d.Arguments))
+	for _, arg := range d.Arguments {
+		if arg.Value != nil {
+			args[arg.Name.Name] = arg.Value.Deserialize(nil)
+		}
+	}
+	return args
+}
+
+func callDirectives(ctx context.Context, directives []boundDirective, info FieldInfo, next Resolve) (interface{}, error) {
+	if len(directives) == 0 {
+		return next(ctx, info.Args)
+	}
+	d := directives[0]
+	return d.fn(ctx, d.args, info, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		info := info
+		info.Args = args
+		return callDirectives(ctx, directives[1:], info, next)
+	})
+}
@@ -17,9 +17,15 @@ type QueryError struct {
 type Location struct {
 	Line   int `json:"line"`
 	Column int `json:"column"`
+	// File is the name of the schema file, it is empty for locations in queries and in schemas
+	// parsed from a single string.
+	File string `json:"file,omitempty"`
 }
 
 func (a Location) Before(b Location) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
 	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
 }
 
@@ -44,6 +50,10 @@ func (err *QueryError) Error() string {
 	}
 	str := fmt.Sprintf("graphql: %s", err.Message)
 	for _, loc := range err.Locations {
+		if loc.File != "" {
+			str += fmt.Sprintf(" (%s, line %d, column %d)", loc.File, loc.Line, loc.Column)
+			continue
+		}
 		str += fmt.Sprintf(" (line %d, column %d)", loc.Line, loc.Column)
 	}
 	return str
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -15,17 +16,47 @@ var schema = `
 	
 	type Query {
 		hello: String!
+		me: User!
+	}
+
+	type User @key(fields: "id") {
+		id: ID!
+		name: String!
 	}
 `
 
+type user struct {
+	ID   graphql.ID
+	Name string
+}
+
+var users = map[graphql.ID]*user{
+	"1": {ID: "1", Name: "Alice"},
+	"2": {ID: "2", Name: "Bob"},
+}
+
 type resolver struct{}
 
 func (r *resolver) Hello() string {
 	return "Hello from subgraph one!"
 }
 
+func (r *resolver) Me() *user {
+	return users["1"]
+}
+
+// UserEntities resolves the users referenced by other subgraphs.
+func (r *resolver) UserEntities(ctx context.Context, representations []map[string]interface{}) ([]*user, error) {
+	res := make([]*user, len(representations))
+	for i, rep := range representations {
+		id, _ := rep["id"].(string)
+		res[i] = users[graphql.ID(id)]
+	}
+	return res, nil
+}
+
 func main() {
-	opts := []graphql.SchemaOpt{graphql.UseFieldResolvers(), graphql.MaxParallelism(20)}
+	opts := []graphql.SchemaOpt{graphql.UseFieldResolvers(), graphql.MaxParallelism(20), graphql.Federation()}
 	schema := graphql.MustParseSchema(schema, &resolver{}, opts...)
 
 	http.Handle("/query", &relay.Handler{Schema: schema})
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -16,16 +17,42 @@ var schema = `
 	type Query {
 		hi: String!
 	}
+
+	type User @key(fields: "id") @extends {
+		id: ID! @external
+		reviews: [String!]!
+	}
 `
 
+type user struct {
+	ID graphql.ID
+}
+
+func (u *user) Reviews() []string {
+	if u.ID == "1" {
+		return []string{"Great product!", "Fast delivery."}
+	}
+	return []string{}
+}
+
 type resolver struct{}
 
 func (r *resolver) Hi() string {
 	return "Hi from subgraph two!"
 }
 
+// UserEntities returns the users owned by subgraph one, which this subgraph extends with reviews.
+func (r *resolver) UserEntities(ctx context.Context, representations []map[string]interface{}) ([]*user, error) {
+	res := make([]*user, len(representations))
+	for i, rep := range representations {
+		id, _ := rep["id"].(string)
+		res[i] = &user{ID: graphql.ID(id)}
+	}
+	return res, nil
+}
+
 func main() {
-	opts := []graphql.SchemaOpt{graphql.UseFieldResolvers(), graphql.MaxParallelism(20)}
+	opts := []graphql.SchemaOpt{graphql.UseFieldResolvers(), graphql.MaxParallelism(20), graphql.Federation()}
 	schema := graphql.MustParseSchema(schema, &resolver{}, opts...)
 
 	http.Handle("/query", &relay.Handler{Schema: schema})
@@ -32,6 +32,17 @@ type Request struct {
 	Logger                   log.Logger
 	PanicHandler             errors.PanicHandler
 	SubscribeResolverTimeout time.Duration
+	FieldMiddleware          FieldMiddleware
+	// MiddlewareFields reports whether the FieldMiddleware is called for a field, given the name of
+	// the concrete object type of the parent and the name of the field. If nil, the FieldMiddleware
+	// is called for every field.
+	MiddlewareFields func(parentType, fieldName string) bool
+
+	// Selections are the selections of the operation if they have been prepared already with
+	// selected.PrepareOperation, e.g. for a prepared operation.
+	Selections []selected.Selection
+
+	incremental *incremental
 }
 
 func (r *Request) handlePanic(ctx context.Context) {
@@ -41,6 +52,14 @@ func (r *Request) handlePanic(ctx context.Context) {
 	}
 }
 
+// applyOperation returns the selections of the operation.
+func (r *Request) applyOperation(s *resolvable.Schema, op *types.OperationDefinition) []selected.Selection {
+	if r.Selections != nil {
+		return selected.BindOperation(&r.Request, s, r.Selections)
+	}
+	return selected.ApplyOperation(&r.Request, s, op)
+}
+
 type extensionser interface {
 	Extensions() map[string]interface{}
 }
@@ -49,7 +68,7 @@ func (r *Request) Execute(ctx context.Context, s *resolvable.Schema, op *types.O
 	var out bytes.Buffer
 	func() {
 		defer r.handlePanic(ctx)
-		sels := selected.ApplyOperation(&r.Request, s, op)
+		sels := r.applyOperation(s, op)
 		r.execSelections(ctx, sels, nil, s, s.Resolver, &out, op.Type == query.Mutation)
 	}()
 
@@ -65,6 +84,16 @@ type fieldToExec struct {
 	sels     []selected.Selection
 	resolver reflect.Value
 	out      *bytes.Buffer
+	batch    *batchedResult
+}
+
+// batchedResult holds the outcome of a batch method call for a single parent. queryErr is the
+// error of the field middleware, which already carries the path of the field.
+type batchedResult struct {
+	value    reflect.Value
+	err      error
+	panicErr *errors.QueryError
+	queryErr *errors.QueryError
 }
 
 func resolvedToNull(b *bytes.Buffer) bool {
@@ -72,10 +101,63 @@ func resolvedToNull(b *bytes.Buffer) bool {
 }
 
 func (r *Request) execSelections(ctx context.Context, sels []selected.Selection, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer, serially bool) {
-	async := !serially && selected.HasAsyncSel(sels)
+	r.execFields(ctx, r.collectFields(sels, path, s, resolver), path, s, out, serially)
+}
 
+// collectFields collects the fields to resolve on the given object. Deferred fragments are queued
+// for later execution if the request is delivered incrementally.
+func (r *Request) collectFields(sels []selected.Selection, path *pathSegment, s *resolvable.Schema, resolver reflect.Value) []*fieldToExec {
 	var fields []*fieldToExec
-	collectFieldsToResolve(sels, s, resolver, &fields, make(map[string]*fieldToExec))
+	if r.incremental == nil {
+		collectFieldsToResolve(sels, s, resolver, &fields, make(map[string]*fieldToExec), nil)
+		return fields
+	}
+
+	var deferred []deferredFragment
+	fieldByAlias := make(map[string]*fieldToExec)
+	collectFieldsToResolve(sels, s, resolver, &fields, fieldByAlias, &deferred)
+	for _, d := range deferred {
+		d.frag = &selected.DeferredFragment{Label: d.frag.Label, Sels: mergeInitialFields(d.frag.Sels, d.resolver, fieldByAlias)}
+		r.incremental.deferFragment(path, s, d)
+	}
+	return fields
+}
+
+// mergeInitialFields returns the selections of a deferred fragment without the fields which are part
+// of the initial result of the object. Their selections are merged into the initial fields instead,
+// so that every field is only delivered once.
+func mergeInitialFields(sels []selected.Selection, resolver reflect.Value, fieldByAlias map[string]*fieldToExec) []selected.Selection {
+	var rest []selected.Selection
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *selected.SchemaField:
+			if f, ok := fieldByAlias[sel.Alias]; ok {
+				f.sels = append(f.sels, sel.Sels...)
+				continue
+			}
+
+		case *selected.TypenameField:
+			if _, ok := fieldByAlias[sel.Alias]; ok {
+				continue
+			}
+
+		case *selected.TypeAssertion:
+			v, ok := sel.Assert(resolver)
+			if !ok {
+				continue
+			}
+			a := *sel
+			a.Sels = mergeInitialFields(sel.Sels, v, fieldByAlias)
+			rest = append(rest, &a)
+			continue
+		}
+		rest = append(rest, sel)
+	}
+	return rest
+}
+
+func (r *Request) execFields(ctx context.Context, fields []*fieldToExec, path *pathSegment, s *resolvable.Schema, out *bytes.Buffer, serially bool) {
+	async := !serially && hasAsyncField(fields)
 
 	if async {
 		var wg sync.WaitGroup
@@ -104,6 +186,7 @@ func (r *Request) execSelections(ctx context.Context, sels []selected.Selection,
 		if _, ok := f.field.Type.(*types.NonNull); ok && resolvedToNull(f.out) {
 			out.Reset()
 			out.Write([]byte("null"))
+			r.incremental.markNulled(path)
 			return
 		}
 
@@ -119,7 +202,18 @@ func (r *Request) execSelections(ctx context.Context, sels []selected.Selection,
 	out.WriteByte('}')
 }
 
-func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, resolver reflect.Value, fields *[]*fieldToExec, fieldByAlias map[string]*fieldToExec) {
+func hasAsyncField(fields []*fieldToExec) bool {
+	for _, f := range fields {
+		if f.field.Async {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFieldsToResolve flattens the selections into fields. Deferred fragments are appended to
+// deferred, or collected like any other fragment if deferred is nil.
+func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, resolver reflect.Value, fields *[]*fieldToExec, fieldByAlias map[string]*fieldToExec, deferred *[]deferredFragment) {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
 		case *selected.SchemaField:
@@ -150,11 +244,18 @@ func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, res
 			}
 
 		case *selected.TypeAssertion:
-			out := resolver.Method(sel.MethodIndex).Call(nil)
-			if !out[1].Bool() {
+			v, ok := sel.Assert(resolver)
+			if !ok {
+				continue
+			}
+			collectFieldsToResolve(sel.Sels, s, v, fields, fieldByAlias, deferred)
+
+		case *selected.DeferredFragment:
+			if deferred == nil {
+				collectFieldsToResolve(sel.Sels, s, resolver, fields, fieldByAlias, nil)
 				continue
 			}
-			collectFieldsToResolve(sel.Sels, s, out[0], fields, fieldByAlias)
+			*deferred = append(*deferred, deferredFragment{frag: sel, resolver: resolver})
 
 		default:
 			panic("unreachable")
@@ -167,8 +268,7 @@ func typeOf(tf *selected.TypenameField, resolver reflect.Value) string {
 		return tf.Name
 	}
 	for name, a := range tf.TypeAssertions {
-		out := resolver.Method(a.MethodIndex).Call(nil)
-		if out[1].Bool() {
+		if _, ok := a.Assert(resolver); ok {
 			return name
 		}
 	}
@@ -198,19 +298,37 @@ func contextWithExecutableRootFieldSelection(parentContext context.Context, f *f
 }
 
 func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f *fieldToExec, path *pathSegment, applyLimiter bool) {
-	if applyLimiter {
-		r.Limiter <- struct{}{}
-	}
-
-	var result reflect.Value
-	var err *errors.QueryError
-
 	traceCtx, finish := r.Tracer.TraceField(ctx, f.field.TraceLabel, f.field.TypeName, f.field.Name, !f.field.Async, f.field.Args)
+	traceCtx, result, err := r.resolveFieldSelection(ctx, traceCtx, f, path, applyLimiter)
 	defer func() {
 		finish(err)
 	}()
 
-	err = func() (err *errors.QueryError) {
+	if err != nil {
+		// If an error occurred while resolving a field, it should be treated as though the field
+		// returned null, and an error must be added to the "errors" list in the response.
+		r.AddError(err)
+		f.out.WriteString("null")
+		return
+	}
+
+	if f.field.Stream != nil && r.incremental != nil && r.execStream(traceCtx, s, f, path, result) {
+		return
+	}
+
+	r.execSelectionSet(traceCtx, f.sels, f.field.Type, path, s, result, f.out)
+}
+
+// resolveFieldSelection resolves the value of the field within the trace of the field. The returned
+// context is passed on to the resolvers of the sub-fields.
+func (r *Request) resolveFieldSelection(ctx context.Context, traceCtx context.Context, f *fieldToExec, path *pathSegment, applyLimiter bool) (context.Context, reflect.Value, *errors.QueryError) {
+	if applyLimiter {
+		r.Limiter <- struct{}{}
+		defer func() { <-r.Limiter }()
+	}
+
+	var result reflect.Value
+	err := func() (err *errors.QueryError) {
 		defer func() {
 			if panicValue := recover(); panicValue != nil {
 				r.Logger.LogPanic(ctx, panicValue)
@@ -228,61 +346,219 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 			return errors.Errorf("%s", err) // don't execute any more resolvers if context got cancelled
 		}
 
-		res := f.resolver
-		if f.field.UseMethodResolver() {
-			var in []reflect.Value
-			if f.field.HasContext {
-				traceCtx = contextWithExecutableFieldSelection(traceCtx, f)
-				if path.parent == nil { // nil parent indicates it's the root field
-					traceCtx = contextWithExecutableRootFieldSelection(traceCtx, f)
-				}
-				in = append(in, reflect.ValueOf(traceCtx))
+		if f.batch == nil && r.wrapsField(f) {
+			traceCtx, result, err = r.resolveWithMiddleware(traceCtx, f, path)
+			return err
+		}
+		traceCtx, result, err = resolveField(traceCtx, f, path, f.field.PackedArgs)
+		return err
+	}()
+	return traceCtx, result, err
+}
+
+// resolveField calls the resolver of the field with the given arguments. The returned context is
+// passed on to the resolvers of the sub-fields.
+func resolveField(ctx context.Context, f *fieldToExec, path *pathSegment, packedArgs reflect.Value) (context.Context, reflect.Value, *errors.QueryError) {
+	if b := f.batch; b != nil {
+		switch {
+		case b.panicErr != nil:
+			err := *b.panicErr
+			err.Path = path.toSlice()
+			return ctx, reflect.Value{}, &err
+		case b.err != nil:
+			return ctx, reflect.Value{}, makeResolverError(b.err, path)
+		case b.queryErr != nil:
+			return ctx, reflect.Value{}, b.queryErr
+		}
+		return ctx, b.value, nil
+	}
+
+	if f.field.Func != nil {
+		return resolveFunc(ctx, f, path, f.field.Args)
+	}
+
+	res := f.resolver
+	if f.field.UseMethodResolver() && f.field.MethodIndex == -1 {
+		// the field only has a batch method, resolve it for a single parent
+		if f.field.Batch.HasContext {
+			ctx = contextWithFieldSelection(ctx, f, path)
+		}
+		results, resolverErr := callBatch(ctx, f.field, []reflect.Value{res}, packedArgs)
+		if resolverErr != nil {
+			return ctx, reflect.Value{}, makeResolverError(resolverErr, path)
+		}
+		return ctx, results[0], nil
+	}
+
+	if f.field.UseMethodResolver() {
+		var in []reflect.Value
+		if f.field.HasContext {
+			ctx = contextWithFieldSelection(ctx, f, path)
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		if f.field.ArgsPacker != nil {
+			in = append(in, packedArgs)
+		}
+		callOut := res.Method(f.field.MethodIndex).Call(in)
+		if f.field.HasError && !callOut[1].IsNil() {
+			return ctx, reflect.Value{}, makeResolverError(callOut[1].Interface().(error), path)
+		}
+		return ctx, callOut[0], nil
+	}
+
+	// TODO extract out unwrapping ptr logic to a common place
+	if res.Kind() == reflect.Ptr {
+		res = res.Elem()
+	}
+	return ctx, res.FieldByIndex(f.field.FieldIndex), nil
+}
+
+// resolveFunc resolves a field bound to a function with the given arguments.
+func resolveFunc(ctx context.Context, f *fieldToExec, path *pathSegment, args map[string]interface{}) (context.Context, reflect.Value, *errors.QueryError) {
+	ctx = contextWithFieldSelection(ctx, f, path)
+	out, err := f.field.Func(ctx, f.resolver.Interface(), args)
+	if err != nil {
+		return ctx, reflect.Value{}, makeResolverError(err, path)
+	}
+	// keep the value as an interface, so that a nil result resolves to null
+	return ctx, reflect.ValueOf(&out).Elem(), nil
+}
+
+func contextWithFieldSelection(ctx context.Context, f *fieldToExec, path *pathSegment) context.Context {
+	ctx = contextWithExecutableFieldSelection(ctx, f)
+	if path.parent == nil { // nil parent indicates it's the root field
+		ctx = contextWithExecutableRootFieldSelection(ctx, f)
+	}
+	return ctx
+}
+
+func makeResolverError(resolverErr error, path *pathSegment) *errors.QueryError {
+	err := errors.Errorf("%s", resolverErr)
+	err.Path = path.toSlice()
+	err.ResolverError = resolverErr
+	if ex, ok := resolverErr.(extensionser); ok {
+		err.Extensions = ex.Extensions()
+	}
+	return err
+}
+
+// callBatch calls the batch method of the field on the first parent and returns one result per parent.
+func callBatch(ctx context.Context, f *selected.SchemaField, parents []reflect.Value, packedArgs reflect.Value) ([]reflect.Value, error) {
+	b := f.Batch
+	var in []reflect.Value
+	if b.HasContext {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+	ps := reflect.MakeSlice(reflect.SliceOf(parents[0].Type()), len(parents), len(parents))
+	for i, p := range parents {
+		ps.Index(i).Set(p)
+	}
+	in = append(in, ps)
+	if f.ArgsPacker != nil {
+		in = append(in, packedArgs)
+	}
+
+	callOut := parents[0].Method(b.MethodIndex).Call(in)
+	if b.HasError && !callOut[1].IsNil() {
+		return nil, callOut[1].Interface().(error)
+	}
+	if n := callOut[0].Len(); n != len(parents) {
+		return nil, fmt.Errorf("batch resolver for %q returned %d results for %d parents", f.Name, n, len(parents))
+	}
+
+	results := make([]reflect.Value, len(parents))
+	for i := range results {
+		results[i] = callOut[0].Index(i)
+	}
+	return results, nil
+}
+
+// resolveBatches collects the fields of all list entries and resolves every batched field with a
+// single call for all of its sibling parents. The returned fields are ready to be executed per entry,
+// nil entries are left empty.
+func (r *Request) resolveBatches(ctx context.Context, sels []selected.Selection, path *pathSegment, s *resolvable.Schema, resolver reflect.Value) [][]*fieldToExec {
+	entryFields := make([][]*fieldToExec, resolver.Len())
+	groups := make(map[*selected.SchemaField][]*fieldToExec)
+	paths := make(map[*selected.SchemaField][]*pathSegment)
+	var order []*selected.SchemaField
+	for i := range entryFields {
+		entry := resolver.Index(i)
+		if isNil(entry) {
+			continue
+		}
+		entryFields[i] = r.collectFields(sels, &pathSegment{path, i}, s, entry)
+		for _, f := range entryFields[i] {
+			if f.field.Batch == nil {
+				continue
 			}
-			if f.field.ArgsPacker != nil {
-				in = append(in, f.field.PackedArgs)
+			if _, ok := groups[f.field]; !ok {
+				order = append(order, f.field)
 			}
-			callOut := res.Method(f.field.MethodIndex).Call(in)
-			result = callOut[0]
-			if f.field.HasError && !callOut[1].IsNil() {
-				resolverErr := callOut[1].Interface().(error)
-				err := errors.Errorf("%s", resolverErr)
-				err.Path = path.toSlice()
-				err.ResolverError = resolverErr
-				if ex, ok := callOut[1].Interface().(extensionser); ok {
-					err.Extensions = ex.Extensions()
-				}
-				return err
+			groups[f.field] = append(groups[f.field], f)
+			paths[f.field] = append(paths[f.field], &pathSegment{&pathSegment{path, i}, f.field.Alias})
+		}
+	}
+
+	for _, sf := range order {
+		var wrapped, plain []*fieldToExec
+		var wrappedPaths []*pathSegment
+		for i, f := range groups[sf] {
+			if r.wrapsField(f) {
+				wrapped = append(wrapped, f)
+				wrappedPaths = append(wrappedPaths, paths[sf][i])
+				continue
 			}
-		} else {
-			// TODO extract out unwrapping ptr logic to a common place
-			if res.Kind() == reflect.Ptr {
-				res = res.Elem()
+			plain = append(plain, f)
+		}
+		if len(wrapped) != 0 {
+			r.execBatchWithMiddleware(ctx, wrapped, wrappedPaths)
+		}
+		if len(plain) != 0 {
+			r.execBatch(ctx, plain)
+		}
+	}
+	return entryFields
+}
+
+func (r *Request) execBatch(ctx context.Context, fields []*fieldToExec) {
+	r.Limiter <- struct{}{}
+	defer func() { <-r.Limiter }()
+
+	defer func() {
+		if panicValue := recover(); panicValue != nil {
+			r.Logger.LogPanic(ctx, panicValue)
+			panicErr := r.PanicHandler.MakePanicError(ctx, panicValue)
+			for _, f := range fields {
+				f.batch = &batchedResult{panicErr: panicErr}
 			}
-			result = res.FieldByIndex(f.field.FieldIndex)
 		}
-		return nil
 	}()
 
-	if applyLimiter {
-		<-r.Limiter
+	if err := ctx.Err(); err != nil {
+		return // the fields report the cancellation themselves
 	}
 
-	if err != nil {
-		// If an error occurred while resolving a field, it should be treated as though the field
-		// returned null, and an error must be added to the "errors" list in the response.
-		r.AddError(err)
-		f.out.WriteString("null")
-		return
+	parents := make([]reflect.Value, len(fields))
+	for i, f := range fields {
+		parents[i] = f.resolver
+	}
+	if fields[0].field.Batch.HasContext {
+		ctx = contextWithExecutableFieldSelection(ctx, fields[0])
+	}
+	results, err := callBatch(ctx, fields[0].field, parents, fields[0].field.PackedArgs)
+	for i, f := range fields {
+		if err != nil {
+			f.batch = &batchedResult{err: err}
+			continue
+		}
+		f.batch = &batchedResult{value: results[i]}
 	}
-
-	r.execSelectionSet(traceCtx, f.sels, f.field.Type, path, s, result, f.out)
 }
 
 func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selection, typ types.Type, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer) {
 	t, nonNull := unwrapNonNull(typ)
 
-	// a reflect.Value of a nil interface will show up as an Invalid value
-	if resolver.Kind() == reflect.Invalid || ((resolver.Kind() == reflect.Ptr || resolver.Kind() == reflect.Interface) && resolver.IsNil()) {
+	if isNil(resolver) {
 		// If a field of a non-null type resolves to null (either because the
 		// function to resolve the field returned null or because an error occurred),
 		// add an error to the "errors" list in the response.
@@ -352,6 +628,18 @@ func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *
 	l := resolver.Len()
 	entryouts := make([]bytes.Buffer, l)
 
+	var entryFields [][]*fieldToExec
+	if isComposite(typ.OfType) && selected.HasBatchSel(sels) {
+		entryFields = r.resolveBatches(ctx, sels, path, s, resolver)
+	}
+	execEntry := func(i int) {
+		if entryFields != nil && entryFields[i] != nil {
+			r.execFields(ctx, entryFields[i], &pathSegment{path, i}, s, &entryouts[i], false)
+			return
+		}
+		r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
+	}
+
 	if selected.HasAsyncSel(sels) {
 		// Limit the number of concurrent goroutines spawned as it can lead to large
 		// memory spikes for large lists.
@@ -362,7 +650,7 @@ func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *
 			go func(i int) {
 				defer func() { <-sem }()
 				defer r.handlePanic(ctx)
-				r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
+				execEntry(i)
 			}(i)
 		}
 		for i := 0; i < concurrency; i++ {
@@ -370,7 +658,7 @@ func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *
 		}
 	} else {
 		for i := 0; i < l; i++ {
-			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
+			execEntry(i)
 		}
 	}
 
@@ -383,6 +671,7 @@ func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *
 		if listOfNonNull && resolvedToNull(&entryout) {
 			out.Reset()
 			out.WriteString("null")
+			r.incremental.markNulled(path)
 			return
 		}
 
@@ -394,6 +683,22 @@ func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *
 	out.WriteByte(']')
 }
 
+// isNil reports whether the resolver value resolves to null.
+func isNil(resolver reflect.Value) bool {
+	// a reflect.Value of a nil interface will show up as an Invalid value
+	return resolver.Kind() == reflect.Invalid || ((resolver.Kind() == reflect.Ptr || resolver.Kind() == reflect.Interface) && resolver.IsNil())
+}
+
+func isComposite(t types.Type) bool {
+	t, _ = unwrapNonNull(t)
+	switch t.(type) {
+	case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition, *types.Union:
+		return true
+	default:
+		return false
+	}
+}
+
 func unwrapNonNull(t types.Type) (types.Type, bool) {
 	if nn, ok := t.(*types.NonNull); ok {
 		return nn.OfType, true
@@ -0,0 +1,282 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/exec/selected"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// IncrementalResult is the result of a single deferred fragment or streamed list item.
+type IncrementalResult struct {
+	Data   json.RawMessage
+	Items  json.RawMessage
+	Path   []interface{}
+	Label  string
+	Errors []*errors.QueryError
+}
+
+// SubsequentPayload is delivered after the initial result of an incremental request.
+type SubsequentPayload struct {
+	Incremental []*IncrementalResult
+	HasNext     bool
+}
+
+// ExecuteIncremental executes the operation like Execute, but delivers the deferred fragments and
+// streamed list items on the returned channel. The channel is nil if there is nothing to deliver
+// after the initial result, otherwise it is closed after the last payload.
+func (r *Request) ExecuteIncremental(ctx context.Context, s *resolvable.Schema, op *types.OperationDefinition) ([]byte, []*errors.QueryError, <-chan *SubsequentPayload) {
+	r.incremental = &incremental{}
+	data, errs := r.Execute(ctx, s, op)
+	if data == nil {
+		return data, errs, nil
+	}
+
+	tasks := r.incremental.take()
+	if len(tasks) == 0 {
+		return data, errs, nil
+	}
+
+	c := make(chan *SubsequentPayload)
+	go r.deliver(ctx, tasks, c)
+	return data, errs, c
+}
+
+type taskResult struct {
+	result   *IncrementalResult
+	children []*incrementalTask
+}
+
+func (r *Request) deliver(ctx context.Context, tasks []*incrementalTask, c chan<- *SubsequentPayload) {
+	defer close(c)
+
+	results := make(chan taskResult)
+	running := 0
+	start := func(t *incrementalTask) {
+		running++
+		go func() {
+			sub := r.subRequest()
+			res := t.run(ctx, sub)
+			results <- taskResult{result: res, children: sub.incremental.take()}
+		}()
+	}
+	for _, t := range tasks {
+		start(t)
+	}
+
+	for running > 0 {
+		tr := <-results
+		running--
+		if ctx.Err() == nil {
+			for _, t := range tr.children {
+				start(t)
+			}
+		}
+
+		payload := &SubsequentPayload{
+			Incremental: []*IncrementalResult{tr.result},
+			HasNext:     running > 0,
+		}
+		select {
+		case c <- payload:
+		case <-ctx.Done():
+			// keep draining the running tasks, nobody is listening anymore
+		}
+	}
+}
+
+// subRequest returns a request sharing the document, variables and settings of r, but collecting
+// its own errors and incremental tasks.
+func (r *Request) subRequest() *Request {
+	return &Request{
+		Request: selected.Request{
+			Schema:               r.Schema,
+			Doc:                  r.Doc,
+			Vars:                 r.Vars,
+			DisableIntrospection: r.DisableIntrospection,
+		},
+		Limiter:                  r.Limiter,
+		Tracer:                   r.Tracer,
+		Logger:                   r.Logger,
+		PanicHandler:             r.PanicHandler,
+		SubscribeResolverTimeout: r.SubscribeResolverTimeout,
+		FieldMiddleware:          r.FieldMiddleware,
+		MiddlewareFields:         r.MiddlewareFields,
+		incremental:              &incremental{},
+	}
+}
+
+// incremental collects the work to deliver after the current result.
+type incremental struct {
+	mu         sync.Mutex
+	tasks      []*incrementalTask
+	nulled     []*pathSegment
+	rootNulled bool
+}
+
+type incrementalTask struct {
+	path *pathSegment
+	run  func(ctx context.Context, r *Request) *IncrementalResult
+}
+
+type deferredFragment struct {
+	frag     *selected.DeferredFragment
+	resolver reflect.Value
+}
+
+func (inc *incremental) add(t *incrementalTask) {
+	inc.mu.Lock()
+	inc.tasks = append(inc.tasks, t)
+	inc.mu.Unlock()
+}
+
+// markNulled records that the value at path resolved to null, so that no incremental results are
+// delivered below it. It is a no-op if the request is not delivered incrementally.
+func (inc *incremental) markNulled(path *pathSegment) {
+	if inc == nil {
+		return
+	}
+	inc.mu.Lock()
+	if path == nil {
+		inc.rootNulled = true
+	} else {
+		inc.nulled = append(inc.nulled, path)
+	}
+	inc.mu.Unlock()
+}
+
+// take returns the collected tasks whose parent value has not been nulled.
+func (inc *incremental) take() []*incrementalTask {
+	inc.mu.Lock()
+	defer inc.mu.Unlock()
+
+	if inc.rootNulled {
+		return nil
+	}
+	var tasks []*incrementalTask
+	for _, t := range inc.tasks {
+		if !inc.isNulled(t.path) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+func (inc *incremental) isNulled(path *pathSegment) bool {
+	p := path.toSlice()
+	for _, n := range inc.nulled {
+		if hasPrefix(p, n.toSlice()) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(path, prefix []interface{}) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (inc *incremental) deferFragment(path *pathSegment, s *resolvable.Schema, d deferredFragment) {
+	inc.add(&incrementalTask{
+		path: path,
+		run: func(ctx context.Context, r *Request) *IncrementalResult {
+			var out bytes.Buffer
+			func() {
+				defer r.handlePanic(ctx)
+				r.execSelections(ctx, d.frag.Sels, path, s, d.resolver, &out, false)
+			}()
+			res := &IncrementalResult{
+				Path:  incrementalPath(path),
+				Label: d.frag.Label,
+			}
+			if err := ctx.Err(); err != nil {
+				res.Errors = []*errors.QueryError{errors.Errorf("%s", err)}
+				return res
+			}
+			res.Data = out.Bytes()
+			res.Errors = r.Errs
+			return res
+		},
+	})
+}
+
+// execStream executes the initial items of a field marked with @stream and queues the remaining
+// items. It returns false if the field value is not streamed.
+func (r *Request) execStream(ctx context.Context, s *resolvable.Schema, f *fieldToExec, path *pathSegment, result reflect.Value) bool {
+	t, _ := unwrapNonNull(f.field.Type)
+	list, ok := t.(*types.List)
+	if !ok || isNil(result) {
+		return false
+	}
+	if result.Kind() == reflect.Ptr || result.Kind() == reflect.Interface {
+		result = result.Elem()
+	}
+	n := f.field.Stream.InitialCount
+	if result.Kind() != reflect.Slice || result.Len() <= n {
+		return false
+	}
+
+	r.execList(ctx, f.sels, list, path, s, result.Slice(0, n), f.out)
+	if resolvedToNull(f.out) {
+		return true
+	}
+	r.incremental.add(r.streamItem(s, f, list, path, result, n))
+	return true
+}
+
+func (r *Request) streamItem(s *resolvable.Schema, f *fieldToExec, list *types.List, path *pathSegment, items reflect.Value, i int) *incrementalTask {
+	return &incrementalTask{
+		path: path,
+		run: func(ctx context.Context, sub *Request) *IncrementalResult {
+			itemPath := &pathSegment{path, i}
+			var out bytes.Buffer
+			func() {
+				defer sub.handlePanic(ctx)
+				sub.execSelectionSet(ctx, f.sels, list.OfType, itemPath, s, items.Index(i), &out)
+			}()
+			res := &IncrementalResult{
+				Path:  itemPath.toSlice(),
+				Label: f.field.Stream.Label,
+			}
+			if err := ctx.Err(); err != nil {
+				res.Errors = []*errors.QueryError{errors.Errorf("%s", err)}
+				return res
+			}
+			res.Errors = sub.Errs
+
+			// If a non-null item resolves to null, the stream is terminated.
+			if _, ok := list.OfType.(*types.NonNull); ok && (out.Len() == 0 || resolvedToNull(&out)) {
+				res.Items = json.RawMessage("null")
+				return res
+			}
+			res.Items = append(append([]byte{'['}, out.Bytes()...), ']')
+			if i+1 < items.Len() {
+				sub.incremental.add(r.streamItem(s, f, list, path, items, i+1))
+			}
+			return res
+		},
+	}
+}
+
+// incrementalPath returns the path of the value an incremental result belongs to. The root value
+// has an empty path.
+func incrementalPath(path *pathSegment) []interface{} {
+	if path == nil {
+		return []interface{}{}
+	}
+	return path.toSlice()
+}
@@ -0,0 +1,300 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// FieldInfo describes the field a FieldMiddleware is called for.
+type FieldInfo struct {
+	// ParentType is the name of the object type the field is resolved on. For a field selected
+	// through an interface it is the concrete object type of the parent.
+	ParentType string
+	// Field is the schema definition of the field, including its schema directives.
+	Field *types.FieldDefinition
+	// Directives are the directives of the field in the query.
+	Directives types.DirectiveList
+	// Args are the arguments of the field as provided in the query, with the variables applied.
+	Args map[string]interface{}
+	Path []interface{}
+}
+
+// Resolve calls the resolver of a field with the given arguments. Passing FieldInfo.Args calls it
+// with the arguments of the query.
+type Resolve func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+// FieldMiddleware is called instead of the resolver of every field. It may call next to invoke the
+// resolver, or return a result on its own. The result must be assignable to the Go type returned by
+// the resolver.
+type FieldMiddleware func(ctx context.Context, info FieldInfo, next Resolve) (interface{}, error)
+
+func (r *Request) resolveWithMiddleware(ctx context.Context, f *fieldToExec, path *pathSegment) (context.Context, reflect.Value, *errors.QueryError) {
+	resolvedCtx := ctx
+	var resolveErr *errors.QueryError
+	next := func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		var result reflect.Value
+		if f.field.Func != nil {
+			resolvedCtx, result, resolveErr = resolveFunc(ctx, f, path, args)
+		} else {
+			packedArgs, err := packArgs(f, args)
+			if err != nil {
+				return nil, err
+			}
+			resolvedCtx, result, resolveErr = resolveField(ctx, f, path, packedArgs)
+		}
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		if isNil(result) {
+			return nil, nil
+		}
+		return result.Interface(), nil
+	}
+
+	out, err := r.FieldMiddleware(ctx, fieldInfo(f, path), next)
+	result, qErr := middlewareResult(f, path, out, err, resolveErr)
+	return resolvedCtx, result, qErr
+}
+
+// wrapsField reports whether the field middleware is called for the field.
+func (r *Request) wrapsField(f *fieldToExec) bool {
+	if r.FieldMiddleware == nil {
+		return false
+	}
+	return r.MiddlewareFields == nil || r.MiddlewareFields(parentType(f), f.field.Name)
+}
+
+// fieldInfo returns the FieldInfo of a field, with a copy of the arguments which the middleware may
+// change in place.
+func fieldInfo(f *fieldToExec, path *pathSegment) FieldInfo {
+	return FieldInfo{
+		ParentType: parentType(f),
+		Field:      &f.field.FieldDefinition,
+		Directives: f.field.Directives,
+		Args:       copyArgs(f.field.Args),
+		Path:       path.toSlice(),
+	}
+}
+
+// parentType returns the name of the concrete object type of the parent of a field.
+func parentType(f *fieldToExec) string {
+	if len(f.field.ParentTypeAssertions) == 0 {
+		return f.field.TypeName
+	}
+	for name, a := range f.field.ParentTypeAssertions {
+		if _, ok := a.Assert(f.resolver); ok {
+			return name
+		}
+	}
+	return f.field.TypeName
+}
+
+// packArgs packs the arguments passed to next by a middleware. The arguments of the query are
+// packed already.
+func packArgs(f *fieldToExec, args map[string]interface{}) (reflect.Value, error) {
+	if f.field.ArgsPacker == nil || reflect.DeepEqual(args, f.field.Args) {
+		return f.field.PackedArgs, nil
+	}
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+	return f.field.ArgsPacker.Pack(args)
+}
+
+// middlewareResult converts the result of a middleware to the Go type returned by the resolver. The
+// error of the resolver called by next is passed through as is.
+func middlewareResult(f *fieldToExec, path *pathSegment, out interface{}, err error, resolveErr *errors.QueryError) (reflect.Value, *errors.QueryError) {
+	if err != nil {
+		if qErr, ok := err.(*errors.QueryError); ok && qErr == resolveErr {
+			return reflect.Value{}, resolveErr
+		}
+		return reflect.Value{}, makeResolverError(err, path)
+	}
+	if out == nil {
+		return reflect.Value{}, nil
+	}
+
+	result := reflect.ValueOf(out)
+	if t := f.field.ValueType(); t != nil && result.Type() != t {
+		if !result.Type().AssignableTo(t) {
+			return reflect.Value{}, makeResolverError(fmt.Errorf("field middleware returned %s for field %q, expected %s", result.Type(), f.field.Name, t), path)
+		}
+		v := reflect.New(t).Elem()
+		v.Set(result)
+		result = v
+	}
+	return result, nil
+}
+
+// batchCall is a call of next for a parent of a batched field, which waits for the batch.
+type batchCall struct {
+	ctx      context.Context
+	f        *fieldToExec
+	value    reflect.Value
+	err      error
+	panicErr *errors.QueryError
+	done     chan struct{}
+}
+
+// execBatchWithMiddleware calls the middleware for every parent of a batched field. The parents
+// whose middleware calls next with the arguments of the query are resolved with a single call of
+// the batch method, once the middleware of every parent has either called next or returned. The
+// batch method gets the context passed to next for the first of them. A parent whose middleware
+// changes the arguments, or calls next more than once, is resolved on its own.
+func (r *Request) execBatchWithMiddleware(ctx context.Context, fields []*fieldToExec, paths []*pathSegment) {
+	r.Limiter <- struct{}{}
+	defer func() { <-r.Limiter }()
+
+	if err := ctx.Err(); err != nil {
+		return // the fields report the cancellation themselves
+	}
+
+	calls := make(chan *batchCall)
+	returned := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(len(fields))
+	for i, f := range fields {
+		go func(f *fieldToExec, path *pathSegment) {
+			defer wg.Done()
+			joined := false
+			signal := func() {
+				if !joined {
+					joined = true
+					returned <- struct{}{}
+				}
+			}
+			defer func() {
+				if panicValue := recover(); panicValue != nil {
+					r.Logger.LogPanic(ctx, panicValue)
+					f.batch = &batchedResult{panicErr: r.PanicHandler.MakePanicError(ctx, panicValue)}
+				}
+				signal()
+			}()
+
+			var resolveErr *errors.QueryError
+			next := func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				var value reflect.Value
+				if !joined && reflect.DeepEqual(args, f.field.Args) {
+					joined = true
+					c := &batchCall{ctx: ctx, f: f, done: make(chan struct{})}
+					calls <- c
+					<-c.done
+					value, resolveErr = c.value, nil
+					switch {
+					case c.panicErr != nil:
+						err := *c.panicErr
+						err.Path = path.toSlice()
+						resolveErr = &err
+					case c.err != nil:
+						resolveErr = makeResolverError(c.err, path)
+					}
+				} else {
+					packedArgs, err := packArgs(f, args)
+					if err != nil {
+						return nil, err
+					}
+					if f.field.Batch.HasContext {
+						ctx = contextWithExecutableFieldSelection(ctx, f)
+					}
+					results, err := callBatch(ctx, f.field, []reflect.Value{f.resolver}, packedArgs)
+					resolveErr = nil
+					if err != nil {
+						resolveErr = makeResolverError(err, path)
+					} else {
+						value = results[0]
+					}
+				}
+				if resolveErr != nil {
+					return nil, resolveErr
+				}
+				if isNil(value) {
+					return nil, nil
+				}
+				return value.Interface(), nil
+			}
+
+			out, err := r.FieldMiddleware(ctx, fieldInfo(f, path), next)
+			result, qErr := middlewareResult(f, path, out, err, resolveErr)
+			f.batch = &batchedResult{value: result, queryErr: qErr}
+		}(f, paths[i])
+	}
+
+	var batch []*batchCall
+	for range fields {
+		select {
+		case c := <-calls:
+			batch = append(batch, c)
+		case <-returned:
+		}
+	}
+	if len(batch) != 0 {
+		r.dispatchBatch(batch)
+	}
+	wg.Wait()
+}
+
+// dispatchBatch resolves the parents of the calls with a single call of the batch method.
+func (r *Request) dispatchBatch(batch []*batchCall) {
+	ctx := batch[0].ctx
+	defer func() {
+		if panicValue := recover(); panicValue != nil {
+			r.Logger.LogPanic(ctx, panicValue)
+			panicErr := r.PanicHandler.MakePanicError(ctx, panicValue)
+			for _, c := range batch {
+				c.panicErr = panicErr
+				close(c.done)
+			}
+		}
+	}()
+
+	f := batch[0].f
+	parents := make([]reflect.Value, len(batch))
+	for i, c := range batch {
+		parents[i] = c.f.resolver
+	}
+	if f.field.Batch.HasContext {
+		ctx = contextWithExecutableFieldSelection(ctx, f)
+	}
+	results, err := callBatch(ctx, f.field, parents, f.field.PackedArgs)
+	for i, c := range batch {
+		if err != nil {
+			c.err = err
+		} else {
+			c.value = results[i]
+		}
+		close(c.done)
+	}
+}
+
+// copyArgs returns a deep copy of the arguments of a field.
+func copyArgs(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return copyArgs(v)
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i := range v {
+			c[i] = copyValue(v[i])
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -143,6 +143,9 @@ func (b *Builder) makeNonNullPacker(schemaType types.Type, reflectType reflect.T
 		if err != nil {
 			return nil, err
 		}
+		if t.IsOneOf() {
+			e.oneOf = t.Name
+		}
 		return e, nil
 
 	case *types.List:
@@ -219,6 +222,8 @@ type StructPacker struct {
 	usePtr        bool
 	defaultStruct reflect.Value
 	fields        []*structPackerField
+	// oneOf is the name of the input object if exactly one of its fields must be non-null
+	oneOf string
 }
 
 type structPackerField struct {
@@ -233,6 +238,16 @@ func (p *StructPacker) Pack(value interface{}) (reflect.Value, error) {
 	}
 
 	values := value.(map[string]interface{})
+	if p.oneOf != "" {
+		if len(values) != 1 {
+			return reflect.Value{}, errors.Errorf("exactly one field must be specified for oneOf input object %q", p.oneOf)
+		}
+		for name, value := range values {
+			if value == nil {
+				return reflect.Value{}, errors.Errorf("field %q of oneOf input object %q must be non-null", name, p.oneOf)
+			}
+		}
+	}
 	v := reflect.New(p.structType)
 	v.Elem().Set(p.defaultStruct)
 	for _, f := range p.fields {
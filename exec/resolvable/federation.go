@@ -0,0 +1,174 @@
+package resolvable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// entity is a value of the _Entity union, the resolver of an entity of the given type.
+type entity struct {
+	typeName string
+	value    reflect.Value
+}
+
+// entityResolver is a method of the root resolver which resolves a batch of representations of one
+// entity type, e.g. `func (r *Resolver) UserEntities(ctx context.Context, representations []map[string]interface{}) ([]*User, error)`.
+// It must return exactly one result per representation.
+type entityResolver struct {
+	methodIndex int
+	hasContext  bool
+	hasError    bool
+}
+
+var representationsType = reflect.TypeOf([]map[string]interface{}(nil))
+
+// isEntitiesField reports whether the field is the _entities field added to the query type by
+// schema.AddEntities.
+func isEntitiesField(s *types.Schema, typeName string, f *types.FieldDefinition) bool {
+	query, ok := s.RootOperationTypes["query"]
+	if !ok || query.TypeName() != typeName || f.Name != "_entities" {
+		return false
+	}
+	_, ok = s.Types["_Entity"].(*types.Union)
+	return ok
+}
+
+func (b *execBuilder) makeEntitiesExec(typeName string, f *types.FieldDefinition, resolverType reflect.Type) (*Field, error) {
+	union := b.schema.Types["_Entity"].(*types.Union)
+
+	resolvers := make(map[string]*entityResolver)
+	obj := &Object{
+		Name:           union.Name,
+		Fields:         make(map[string]*Field),
+		TypeAssertions: make(map[string]*TypeAssertion),
+	}
+	for _, impl := range union.UnionMemberTypes {
+		methodName := impl.Name + "Entities"
+		methodIndex := findMethod(resolverType, methodName)
+		if methodIndex == -1 {
+			return nil, fmt.Errorf("%s does not resolve entities of %q: missing method %q", resolverType, impl.Name, methodName)
+		}
+		m := resolverType.Method(methodIndex)
+		r, out, err := makeEntityResolver(m, methodIndex, resolverType.Kind() != reflect.Interface)
+		if err != nil {
+			return nil, fmt.Errorf("%s\n\tused by (%s).%s", err, resolverType, m.Name)
+		}
+		resolvers[impl.Name] = r
+
+		a := &TypeAssertion{MethodIndex: -1, typeName: impl.Name, entity: true}
+		if err := b.assignExec(&a.TypeExec, impl, out); err != nil {
+			return nil, fmt.Errorf("%s\n\tused by (%s).%s", err, resolverType, m.Name)
+		}
+		obj.TypeAssertions[impl.Name] = a
+	}
+
+	return &Field{
+		FieldDefinition: *f,
+		TypeName:        typeName,
+		MethodIndex:     -1,
+		Func:            resolveEntities(resolvers),
+		ValueExec:       &List{Elem: obj},
+		TraceLabel:      fmt.Sprintf("GraphQL field: %s.%s", typeName, f.Name),
+		valueType:       dynamicType,
+	}, nil
+}
+
+// makeEntityResolver checks the signature of an entity resolver method and returns the type of the
+// resolved entities.
+func makeEntityResolver(m reflect.Method, methodIndex int, methodHasReceiver bool) (*entityResolver, reflect.Type, error) {
+	in := make([]reflect.Type, m.Type.NumIn())
+	for i := range in {
+		in[i] = m.Type.In(i)
+	}
+	if methodHasReceiver {
+		in = in[1:] // first parameter is receiver
+	}
+
+	hasContext := len(in) > 0 && in[0] == contextType
+	if hasContext {
+		in = in[1:]
+	}
+	if len(in) == 0 || in[0] != representationsType {
+		return nil, nil, fmt.Errorf("must have `%s` argument for the representations", representationsType)
+	}
+	if len(in) > 1 {
+		return nil, nil, fmt.Errorf("too many arguments")
+	}
+
+	if m.Type.NumOut() < 1 {
+		return nil, nil, fmt.Errorf("too few return values")
+	}
+	if m.Type.NumOut() > 2 {
+		return nil, nil, fmt.Errorf("too many return values")
+	}
+	hasError := m.Type.NumOut() == 2
+	if hasError && m.Type.Out(1) != errorType {
+		return nil, nil, fmt.Errorf(`must have "error" as its last return value`)
+	}
+	out := m.Type.Out(0)
+	if out.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("must return a slice with one result per representation, got %s", out)
+	}
+
+	return &entityResolver{
+		methodIndex: methodIndex,
+		hasContext:  hasContext,
+		hasError:    hasError,
+	}, out.Elem(), nil
+}
+
+// resolveEntities returns the function resolving the _entities field. The representations are
+// grouped by their type and each group is resolved with a single call of the type's resolver.
+func resolveEntities(resolvers map[string]*entityResolver) FieldFunc {
+	return func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+		reps, _ := args["representations"].([]interface{})
+
+		var typeNames []string
+		batches := make(map[string][]map[string]interface{})
+		indexes := make(map[string][]int)
+		for i, rep := range reps {
+			m, ok := rep.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("representation %d is not an object", i)
+			}
+			name, _ := m["__typename"].(string)
+			if _, ok := resolvers[name]; !ok {
+				return nil, fmt.Errorf("representation %d: %q is not an entity type", i, name)
+			}
+			if _, ok := batches[name]; !ok {
+				typeNames = append(typeNames, name)
+			}
+			batches[name] = append(batches[name], m)
+			indexes[name] = append(indexes[name], i)
+		}
+
+		out := make([]interface{}, len(reps))
+		root := reflect.ValueOf(parent)
+		for _, name := range typeNames {
+			r := resolvers[name]
+			var in []reflect.Value
+			if r.hasContext {
+				in = append(in, reflect.ValueOf(ctx))
+			}
+			in = append(in, reflect.ValueOf(batches[name]))
+			res := root.Method(r.methodIndex).Call(in)
+			if r.hasError && !res[1].IsNil() {
+				return nil, res[1].Interface().(error)
+			}
+			if res[0].Len() != len(batches[name]) {
+				return nil, fmt.Errorf("entity resolver of %q returned %d results for %d representations", name, res[0].Len(), len(batches[name]))
+			}
+			for j, i := range indexes[name] {
+				v := res[0].Index(j)
+				if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+					continue
+				}
+				out[i] = &entity{typeName: name, value: v}
+			}
+		}
+		return out, nil
+	}
+}
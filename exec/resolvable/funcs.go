@@ -0,0 +1,133 @@
+package resolvable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// FieldFunc resolves a field without reflection. The parent is the value the field is resolved
+// on, it is nil for the fields of the root types if the schema has no root resolver.
+type FieldFunc func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error)
+
+// TypeFunc returns the name of the object type of a value of an interface or union type.
+type TypeFunc func(value interface{}) string
+
+// Funcs binds fields and abstract types to functions by type name.
+type Funcs struct {
+	Fields map[string]map[string]FieldFunc
+	Types  map[string]TypeFunc
+}
+
+func (f *Funcs) field(typeName, fieldName string) FieldFunc {
+	if f == nil {
+		return nil
+	}
+	return f.Fields[typeName][fieldName]
+}
+
+func (f *Funcs) typeFunc(typeName string) TypeFunc {
+	if f == nil {
+		return nil
+	}
+	return f.Types[typeName]
+}
+
+// check reports functions bound to types or fields which do not exist in the schema.
+func (f *Funcs) check(s *types.Schema) error {
+	if f == nil {
+		return nil
+	}
+	for typeName, fields := range f.Fields {
+		var defs types.FieldsDefinition
+		switch t := s.Types[typeName].(type) {
+		case *types.ObjectTypeDefinition:
+			defs = t.Fields
+		case *types.InterfaceTypeDefinition:
+			defs = t.Fields
+		default:
+			return fmt.Errorf("resolver map binds fields of %q, which is not an object or interface type", typeName)
+		}
+		for fieldName := range fields {
+			if defs.Get(fieldName) == nil {
+				return fmt.Errorf("resolver map binds unknown field %q of %q", fieldName, typeName)
+			}
+		}
+	}
+	for typeName := range f.Types {
+		switch s.Types[typeName].(type) {
+		case *types.InterfaceTypeDefinition, *types.Union:
+		default:
+			return fmt.Errorf("type resolver bound to %q, which is not an interface or union type", typeName)
+		}
+	}
+	return nil
+}
+
+// dynamicType is the resolver type of the values returned by field functions. Their fields can only
+// be resolved by field functions as well.
+var dynamicType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// noResolver is the root resolver of a schema which is only resolved by field functions.
+type noResolver struct{}
+
+var noResolverType = reflect.TypeOf(&noResolver{})
+
+func (b *execBuilder) makeFuncFieldExec(typeName string, f *types.FieldDefinition, fn FieldFunc, resolverType reflect.Type) (*Field, error) {
+	if sub, ok := b.schema.RootOperationTypes["subscription"]; ok && typeName == sub.TypeName() {
+		return nil, fmt.Errorf("resolver map can not resolve subscription field %q", f.Name)
+	}
+	if resolverType == noResolverType {
+		parentFn := fn
+		fn = func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			return parentFn(ctx, nil, args)
+		}
+	}
+
+	fe := &Field{
+		FieldDefinition: *f,
+		TypeName:        typeName,
+		MethodIndex:     -1,
+		Func:            fn,
+		TraceLabel:      fmt.Sprintf("GraphQL field: %s.%s", typeName, f.Name),
+		valueType:       dynamicType,
+	}
+	if err := b.assignExec(&fe.ValueExec, f.Type, dynamicType); err != nil {
+		return nil, err
+	}
+	return fe, nil
+}
+
+// makeDynamicExec returns the resolvable of a non-composite type for the values of field functions.
+func (b *execBuilder) makeDynamicExec(t types.Type) (Resolvable, error) {
+	switch t := t.(type) {
+	case *types.ScalarTypeDefinition, *types.EnumTypeDefinition:
+		return &Scalar{}, nil
+
+	case *types.List:
+		e := &List{}
+		if err := b.assignExec(&e.Elem, t.OfType, dynamicType); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	default:
+		panic("invalid type: " + t.String())
+	}
+}
+
+// dispatchFunc resolves a field of an interface on a value of the interface by calling the field
+// function of the value's object type.
+func (b *execBuilder) dispatchFunc(typeName string, fieldName string, typeFn TypeFunc) FieldFunc {
+	funcs := b.funcs
+	return func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+		name := typeFn(parent)
+		fn := funcs.field(name, fieldName)
+		if fn == nil {
+			return nil, fmt.Errorf("type resolver of %q returned %q, which does not resolve field %q", typeName, name, fieldName)
+		}
+		return fn(ctx, parent, args)
+	}
+}
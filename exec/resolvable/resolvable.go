@@ -40,15 +40,55 @@ type Field struct {
 	ArgsPacker  *packer.StructPacker
 	ValueExec   Resolvable
 	TraceLabel  string
+	Batch       *BatchMethod
+	// Func resolves the field instead of a method or struct field if it is set.
+	Func FieldFunc
+
+	argsType  reflect.Type
+	valueType reflect.Type
 }
 
 func (f *Field) UseMethodResolver() bool {
 	return len(f.FieldIndex) == 0
 }
 
+// ValueType returns the Go type of the field's resolved value.
+func (f *Field) ValueType() reflect.Type {
+	return f.valueType
+}
+
+// BatchMethod describes a resolver method which resolves a field for a set of sibling parents
+// with a single call, e.g. `func (r *User) BatchPosts(ctx context.Context, parents []*User) ([]*[]*Post, error)`.
+// The method is called on the first parent and must return exactly one result per parent.
+type BatchMethod struct {
+	MethodIndex int
+	HasContext  bool
+	HasError    bool
+}
+
 type TypeAssertion struct {
 	MethodIndex int
 	TypeExec    Resolvable
+
+	// set instead of MethodIndex for the values of field functions
+	typeName string
+	typeFn   TypeFunc
+	// set for the values of the _entities field, which wrap the resolver of their type
+	entity bool
+}
+
+// Assert converts the value of an interface or union type to the asserted object type and reports
+// whether it is of that type.
+func (a *TypeAssertion) Assert(v reflect.Value) (reflect.Value, bool) {
+	if a.entity {
+		e := v.Interface().(*entity)
+		return e.value, e.typeName == a.typeName
+	}
+	if a.typeFn != nil {
+		return v, a.typeFn(v.Interface()) == a.typeName
+	}
+	out := v.Method(a.MethodIndex).Call(nil)
+	return out[0], out[1].Bool()
 }
 
 type List struct {
@@ -62,11 +102,25 @@ func (*List) isResolvable()   {}
 func (*Scalar) isResolvable() {}
 
 func ApplyResolver(s *types.Schema, resolver interface{}) (*Schema, error) {
+	return ApplyResolverFuncs(s, resolver, nil)
+}
+
+// ApplyResolverFuncs is like ApplyResolver, but resolves the fields bound in funcs by calling the
+// functions instead of resolver methods. If only funcs are given, the root fields are resolved by
+// them without a root resolver.
+func ApplyResolverFuncs(s *types.Schema, resolver interface{}, funcs *Funcs) (*Schema, error) {
+	if err := funcs.check(s); err != nil {
+		return nil, err
+	}
 	if resolver == nil {
-		return &Schema{Meta: newMeta(s), Schema: *s}, nil
+		if funcs == nil || len(funcs.Fields) == 0 {
+			return &Schema{Meta: newMeta(s), Schema: *s}, nil
+		}
+		resolver = &noResolver{}
 	}
 
 	b := newBuilder(s)
+	b.funcs = funcs
 
 	var query, mutation, subscription Resolvable
 
@@ -106,6 +160,7 @@ type execBuilder struct {
 	schema        *types.Schema
 	resMap        map[typePair]*resMapEntry
 	packerBuilder *packer.Builder
+	funcs         *Funcs
 }
 
 type typePair struct {
@@ -167,6 +222,10 @@ func (b *execBuilder) makeExec(t types.Type, resolverType reflect.Type) (Resolva
 		return b.makeObjectExec(t.Name, nil, t.UnionMemberTypes, nonNull, resolverType)
 	}
 
+	if resolverType == dynamicType {
+		return b.makeDynamicExec(t)
+	}
+
 	if !nonNull {
 		if resolverType.Kind() != reflect.Ptr {
 			return nil, fmt.Errorf("%s is not a pointer", resolverType)
@@ -226,20 +285,55 @@ func (b *execBuilder) makeObjectExec(typeName string, fields types.FieldsDefinit
 	}
 
 	methodHasReceiver := resolverType.Kind() != reflect.Interface
+	dynamic := resolverType == dynamicType
+	var typeFn TypeFunc
+	if dynamic && len(possibleTypes) != 0 {
+		typeFn = b.funcs.typeFunc(typeName)
+		if typeFn == nil {
+			return nil, fmt.Errorf("resolver map does not resolve %q: missing type resolver", typeName)
+		}
+	}
 
 	Fields := make(map[string]*Field)
 	rt := unwrapPtr(resolverType)
 	fieldsCount := fieldCount(rt, map[string]int{})
 	for _, f := range fields {
+		if isEntitiesField(b.schema, typeName, f) {
+			fe, err := b.makeEntitiesExec(typeName, f, resolverType)
+			if err != nil {
+				return nil, err
+			}
+			Fields[f.Name] = fe
+			continue
+		}
+
+		fn := b.funcs.field(typeName, f.Name)
+		if fn == nil && typeFn != nil {
+			// a field of an interface is resolved by the functions of the object types
+			fn = b.dispatchFunc(typeName, f.Name, typeFn)
+		}
+		if fn != nil {
+			fe, err := b.makeFuncFieldExec(typeName, f, fn, resolverType)
+			if err != nil {
+				return nil, fmt.Errorf("%s\n\tused by resolver map entry %s.%s", err, typeName, f.Name)
+			}
+			Fields[f.Name] = fe
+			continue
+		}
+		if dynamic {
+			return nil, fmt.Errorf("resolver map does not resolve %q: missing function for field %q", typeName, f.Name)
+		}
+
 		var fieldIndex []int
 		methodIndex := findMethod(resolverType, f.Name)
-		if b.schema.UseFieldResolvers && methodIndex == -1 {
+		batchIndex := findBatchMethod(resolverType, typeName, fields, f.Name, b.schema)
+		if b.schema.UseFieldResolvers && methodIndex == -1 && batchIndex == -1 {
 			if fieldsCount[strings.ToLower(stripUnderscore(f.Name))] > 1 {
 				return nil, fmt.Errorf("%s does not resolve %q: ambiguous field %q", resolverType, typeName, f.Name)
 			}
 			fieldIndex = findField(rt, f.Name, []int{})
 		}
-		if methodIndex == -1 && len(fieldIndex) == 0 {
+		if methodIndex == -1 && batchIndex == -1 && len(fieldIndex) == 0 {
 			hint := ""
 			if findMethod(reflect.PtrTo(resolverType), f.Name) != -1 {
 				hint = " (hint: the method exists on the pointer type)"
@@ -251,7 +345,7 @@ func (b *execBuilder) makeObjectExec(typeName string, fields types.FieldsDefinit
 		var sf reflect.StructField
 		if methodIndex != -1 {
 			m = resolverType.Method(methodIndex)
-		} else {
+		} else if len(fieldIndex) != 0 {
 			sf = rt.FieldByIndex(fieldIndex)
 		}
 		fe, err := b.makeFieldExec(typeName, f, m, sf, methodIndex, fieldIndex, methodHasReceiver)
@@ -264,6 +358,15 @@ func (b *execBuilder) makeObjectExec(typeName string, fields types.FieldsDefinit
 			}
 			return nil, fmt.Errorf("%s\n\tused by (%s).%s", err, resolverType, resolverName)
 		}
+		if batchIndex != -1 {
+			bm := resolverType.Method(batchIndex)
+			err := b.makeBatchExec(fe, f, bm, batchIndex, resolverType, methodHasReceiver)
+			// a method which is only named like a batch method, e.g. BatchSize for the field "size",
+			// is ignored if the field has another resolver
+			if err != nil && methodIndex == -1 && len(fieldIndex) == 0 {
+				return nil, fmt.Errorf("%s\n\tused by (%s).%s", err, resolverType, bm.Name)
+			}
+		}
 		Fields[f.Name] = fe
 	}
 
@@ -271,7 +374,15 @@ func (b *execBuilder) makeObjectExec(typeName string, fields types.FieldsDefinit
 	//	1) using method resolvers
 	//	2) Or resolver is not an interface type
 	typeAssertions := make(map[string]*TypeAssertion)
-	if !b.schema.UseFieldResolvers || resolverType.Kind() != reflect.Interface {
+	if dynamic {
+		for _, impl := range possibleTypes {
+			a := &TypeAssertion{MethodIndex: -1, typeName: impl.Name, typeFn: typeFn}
+			if err := b.assignExec(&a.TypeExec, impl, dynamicType); err != nil {
+				return nil, err
+			}
+			typeAssertions[impl.Name] = a
+		}
+	} else if !b.schema.UseFieldResolvers || resolverType.Kind() != reflect.Interface {
 		for _, impl := range possibleTypes {
 			methodIndex := findMethod(resolverType, "To"+impl.Name)
 			if methodIndex == -1 {
@@ -312,6 +423,7 @@ func (b *execBuilder) makeFieldExec(typeName string, f *types.FieldDefinition, m
 	methodIndex int, fieldIndex []int, methodHasReceiver bool) (*Field, error) {
 
 	var argsPacker *packer.StructPacker
+	var argsType reflect.Type
 	var hasError bool
 	var hasContext bool
 
@@ -339,6 +451,7 @@ func (b *execBuilder) makeFieldExec(typeName string, f *types.FieldDefinition, m
 			if err != nil {
 				return nil, err
 			}
+			argsType = in[0]
 			in = in[1:]
 		}
 
@@ -372,6 +485,7 @@ func (b *execBuilder) makeFieldExec(typeName string, f *types.FieldDefinition, m
 		ArgsPacker:      argsPacker,
 		HasError:        hasError,
 		TraceLabel:      fmt.Sprintf("GraphQL field: %s.%s", typeName, f.Name),
+		argsType:        argsType,
 	}
 
 	var out reflect.Type
@@ -381,16 +495,99 @@ func (b *execBuilder) makeFieldExec(typeName string, f *types.FieldDefinition, m
 		if ok && typeName == sub.TypeName() && out.Kind() == reflect.Chan {
 			out = m.Type.Out(0).Elem()
 		}
-	} else {
+	} else if len(fieldIndex) != 0 {
 		out = sf.Type
+	} else {
+		// the field is resolved by a batch method only, see makeBatchExec
+		return fe, nil
 	}
 	if err := b.assignExec(&fe.ValueExec, f.Type, out); err != nil {
 		return nil, err
 	}
+	fe.valueType = out
 
 	return fe, nil
 }
 
+func (b *execBuilder) makeBatchExec(fe *Field, f *types.FieldDefinition, m reflect.Method, methodIndex int,
+	resolverType reflect.Type, methodHasReceiver bool) error {
+
+	in := make([]reflect.Type, m.Type.NumIn())
+	for i := range in {
+		in[i] = m.Type.In(i)
+	}
+	if methodHasReceiver {
+		in = in[1:] // first parameter is receiver
+	}
+
+	hasContext := len(in) > 0 && in[0] == contextType
+	if hasContext {
+		in = in[1:]
+	}
+
+	if len(in) == 0 || in[0] != reflect.SliceOf(resolverType) {
+		return fmt.Errorf("must have `[]%s` argument for the batched parents", resolverType)
+	}
+	in = in[1:]
+
+	// the field is only changed once the method is known to be a batch method
+	argsPacker, argsType := fe.ArgsPacker, fe.argsType
+	if len(f.Arguments) > 0 {
+		if len(in) == 0 {
+			return fmt.Errorf("must have `args struct { ... }` argument for field arguments")
+		}
+		switch {
+		case argsPacker == nil:
+			var err error
+			argsPacker, err = b.packerBuilder.MakeStructPacker(f.Arguments, in[0])
+			if err != nil {
+				return err
+			}
+			argsType = in[0]
+		case argsType != in[0]:
+			return fmt.Errorf("arguments of type %s do not match %s of the non-batched resolver", in[0], argsType)
+		}
+		in = in[1:]
+	}
+
+	if len(in) > 0 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	if m.Type.NumOut() < 1 {
+		return fmt.Errorf("too few return values")
+	}
+	if m.Type.NumOut() > 2 {
+		return fmt.Errorf("too many return values")
+	}
+	hasError := m.Type.NumOut() == 2
+	if hasError && m.Type.Out(1) != errorType {
+		return fmt.Errorf(`must have "error" as its last return value`)
+	}
+
+	out := m.Type.Out(0)
+	if out.Kind() != reflect.Slice {
+		return fmt.Errorf("must return a slice with one result per parent, got %s", out)
+	}
+	switch {
+	case fe.valueType == nil:
+		if err := b.assignExec(&fe.ValueExec, f.Type, out.Elem()); err != nil {
+			return err
+		}
+		fe.valueType = out.Elem()
+	case fe.valueType != out.Elem():
+		return fmt.Errorf("result of type %s does not match %s of the non-batched resolver", out.Elem(), fe.valueType)
+	}
+
+	fe.ArgsPacker, fe.argsType = argsPacker, argsType
+	fe.Batch = &BatchMethod{
+		MethodIndex: methodIndex,
+		HasContext:  hasContext,
+		HasError:    hasError,
+	}
+	return nil
+}
+
 func findMethod(t reflect.Type, name string) int {
 	for i := 0; i < t.NumMethod(); i++ {
 		if strings.EqualFold(stripUnderscore(name), stripUnderscore(t.Method(i).Name)) {
@@ -400,6 +597,23 @@ func findMethod(t reflect.Type, name string) int {
 	return -1
 }
 
+// findBatchMethod returns the index of the "Batch<Field>" method resolving the given field for
+// a set of parents, or -1 if there is none. Subscription fields can not be batched, and the
+// method is ignored when it is the regular resolver of another field (e.g. "batchPosts"). A method
+// whose signature does not match is ignored by the caller if the field has another resolver.
+func findBatchMethod(t reflect.Type, typeName string, fields types.FieldsDefinition, name string, s *types.Schema) int {
+	if sub, ok := s.RootOperationTypes["subscription"]; ok && typeName == sub.TypeName() {
+		return -1
+	}
+	batchName := "Batch" + name
+	for _, f := range fields {
+		if strings.EqualFold(stripUnderscore(f.Name), stripUnderscore(batchName)) {
+			return -1
+		}
+	}
+	return findMethod(t, batchName)
+}
+
 func findField(t reflect.Type, name string, index []int) []int {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
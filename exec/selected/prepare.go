@@ -0,0 +1,184 @@
+package selected
+
+import (
+	"github.com/graph-gophers/graphql-go/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// PrepareOperation applies the operation to the schema independently of the variables. The fields
+// and fragments whose arguments or directives depend on the variables are kept along with their
+// prepared selections, and BindOperation applies them to the variables of each execution. The
+// returned selections are not changed by BindOperation, so they can be shared by executions.
+func PrepareOperation(r *Request, s *resolvable.Schema, op *types.OperationDefinition) []Selection {
+	r.prepare = true
+	defer func() { r.prepare = false }()
+	return ApplyOperation(r, s, op)
+}
+
+// BindOperation returns the selections prepared by PrepareOperation for the variables of the
+// request. The selections which do not depend on the variables are shared, the document is only
+// read for the arguments and directives of the others.
+func BindOperation(r *Request, s *resolvable.Schema, sels []Selection) []Selection {
+	bound, _ := bindSelections(r, s, sels)
+	return bound
+}
+
+// varField is a prepared field whose arguments or directives depend on the variables.
+type varField struct {
+	e     *resolvable.Object
+	field *types.Field
+	sels  []Selection
+}
+
+// varFragment is a prepared fragment whose directives depend on the variables.
+type varFragment struct {
+	directives types.DirectiveList
+	sels       []Selection
+}
+
+func (*varField) isSelection()    {}
+func (*varFragment) isSelection() {}
+
+func prepareField(r *Request, s *resolvable.Schema, e *resolvable.Object, field *types.Field) *varField {
+	f := &varField{e: e, field: field}
+	if !isMetaField(field.Name.Name) {
+		f.sels = applyField(r, s, e.Fields[field.Name.Name].ValueExec, field.SelectionSet)
+	}
+	return f
+}
+
+func isMetaField(name string) bool {
+	switch name {
+	case "__typename", "__schema", "__type", "_service":
+		return true
+	}
+	return false
+}
+
+// bindSelections binds the prepared selections to the variables of the request. It returns the
+// selections as is and false if none of them depends on the variables.
+func bindSelections(r *Request, s *resolvable.Schema, sels []Selection) ([]Selection, bool) {
+	var bound []Selection
+	changed := false
+	for i, sel := range sels {
+		out, ok := bindSelection(r, s, sel)
+		if !ok {
+			if changed {
+				bound = append(bound, sel)
+			}
+			continue
+		}
+		if !changed {
+			changed = true
+			bound = append(make([]Selection, 0, len(sels)), sels[:i]...)
+		}
+		bound = append(bound, out...)
+	}
+	if !changed {
+		return sels, false
+	}
+	return bound, true
+}
+
+func bindSelection(r *Request, s *resolvable.Schema, sel Selection) ([]Selection, bool) {
+	switch sel := sel.(type) {
+	case *SchemaField:
+		sels, ok := bindSelections(r, s, sel.Sels)
+		if !ok {
+			return nil, false
+		}
+		sf := *sel
+		sf.Sels = sels
+		sf.Async = sf.FixedResult.IsValid() || asyncField(&sf.Field) || HasAsyncSel(sels)
+		return []Selection{&sf}, true
+
+	case *TypeAssertion:
+		sels, ok := bindSelections(r, s, sel.Sels)
+		if !ok {
+			return nil, false
+		}
+		ta := *sel
+		ta.Sels = sels
+		return []Selection{&ta}, true
+
+	case *DeferredFragment:
+		sels, ok := bindSelections(r, s, sel.Sels)
+		if !ok {
+			return nil, false
+		}
+		return []Selection{&DeferredFragment{Label: sel.Label, Sels: sels}}, true
+
+	case *TypenameField:
+		return nil, false
+
+	case *varField:
+		if isMetaField(sel.field.Name.Name) {
+			return applySelectionSet(r, s, sel.e, []types.Selection{sel.field}), true
+		}
+		if skipByDirective(r, sel.field.Directives) {
+			return nil, true
+		}
+		sf := schemaField(r, sel.e, sel.e.Fields[sel.field.Name.Name], sel.field)
+		if sf == nil {
+			return nil, true
+		}
+		sels, _ := bindSelections(r, s, sel.sels)
+		sf.setSels(sels)
+		return []Selection{sf}, true
+
+	case *varFragment:
+		if skipByDirective(r, sel.directives) {
+			return nil, true
+		}
+		sels, _ := bindSelections(r, s, sel.sels)
+		if label, ok := deferByDirective(r, sel.directives); ok {
+			return []Selection{&DeferredFragment{Label: label, Sels: sels}}, true
+		}
+		return sels, true
+
+	default:
+		panic("unreachable")
+	}
+}
+
+func fieldHasVariables(field *types.Field) bool {
+	return argumentsHaveVariables(field.Arguments) || directivesHaveVariables(field.Directives)
+}
+
+func directivesHaveVariables(directives types.DirectiveList) bool {
+	for _, d := range directives {
+		if argumentsHaveVariables(d.Arguments) {
+			return true
+		}
+	}
+	return false
+}
+
+func argumentsHaveVariables(args types.ArgumentList) bool {
+	for _, arg := range args {
+		if hasVariables(arg.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVariables(v types.Value) bool {
+	switch v := v.(type) {
+	case *types.Variable:
+		return true
+	case *types.ListValue:
+		for _, elem := range v.Values {
+			if hasVariables(elem) {
+				return true
+			}
+		}
+	case *types.ObjectValue:
+		for _, f := range v.Fields {
+			if hasVariables(f.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
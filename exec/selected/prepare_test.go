@@ -0,0 +1,100 @@
+package selected
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/schema"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+type prepareResolver struct{}
+
+func (r *prepareResolver) User(args struct{ ID string }) *prepareUser { return &prepareUser{} }
+
+type prepareUser struct{}
+
+func (u *prepareUser) Name() string { return "" }
+
+func (u *prepareUser) Friends(args struct{ First int32 }) []*prepareUser { return nil }
+
+func TestBindOperation(t *testing.T) {
+	s := schema.New()
+	if err := schema.Parse(s, `
+		type Query {
+			user(id: ID!): User
+		}
+
+		type User {
+			name: String!
+			friends(first: Int!): [User!]!
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+	res, err := resolvable.ApplyResolver(s, &prepareResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const queryString = `
+		query($id: ID!, $first: Int!, $withFriends: Boolean!) {
+			me: user(id: "1") { name }
+			user(id: $id) {
+				name
+				...Friends @include(if: $withFriends)
+			}
+		}
+
+		fragment Friends on User {
+			friends(first: $first) { name }
+			best: friends(first: 1) { name }
+		}
+	`
+	apply := func(vars map[string]interface{}) []*types.SelectedField {
+		doc, err := query.Parse(queryString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &Request{Schema: s, Doc: doc, Vars: vars}
+		return selsToSelectedFields(ApplyOperation(r, res, doc.Operations[0]))
+	}
+
+	doc, qErr := query.Parse(queryString)
+	if qErr != nil {
+		t.Fatal(qErr)
+	}
+	op := doc.Operations[0]
+	prepared := PrepareOperation(&Request{Schema: s, Doc: doc, Vars: map[string]interface{}{}}, res, op)
+
+	// binding only reads the arguments and directives of the fields and fragments which depend on
+	// the variables, not the selection sets of the operation and the fragments
+	op.Selections = nil
+	doc.Fragments = nil
+
+	for _, vars := range []map[string]interface{}{
+		{"id": "2", "first": int32(3), "withFriends": true},
+		{"id": "3", "first": int32(5), "withFriends": false},
+	} {
+		r := &Request{Schema: s, Doc: doc, Vars: vars}
+		got := selsToSelectedFields(BindOperation(r, res, prepared))
+		if len(r.Errs) != 0 {
+			t.Fatal(r.Errs)
+		}
+		if want := apply(vars); !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected selections for %v\ngot:  %#v\nwant: %#v", vars, got, want)
+		}
+	}
+
+	// the selections of the fields which do not depend on the variables are shared
+	r := &Request{Schema: s, Doc: doc, Vars: map[string]interface{}{"id": "2", "first": int32(3), "withFriends": true}}
+	bound := BindOperation(r, res, prepared)
+	if bound[0] != prepared[0] {
+		t.Error("expected the prepared selection of the field without variables")
+	}
+	if bound[1] == prepared[1] {
+		t.Error("expected a bound selection of the field with variables")
+	}
+}
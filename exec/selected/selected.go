@@ -20,6 +20,10 @@ type Request struct {
 	Mu                   sync.Mutex
 	Errs                 []*errors.QueryError
 	DisableIntrospection bool
+
+	// prepare is set while the selections are applied independently of the variables, see
+	// PrepareOperation.
+	prepare bool
 }
 
 func (r *Request) AddError(err *errors.QueryError) {
@@ -57,19 +61,34 @@ type SchemaField struct {
 	Sels        []Selection
 	Async       bool
 	FixedResult reflect.Value
+
+	// Stream is set if the list field is marked with @stream.
+	Stream *Stream
+
+	// ParentTypeAssertions are the type assertions of the parent if it is an interface or a union.
+	ParentTypeAssertions map[string]*resolvable.TypeAssertion
+}
+
+// Stream holds the arguments of a @stream directive.
+type Stream struct {
+	Label        string
+	InitialCount int
 }
 
 func (sf *SchemaField) ToSelectedField() *types.SelectedField {
 	if sf == nil {
 		return nil
 	}
+	// copy the directives, the selections may be shared by the executions of a prepared operation
+	directives := make(types.DirectiveList, 0, len(sf.Directives)+len(sf.Field.Directives))
+	directives = append(append(directives, sf.Directives...), sf.Field.Directives...)
 	return &types.SelectedField{
 		Name:       sf.Name,
 		TypeName:   sf.Field.Type.String(),
 		Alias:      sf.Alias,
 		Fields:     selsToSelectedFields(sf.Sels),
 		Args:       sf.Args,
-		Directives: append(sf.Directives, sf.Field.Directives...),
+		Directives: directives,
 	}
 }
 
@@ -79,6 +98,8 @@ func selsToSelectedFields(sels []Selection) (res []*types.SelectedField) {
 		switch v := f.(type) {
 		case *SchemaField:
 			res = append(res, v.ToSelectedField())
+		case *DeferredFragment:
+			res = append(res, selsToSelectedFields(v.Sels)...)
 		case *TypeAssertion:
 			// TypeAssertion is a selection dependent on the type of a union field
 			var assertedTypeName string
@@ -108,15 +129,27 @@ type TypenameField struct {
 	Alias string
 }
 
-func (*SchemaField) isSelection()   {}
-func (*TypeAssertion) isSelection() {}
-func (*TypenameField) isSelection() {}
+// DeferredFragment is a fragment marked with @defer. Its selections are executed after the initial
+// response when the request is delivered incrementally, otherwise they are executed as usual.
+type DeferredFragment struct {
+	Label string
+	Sels  []Selection
+}
+
+func (*SchemaField) isSelection()      {}
+func (*TypeAssertion) isSelection()    {}
+func (*TypenameField) isSelection()    {}
+func (*DeferredFragment) isSelection() {}
 
 func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, sels []types.Selection) (flattenedSels []Selection) {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
 		case *types.Field:
 			field := sel
+			if r.prepare && fieldHasVariables(field) {
+				flattenedSels = append(flattenedSels, prepareField(r, s, e, field))
+				continue
+			}
 			if skipByDirective(r, field.Directives) {
 				continue
 			}
@@ -178,47 +211,45 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 
 			default:
 				fe := e.Fields[field.Name.Name]
-
-				var args map[string]interface{}
-				var packedArgs reflect.Value
-				if fe.ArgsPacker != nil {
-					args = make(map[string]interface{})
-					for _, arg := range field.Arguments {
-						args[arg.Name.Name] = arg.Value.Deserialize(r.Vars)
-					}
-					var err error
-					packedArgs, err = fe.ArgsPacker.Pack(args)
-					if err != nil {
-						r.AddError(errors.Errorf("%s", err))
-						return
-					}
+				sf := schemaField(r, e, fe, field)
+				if sf == nil {
+					return
 				}
-
-				fieldSels := applyField(r, s, fe.ValueExec, field.SelectionSet)
-				flattenedSels = append(flattenedSels, &SchemaField{
-					Field:      *fe,
-					Alias:      field.Alias.Name,
-					Args:       args,
-					Directives: field.Directives,
-					PackedArgs: packedArgs,
-					Sels:       fieldSels,
-					Async:      fe.HasContext || fe.ArgsPacker != nil || fe.HasError || HasAsyncSel(fieldSels),
-				})
+				sf.setSels(applyField(r, s, fe.ValueExec, field.SelectionSet))
+				flattenedSels = append(flattenedSels, sf)
 			}
 
 		case *types.InlineFragment:
 			frag := sel
+			if r.prepare && directivesHaveVariables(frag.Directives) {
+				flattenedSels = append(flattenedSels, &varFragment{directives: frag.Directives, sels: applyFragment(r, s, e, &frag.Fragment)})
+				continue
+			}
 			if skipByDirective(r, frag.Directives) {
 				continue
 			}
-			flattenedSels = append(flattenedSels, applyFragment(r, s, e, &frag.Fragment)...)
+			fragSels := applyFragment(r, s, e, &frag.Fragment)
+			if label, ok := deferByDirective(r, frag.Directives); ok {
+				flattenedSels = append(flattenedSels, &DeferredFragment{Label: label, Sels: fragSels})
+				continue
+			}
+			flattenedSels = append(flattenedSels, fragSels...)
 
 		case *types.FragmentSpread:
 			spread := sel
+			if r.prepare && directivesHaveVariables(spread.Directives) {
+				flattenedSels = append(flattenedSels, &varFragment{directives: spread.Directives, sels: applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment)})
+				continue
+			}
 			if skipByDirective(r, spread.Directives) {
 				continue
 			}
-			flattenedSels = append(flattenedSels, applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment)...)
+			fragSels := applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment)
+			if label, ok := deferByDirective(r, spread.Directives); ok {
+				flattenedSels = append(flattenedSels, &DeferredFragment{Label: label, Sels: fragSels})
+				continue
+			}
+			flattenedSels = append(flattenedSels, fragSels...)
 
 		default:
 			panic("invalid type")
@@ -227,6 +258,70 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 	return
 }
 
+// schemaField returns the selection of a field of an object without the selections of its value, or
+// nil if the arguments can not be packed.
+func schemaField(r *Request, e *resolvable.Object, fe *resolvable.Field, field *types.Field) *SchemaField {
+	var args map[string]interface{}
+	var packedArgs reflect.Value
+	if fe.Func != nil {
+		args = funcArgs(r, fe, field)
+	} else if fe.ArgsPacker != nil {
+		args = make(map[string]interface{})
+		for _, arg := range field.Arguments {
+			args[arg.Name.Name] = arg.Value.Deserialize(r.Vars)
+		}
+		var err error
+		packedArgs, err = fe.ArgsPacker.Pack(args)
+		if err != nil {
+			r.AddError(errors.Errorf("%s", err))
+			return nil
+		}
+	}
+
+	return &SchemaField{
+		Field:      *fe,
+		Alias:      field.Alias.Name,
+		Args:       args,
+		Directives: field.Directives,
+		PackedArgs: packedArgs,
+		Async:      asyncField(fe),
+		Stream:     streamByDirective(r, field.Directives),
+
+		ParentTypeAssertions: e.TypeAssertions,
+	}
+}
+
+// setSels sets the selections of the value of the field.
+func (sf *SchemaField) setSels(sels []Selection) {
+	sf.Sels = sels
+	sf.Async = sf.Async || HasAsyncSel(sels)
+}
+
+// asyncField reports whether the resolver of a field is run in its own goroutine.
+func asyncField(fe *resolvable.Field) bool {
+	return fe.HasContext || fe.ArgsPacker != nil || fe.HasError || fe.Batch != nil || fe.Func != nil
+}
+
+// funcArgs returns the arguments of a field resolved by a function. Arguments which are not provided
+// are set to their default value if they have one.
+func funcArgs(r *Request, fe *resolvable.Field, field *types.Field) map[string]interface{} {
+	args := make(map[string]interface{}, len(fe.Arguments))
+	for _, def := range fe.Arguments {
+		v, ok := field.Arguments.Get(def.Name.Name)
+		if variable, isVar := v.(*types.Variable); isVar {
+			_, ok = r.Vars[variable.Name]
+		}
+		if ok {
+			args[def.Name.Name] = v.Deserialize(r.Vars)
+			continue
+		}
+		if def.Default != nil {
+			args[def.Name.Name] = def.Default.Deserialize(nil)
+		}
+	}
+	return args
+}
+
 func applyFragment(r *Request, s *resolvable.Schema, e *resolvable.Object, frag *types.Fragment) []Selection {
 	if frag.On.Name != e.Name {
 		t := r.Schema.Resolve(frag.On.Name)
@@ -304,6 +399,64 @@ func skipByDirective(r *Request, directives types.DirectiveList) bool {
 	return false
 }
 
+// deferByDirective reports whether the fragment is deferred by an enabled @defer directive and
+// returns its label.
+func deferByDirective(r *Request, directives types.DirectiveList) (string, bool) {
+	d := directives.Get("defer")
+	if d == nil || !directiveEnabled(r, d) {
+		return "", false
+	}
+	return directiveLabel(r, d), true
+}
+
+// streamByDirective returns the arguments of an enabled @stream directive or nil.
+func streamByDirective(r *Request, directives types.DirectiveList) *Stream {
+	d := directives.Get("stream")
+	if d == nil || !directiveEnabled(r, d) {
+		return nil
+	}
+
+	stream := &Stream{Label: directiveLabel(r, d)}
+	if arg, ok := d.Arguments.Get("initialCount"); ok {
+		p := packer.ValuePacker{ValueType: reflect.TypeOf(int32(0))}
+		v, err := p.Pack(arg.Deserialize(r.Vars))
+		if err != nil {
+			r.AddError(errors.Errorf("%s", err))
+			return nil
+		}
+		if v.Int() < 0 {
+			r.AddError(errors.Errorf("initialCount must be a positive integer, got %d", v.Int()))
+			return nil
+		}
+		stream.InitialCount = int(v.Int())
+	}
+	return stream
+}
+
+// directiveEnabled evaluates the optional "if" argument of @defer and @stream, which defaults to true.
+func directiveEnabled(r *Request, d *types.Directive) bool {
+	arg, ok := d.Arguments.Get("if")
+	if !ok {
+		return true
+	}
+	p := packer.ValuePacker{ValueType: reflect.TypeOf(false)}
+	v, err := p.Pack(arg.Deserialize(r.Vars))
+	if err != nil {
+		r.AddError(errors.Errorf("%s", err))
+		return false
+	}
+	return v.Bool()
+}
+
+func directiveLabel(r *Request, d *types.Directive) string {
+	arg, ok := d.Arguments.Get("label")
+	if !ok {
+		return ""
+	}
+	label, _ := arg.Deserialize(r.Vars).(string)
+	return label
+}
+
 func HasAsyncSel(sels []Selection) bool {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
@@ -315,8 +468,39 @@ func HasAsyncSel(sels []Selection) bool {
 			if HasAsyncSel(sel.Sels) {
 				return true
 			}
+		case *DeferredFragment:
+			if HasAsyncSel(sel.Sels) {
+				return true
+			}
 		case *TypenameField:
 			// sync
+		case *varField, *varFragment:
+			return true // only during PrepareOperation, the bound selections are checked again
+		default:
+			panic("unreachable")
+		}
+	}
+	return false
+}
+
+// HasBatchSel reports whether any of the given selections is resolved by a batch method.
+func HasBatchSel(sels []Selection) bool {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *SchemaField:
+			if sel.Batch != nil {
+				return true
+			}
+		case *TypeAssertion:
+			if HasBatchSel(sel.Sels) {
+				return true
+			}
+		case *DeferredFragment:
+			if HasBatchSel(sel.Sels) {
+				return true
+			}
+		case *TypenameField:
+			// never batched
 		default:
 			panic("unreachable")
 		}
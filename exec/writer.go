@@ -0,0 +1,394 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/exec/selected"
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// ExecuteTo executes the operation like Execute, but writes the data to w while it is executed.
+// The fields of an object are resolved concurrently and written in document order, the values of
+// fields and list entries are written as soon as they are resolved. The values of sibling fields with
+// asynchronous fields of their own are executed concurrently too, see orderedWriter. Since a null propagates to the
+// parent of a non-null field or list entry, a value which can still become null that way, e.g. a list
+// of non-null objects with non-null fields, is collected before it is written. The returned error is
+// the first error returned by w.
+func (r *Request) ExecuteTo(ctx context.Context, s *resolvable.Schema, op *types.OperationDefinition, w io.Writer) ([]*errors.QueryError, error) {
+	ew := &errWriter{w: w}
+	func() {
+		defer r.handlePanic(ctx)
+		sels := r.applyOperation(s, op)
+		fields := r.collectFields(sels, nil, s, s.Resolver)
+		if op.Type == query.Mutation {
+			r.writeFieldsSerially(ctx, fields, s, ew)
+			return
+		}
+		r.writeFields(ctx, fields, nil, s, ew)
+	}()
+
+	if !ew.written {
+		// panicked before anything was written
+		ew.WriteString("null")
+	}
+	if err := ctx.Err(); err != nil {
+		r.AddError(errors.Errorf("%s", err))
+	}
+	return r.Errs, ew.err
+}
+
+// fieldValue is the resolved value of a field, which is written once the fields before it are
+// written. If the value is executed in advance, it is written from the output of the field.
+type fieldValue struct {
+	ctx    context.Context
+	value  reflect.Value
+	finish tracer.FieldFinishFunc
+}
+
+// writeFields writes the object of the fields to w. The object is null if a non-null field resolves
+// to null.
+func (r *Request) writeFields(ctx context.Context, fields []*fieldToExec, path *pathSegment, s *resolvable.Schema, w *errWriter) {
+	values := make([]*fieldValue, len(fields))
+	ordered := make([]*orderedWriter, len(fields))
+	if hasAsyncField(fields) {
+		// the values of several fields with asynchronous fields of their own are executed as soon as
+		// they are resolved, see orderedWriter
+		concurrent := 0
+		for _, f := range fields {
+			if selected.HasAsyncSel(f.sels) {
+				concurrent++
+			}
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(len(fields))
+		for i, f := range fields {
+			if concurrent > 1 && selected.HasAsyncSel(f.sels) {
+				ordered[i] = &orderedWriter{w: w, done: make(chan struct{})}
+			}
+			go func(i int, f *fieldToExec) {
+				func() {
+					defer wg.Done()
+					defer r.handlePanic(ctx)
+					values[i] = r.resolveFieldValue(ctx, s, f, &pathSegment{path, f.field.Alias})
+				}()
+				if o := ordered[i]; o != nil {
+					defer close(o.done)
+					if v := values[i]; v != nil {
+						defer r.handlePanic(v.ctx)
+						r.writeSelectionSet(v.ctx, f.sels, f.field.Type, &pathSegment{path, f.field.Alias}, s, v.value, &errWriter{w: o})
+						v.finish(nil)
+					}
+				}
+			}(i, f)
+		}
+		wg.Wait()
+	} else {
+		for i, f := range fields {
+			values[i] = r.resolveFieldValue(ctx, s, f, &pathSegment{path, f.field.Alias})
+		}
+	}
+
+	// see execFields, a non-null field resolving to null nulls the object
+	for i, f := range fields {
+		if _, ok := f.field.Type.(*types.NonNull); ok && values[i] == nil && resolvedToNull(f.out) {
+			for i, v := range values {
+				if o := ordered[i]; o != nil {
+					o.discard()
+					continue
+				}
+				if v != nil {
+					v.finish(nil)
+				}
+			}
+			w.WriteString("null")
+			r.incremental.markNulled(path)
+			return
+		}
+	}
+
+	w.WriteString("{")
+	for i, f := range fields {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString(`"`)
+		w.WriteString(f.field.Alias)
+		w.WriteString(`":`)
+		if v := values[i]; v != nil {
+			if o := ordered[i]; o != nil {
+				o.flush()
+				continue
+			}
+			r.writeSelectionSet(v.ctx, f.sels, f.field.Type, &pathSegment{path, f.field.Alias}, s, v.value, w)
+			v.finish(nil)
+			continue
+		}
+		w.Write(f.out.Bytes())
+		f.out = nil // release the output as early as possible
+	}
+	w.WriteString("}")
+}
+
+// writeFieldsSerially writes the object of the fields of a mutation to w. Every field is complete
+// before the next one is resolved.
+func (r *Request) writeFieldsSerially(ctx context.Context, fields []*fieldToExec, s *resolvable.Schema, w *errWriter) {
+	for _, f := range fields {
+		if _, ok := f.field.Type.(*types.NonNull); ok {
+			// the data can only be written once the non-null fields are complete
+			var out bytes.Buffer
+			r.execFields(ctx, fields, nil, s, &out, true)
+			w.Write(out.Bytes())
+			return
+		}
+	}
+
+	w.WriteString("{")
+	for i, f := range fields {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString(`"`)
+		w.WriteString(f.field.Alias)
+		w.WriteString(`":`)
+		path := &pathSegment{nil, f.field.Alias}
+		if v := r.resolveFieldValue(ctx, s, f, path); v != nil {
+			r.writeSelectionSet(v.ctx, f.sels, f.field.Type, path, s, v.value, w)
+			v.finish(nil)
+			continue
+		}
+		w.Write(f.out.Bytes())
+		f.out = nil
+	}
+	w.WriteString("}")
+}
+
+// resolveFieldValue resolves the field. The value is returned if it can be written later on,
+// otherwise it is executed into the output of the field right away and nil is returned. This is the
+// case for errors and for values of non-null fields which can still become null.
+func (r *Request) resolveFieldValue(ctx context.Context, s *resolvable.Schema, f *fieldToExec, path *pathSegment) *fieldValue {
+	f.out = new(bytes.Buffer)
+	traceCtx, finish := r.Tracer.TraceField(ctx, f.field.TraceLabel, f.field.TypeName, f.field.Name, !f.field.Async, f.field.Args)
+	traceCtx, result, err := r.resolveFieldSelection(ctx, traceCtx, f, path, true)
+	if err != nil {
+		r.AddError(err)
+		f.out.WriteString("null")
+		finish(err)
+		return nil
+	}
+
+	if _, ok := f.field.Type.(*types.NonNull); ok && canBeNulled(f.sels, f.field.Type, result) {
+		r.execSelectionSet(traceCtx, f.sels, f.field.Type, path, s, result, f.out)
+		finish(nil)
+		return nil
+	}
+	return &fieldValue{ctx: traceCtx, value: result, finish: finish}
+}
+
+// writeSelectionSet writes the value to w like execSelectionSet. The value must not be able to null
+// its parent unless it is nullable.
+func (r *Request) writeSelectionSet(ctx context.Context, sels []selected.Selection, typ types.Type, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, w *errWriter) {
+	t, _ := unwrapNonNull(typ)
+	if !isNil(resolver) {
+		switch t := t.(type) {
+		case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition, *types.Union:
+			r.writeFields(ctx, r.collectFields(sels, path, s, resolver), path, s, w)
+			return
+
+		case *types.List:
+			if resolver.Kind() == reflect.Ptr || resolver.Kind() == reflect.Interface {
+				resolver = resolver.Elem()
+			}
+			if !canBeNulled(sels, t, resolver) {
+				r.writeList(ctx, sels, t, path, s, resolver, w)
+				return
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	r.execSelectionSet(ctx, sels, typ, path, s, resolver, &out)
+	w.Write(out.Bytes())
+}
+
+// writeList writes the entries of the list to w one after another. The entries must not be able to
+// null the list. Entries with asynchronous fields are executed concurrently in chunks, see execList,
+// and each chunk is collected before it is written.
+func (r *Request) writeList(ctx context.Context, sels []selected.Selection, typ *types.List, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, w *errWriter) {
+	l := resolver.Len()
+
+	var entryFields [][]*fieldToExec
+	if isComposite(typ.OfType) && selected.HasBatchSel(sels) {
+		entryFields = r.resolveBatches(ctx, sels, path, s, resolver)
+	}
+
+	w.WriteString("[")
+	if !selected.HasAsyncSel(sels) {
+		for i := 0; i < l; i++ {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			if entryFields != nil && entryFields[i] != nil {
+				r.writeFields(ctx, entryFields[i], &pathSegment{path, i}, s, w)
+				continue
+			}
+			r.writeSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), w)
+		}
+		w.WriteString("]")
+		return
+	}
+
+	chunk := cap(r.Limiter)
+	for start := 0; start < l; start += chunk {
+		end := start + chunk
+		if end > l {
+			end = l
+		}
+		entryouts := make([]bytes.Buffer, end-start)
+		var wg sync.WaitGroup
+		wg.Add(end - start)
+		for i := start; i < end; i++ {
+			go func(i int, out *bytes.Buffer) {
+				defer wg.Done()
+				defer r.handlePanic(ctx)
+				if entryFields != nil && entryFields[i] != nil {
+					r.execFields(ctx, entryFields[i], &pathSegment{path, i}, s, out, false)
+					return
+				}
+				r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), out)
+			}(i, &entryouts[i-start])
+		}
+		wg.Wait()
+
+		for i := range entryouts {
+			if start+i > 0 {
+				w.WriteString(",")
+			}
+			w.Write(entryouts[i].Bytes())
+		}
+	}
+	w.WriteString("]")
+}
+
+// canBeNulled reports whether the resolved value of the type may still become null because a null
+// propagates to it from one of its fields or list entries.
+func canBeNulled(sels []selected.Selection, typ types.Type, resolver reflect.Value) bool {
+	if isNil(resolver) {
+		return true
+	}
+
+	t, _ := unwrapNonNull(typ)
+	switch t := t.(type) {
+	case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition, *types.Union:
+		return hasNonNullField(sels)
+
+	case *types.List:
+		if resolver.Kind() == reflect.Ptr || resolver.Kind() == reflect.Interface {
+			resolver = resolver.Elem()
+		}
+		if _, ok := t.OfType.(*types.NonNull); !ok {
+			return false
+		}
+		for i := 0; i < resolver.Len(); i++ {
+			if canBeNulled(sels, t.OfType, resolver.Index(i)) {
+				return true
+			}
+		}
+		return false
+
+	case *types.EnumTypeDefinition:
+		// an invalid enum value resolves to null
+		return true
+
+	default:
+		return false
+	}
+}
+
+// hasNonNullField reports whether any of the selected fields has a non-null type.
+func hasNonNullField(sels []selected.Selection) bool {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *selected.SchemaField:
+			if _, ok := sel.Type.(*types.NonNull); ok {
+				return true
+			}
+		case *selected.TypeAssertion:
+			if hasNonNullField(sel.Sels) {
+				return true
+			}
+		case *selected.DeferredFragment:
+			if hasNonNullField(sel.Sels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orderedWriter is the writer of a value which is written concurrently with its siblings. The output
+// is buffered until the values before it are written, then it is forwarded to w as it is written, so
+// only the writes are serialized.
+type orderedWriter struct {
+	mu     sync.Mutex
+	w      *errWriter
+	buf    bytes.Buffer
+	direct bool
+	done   chan struct{}
+}
+
+func (o *orderedWriter) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.direct {
+		return o.w.Write(p)
+	}
+	return o.buf.Write(p)
+}
+
+// flush writes the buffered output to w, forwards the rest of the output and returns once the value
+// is written.
+func (o *orderedWriter) flush() {
+	o.mu.Lock()
+	o.w.Write(o.buf.Bytes())
+	o.buf = bytes.Buffer{}
+	o.direct = true
+	o.mu.Unlock()
+	<-o.done
+}
+
+// discard drops the output of the value, which is not written since its parent is null, and returns
+// once the value is executed.
+func (o *orderedWriter) discard() {
+	<-o.done
+	o.buf = bytes.Buffer{}
+}
+
+// errWriter remembers the first write error and skips all writes after it.
+type errWriter struct {
+	w       io.Writer
+	err     error
+	written bool
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	w.written = true
+	if w.err != nil {
+		return 0, w.err
+	}
+	var n int
+	n, w.err = w.w.Write(p)
+	return n, w.err
+}
+
+func (w *errWriter) WriteString(s string) {
+	w.Write([]byte(s))
+}
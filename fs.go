@@ -0,0 +1,32 @@
+//go:build go1.16
+// +build go1.16
+
+package graphql
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+// ParseSchemaFS parses the schema from the files of fsys matching the pattern, like
+// ParseSchemaFiles. The files are parsed in lexical order, see fs.Glob for the pattern syntax.
+func ParseSchemaFS(fsys fs.FS, pattern string, resolver interface{}, opts ...SchemaOpt) (*Schema, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no schema files match %q", pattern)
+	}
+	sources := make([]schema.Source, len(names))
+	for i, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = schema.Source{Name: name, Body: string(b)}
+	}
+	return parseSchemaSources(sources, resolver, opts)
+}
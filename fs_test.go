@@ -0,0 +1,94 @@
+//go:build go1.16
+// +build go1.16
+
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type userResolver struct{}
+
+func (*userResolver) User() *userResolver { return &userResolver{} }
+func (*userResolver) ID() graphql.ID      { return "1" }
+func (*userResolver) Name() string        { return "Alice" }
+
+func TestParseSchemaFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schema/query.graphql": {Data: []byte(`type Query { user: User }`)},
+		"schema/user.graphql":  {Data: []byte("type User {\n  id: ID!\n}\n\nextend type User {\n  name: String!\n}")},
+		"schema/README.md":     {Data: []byte(`not a schema`)},
+	}
+
+	s, err := graphql.ParseSchemaFS(fsys, "schema/*.graphql", &userResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := s.Exec(context.Background(), `{ user { id name } }`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	if got, want := string(resp.Data), `{"user":{"id":"1","name":"Alice"}}`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	fsys["schema/user.graphql"] = &fstest.MapFile{Data: []byte("type User {\n  id: Identifier!\n}")}
+	_, err = graphql.ParseSchemaFS(fsys, "schema/*.graphql", &userResolver{})
+	if want := `graphql: Unknown type "Identifier". (schema/user.graphql, line 2, column 7)`; err == nil || err.Error() != want {
+		t.Fatalf("got error %q, want %q", err, want)
+	}
+
+	if _, err := graphql.ParseSchemaFS(fsys, "*.gql", &userResolver{}); err == nil || err.Error() != `no schema files match "*.gql"` {
+		t.Fatalf("got error %q", err)
+	}
+}
+
+func TestParseSchemaFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"query.graphql": `type Query { user: User }`,
+		"user.graphql":  `type User { id: ID! name: String! }`,
+	}
+	var names []string
+	for name, sdl := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(sdl), 0600); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, path)
+	}
+
+	s, err := graphql.ParseSchemaFiles(names, &userResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Type struct {
+			Fields []struct{ Name string }
+		} `json:"__type"`
+	}
+	resp := s.Exec(context.Background(), `{ __type(name: "User") { fields { name } } }`, "", nil)
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Type.Fields) != 2 {
+		t.Fatalf("got %s", resp.Data)
+	}
+
+	if _, err := graphql.ParseSchemaFiles([]string{filepath.Join(dir, "missing.graphql")}, nil); !os.IsNotExist(err) {
+		t.Fatalf("got error %v, want a not exist error", err)
+	}
+}
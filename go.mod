@@ -3,6 +3,7 @@ module github.com/graph-gophers/graphql-go
 go 1.13
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	github.com/opentracing/opentracing-go v1.2.0
 	go.opentelemetry.io/otel v1.6.3
 	go.opentelemetry.io/otel/trace v1.6.3
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"time"
 
 	"github.com/graph-gophers/graphql-go/common"
@@ -25,6 +27,32 @@ import (
 // the Go type signature of the resolvers does not match the schema. If nil is passed as the
 // resolver, then the schema can not be executed, but it may be inspected (e.g. with ToJSON).
 func ParseSchema(schemaString string, resolver interface{}, opts ...SchemaOpt) (*Schema, error) {
+	return parseSchema(func(s *types.Schema, useStringDescriptions bool) error {
+		return schema.Parse(s, schemaString, useStringDescriptions)
+	}, resolver, opts)
+}
+
+// ParseSchemaFiles parses the schema from several files, like ParseSchema. The types of a file may be
+// extended in any of the files. Errors report the file names of their locations.
+func ParseSchemaFiles(filenames []string, resolver interface{}, opts ...SchemaOpt) (*Schema, error) {
+	sources := make([]schema.Source, len(filenames))
+	for i, name := range filenames {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = schema.Source{Name: name, Body: string(b)}
+	}
+	return parseSchemaSources(sources, resolver, opts)
+}
+
+func parseSchemaSources(sources []schema.Source, resolver interface{}, opts []SchemaOpt) (*Schema, error) {
+	return parseSchema(func(s *types.Schema, useStringDescriptions bool) error {
+		return schema.ParseSources(s, sources, useStringDescriptions)
+	}, resolver, opts)
+}
+
+func parseSchema(parse func(s *types.Schema, useStringDescriptions bool) error, resolver interface{}, opts []SchemaOpt) (*Schema, error) {
 	s := &Schema{
 		schema:         schema.New(),
 		maxParallelism: 10,
@@ -44,14 +72,31 @@ func ParseSchema(schemaString string, resolver interface{}, opts ...SchemaOpt) (
 		}
 	}
 
-	if err := schema.Parse(s.schema, schemaString, s.useStringDescriptions); err != nil {
+	if err := parse(s.schema, s.useStringDescriptions); err != nil {
 		return nil, err
 	}
 	if err := s.validateSchema(); err != nil {
 		return nil, err
 	}
+	if s.federation {
+		if err := schema.AddEntities(s.schema); err != nil {
+			return nil, err
+		}
+		s.schema.SchemaString = schema.Print(s.schema, schema.PrintOptions{Federation: true})
+	}
+	if len(s.directives) != 0 {
+		m, fields, err := s.directiveMiddleware()
+		if err != nil {
+			return nil, err
+		}
+		if s.fieldMiddleware == nil {
+			// only the fields with directives need to be wrapped
+			s.middlewareFields = fields
+		}
+		s.fieldMiddleware = chainFieldMiddleware(s.fieldMiddleware, m)
+	}
 
-	r, err := resolvable.ApplyResolver(s.schema, resolver)
+	r, err := resolvable.ApplyResolverFuncs(s.schema, resolver, &s.funcs)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +120,7 @@ type Schema struct {
 	res    *resolvable.Schema
 
 	maxDepth                 int
+	maxComplexity            int
 	maxParallelism           int
 	tracer                   tracer.Tracer
 	validationTracer         tracer.ValidationTracer
@@ -83,12 +129,24 @@ type Schema struct {
 	useStringDescriptions    bool
 	disableIntrospection     bool
 	subscribeResolverTimeout time.Duration
+	queryCache               *queryCache
+	fieldMiddleware          exec.FieldMiddleware
+	middlewareFields         func(parentType, fieldName string) bool
+	directives               map[string]DirectiveFunc
+	funcs                    resolvable.Funcs
+	federation               bool
 }
 
 func (s *Schema) ASTSchema() *types.Schema {
 	return s.schema
 }
 
+// ToSDL returns the schema in the GraphQL schema definition language, without the built-in types
+// and directives. Descriptions are printed as strings.
+func (s *Schema) ToSDL() string {
+	return schema.Print(s.schema, schema.PrintOptions{})
+}
+
 // SchemaOpt is an option to pass to ParseSchema or MustParseSchema.
 type SchemaOpt func(*Schema)
 
@@ -116,6 +174,16 @@ func MaxDepth(n int) SchemaOpt {
 	}
 }
 
+// MaxComplexity specifies the maximum static cost of an operation. It adds the @cost and @listSize
+// directives to the schema, which are used to compute the cost. The cost is reported in the
+// response extensions. The default is 0 which disables the cost analysis.
+func MaxComplexity(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxComplexity = n
+		schema.AddCostDirectives(s.schema)
+	}
+}
+
 // MaxParallelism specifies the maximum number of resolvers per request allowed to run in parallel. The default is 10.
 func MaxParallelism(n int) SchemaOpt {
 	return func(s *Schema) {
@@ -160,6 +228,91 @@ func DisableIntrospection() SchemaOpt {
 	}
 }
 
+// IncrementalDelivery adds the @defer and @stream directives to the schema. Use ExecIncremental to
+// deliver the deferred fragments and streamed list items after the initial response.
+func IncrementalDelivery() SchemaOpt {
+	return func(s *Schema) {
+		schema.AddIncrementalDirectives(s.schema)
+	}
+}
+
+// Federation makes the schema an Apollo Federation subgraph. It adds the _Any and _FieldSet
+// scalars and the @key, @external, @requires, @provides and @extends directives. The object types
+// with a @key directive are entities, which the gateway resolves with the _entities field of the
+// query type. The root resolver must resolve the entities of each type T with a batched method:
+//
+//	func (r *Resolver) TEntities(ctx context.Context, representations []map[string]interface{}) ([]*T, error)
+//
+// The representations contain the "__typename" and the key fields of the entities, the method must
+// return one result per representation in the same order. The context argument is optional.
+//
+// A federation v2 subgraph links to the specification with
+//
+//	extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key", "@shareable"])
+//
+// which replaces the v1 directives with the v2 ones, e.g. @shareable, @inaccessible, @override, @tag
+// and @interfaceObject. The directives which are not imported are namespaced, e.g. @federation__tag.
+// The _service field returns the schema printed with schema.PrintOptions.Federation.
+func Federation() SchemaOpt {
+	return func(s *Schema) {
+		s.federation = true
+		schema.AddFederation(s.schema)
+	}
+}
+
+// QueryCache enables a cache of up to size parsed and validated queries, keyed by the query string.
+// Only the validation of the variable values is repeated for cached queries.
+func QueryCache(size int) SchemaOpt {
+	return func(s *Schema) {
+		s.queryCache = newQueryCache(size)
+	}
+}
+
+// QueryCacheStats returns the hit and miss counters of the query cache. They are zero if the cache
+// is not enabled with QueryCache.
+func (s *Schema) QueryCacheStats() QueryCacheStats {
+	if s.queryCache == nil {
+		return QueryCacheStats{}
+	}
+	return s.queryCache.stats()
+}
+
+// FieldInfo describes the field a field middleware is called for.
+type FieldInfo = exec.FieldInfo
+
+// Resolve calls the resolver of a field with the given arguments.
+type Resolve = exec.Resolve
+
+// FieldMiddleware is called instead of the resolver of every field, except for __typename and the
+// introspection entry points. It may call next to invoke the resolver, possibly with changed
+// arguments, or return a result on its own. The result must be assignable to the Go type returned by
+// the resolver. Multiple middlewares are called in the given order. FieldInfo.Args is a copy owned by
+// the call and may be changed before it is passed to next.
+//
+// For a field with a batch resolver the middleware is called for every parent concurrently. The
+// parents whose middleware calls next with unchanged arguments are resolved together with a single
+// call of the batch method, the others are resolved on their own.
+func FieldMiddleware(middlewares ...func(ctx context.Context, info FieldInfo, next Resolve) (interface{}, error)) SchemaOpt {
+	return func(s *Schema) {
+		for _, m := range middlewares {
+			s.fieldMiddleware = chainFieldMiddleware(s.fieldMiddleware, m)
+		}
+	}
+}
+
+func chainFieldMiddleware(outer, inner exec.FieldMiddleware) exec.FieldMiddleware {
+	if outer == nil {
+		return inner
+	}
+	return func(ctx context.Context, info FieldInfo, next Resolve) (interface{}, error) {
+		return outer(ctx, info, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			info := info
+			info.Args = args
+			return inner(ctx, info, next)
+		})
+	}
+}
+
 // SubscribeResolverTimeout is an option to control the amount of time
 // we allow for a single subscribe message resolver to complete it's job
 // before it times out and returns an error to the subscriber.
@@ -176,6 +329,38 @@ type Response struct {
 	Errors     []*errors.QueryError   `json:"errors,omitempty"`
 	Data       json.RawMessage        `json:"data,omitempty"`
 	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	// HasNext is set by ExecIncremental if subsequent responses follow.
+	HasNext *bool `json:"hasNext,omitempty"`
+}
+
+// SubsequentResponse is a response delivered by ExecIncremental after the initial response.
+type SubsequentResponse struct {
+	Incremental []*IncrementalResult `json:"incremental,omitempty"`
+	HasNext     bool                 `json:"hasNext"`
+}
+
+// IncrementalResult holds the data of a deferred fragment or the items of a streamed list. Path is
+// the path of the fragment's object, or of the first streamed item.
+type IncrementalResult struct {
+	Errors []*errors.QueryError `json:"errors,omitempty"`
+	Data   json.RawMessage      `json:"data,omitempty"`
+	Items  json.RawMessage      `json:"items,omitempty"`
+	Path   []interface{}        `json:"path"`
+	Label  string               `json:"label,omitempty"`
+}
+
+func newSubsequentResponse(p *exec.SubsequentPayload) *SubsequentResponse {
+	resp := &SubsequentResponse{HasNext: p.HasNext}
+	for _, r := range p.Incremental {
+		resp.Incremental = append(resp.Incremental, &IncrementalResult{
+			Errors: r.Errors,
+			Data:   r.Data,
+			Items:  r.Items,
+			Path:   r.Path,
+			Label:  r.Label,
+		})
+	}
+	return resp
 }
 
 // Validate validates the given query with the schema.
@@ -203,22 +388,160 @@ func (s *Schema) Exec(ctx context.Context, queryString string, operationName str
 	return s.exec(ctx, queryString, operationName, variables, s.res)
 }
 
+// ExecIncremental executes the given query like Exec, but delivers the fragments marked with @defer
+// and the list items marked with @stream after the initial response. The returned channel is nil if
+// nothing is delivered after the initial response. Otherwise it is closed after the last subsequent
+// response, which has HasNext set to false. The caller must read the channel until it is closed or
+// cancel the context to stop the delivery. The schema must be created with IncrementalDelivery.
+func (s *Schema) ExecIncremental(ctx context.Context, queryString string, operationName string, variables map[string]interface{}) (*Response, <-chan *SubsequentResponse) {
+	if !s.res.Resolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+	req, resp := s.prepareRequest(ctx, queryString, operationName, variables)
+	if resp != nil {
+		return resp, nil
+	}
+	data, errs, payloads := req.exec.ExecuteIncremental(req.ctx, s.res, req.op)
+	req.finish(errs)
+	resp = &Response{Data: data, Errors: errs, Extensions: req.extensions}
+	if payloads == nil {
+		return resp, nil
+	}
+
+	hasNext := true
+	resp.HasNext = &hasNext
+	c := make(chan *SubsequentResponse)
+	go func() {
+		defer close(c)
+		for p := range payloads {
+			select {
+			case c <- newSubsequentResponse(p):
+			case <-ctx.Done():
+				// nobody is reading anymore, drain the payloads until the delivery stops
+			}
+		}
+	}()
+	return resp, c
+}
+
 func (s *Schema) exec(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema) *Response {
-	doc, qErr := query.Parse(queryString)
-	if qErr != nil {
-		return &Response{Errors: []*errors.QueryError{qErr}}
+	req, resp := s.prepareRequest(ctx, queryString, operationName, variables)
+	if resp != nil {
+		return resp
 	}
+	data, errs := req.exec.Execute(req.ctx, res, req.op)
+	req.finish(errs)
 
-	validationFinish := s.validationTracer.TraceValidation(ctx)
-	errs := validation.Validate(s.schema, doc, variables, s.maxDepth)
-	validationFinish(errs)
+	return &Response{
+		Data:       data,
+		Errors:     errs,
+		Extensions: req.extensions,
+	}
+}
+
+// ExecTo executes the given query like Exec, but writes the JSON encoded response to w while it is
+// being executed, instead of building it in memory. Fields and list entries are written in document
+// order as soon as they are resolved, unless a null of a non-null field or list entry could still
+// propagate to them, the errors and extensions follow after the data. The returned error is the first
+// error returned by w.
+func (s *Schema) ExecTo(ctx context.Context, w io.Writer, queryString string, operationName string, variables map[string]interface{}) error {
+	if !s.res.Resolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+	req, resp := s.prepareRequest(ctx, queryString, operationName, variables)
+	return s.execTo(w, req, resp, nil)
+}
+
+// ResponseInfo describes the response of ExecToFunc before it is written.
+type ResponseInfo struct {
+	// OperationType is the type of the executed operation, or "" if the request failed before the
+	// execution.
+	OperationType types.OperationType
+	// RequestError reports whether the request failed before the execution, e.g. because the query
+	// or the variables are invalid. The response only holds the errors then.
+	RequestError bool
+}
+
+// ExecToFunc executes the given query like ExecTo, but calls before with the ResponseInfo of the
+// response once the query is validated and before anything is written to w, e.g. to set the status
+// of an HTTP response. If before returns an error, the operation is not executed and the error is
+// returned without writing the response.
+func (s *Schema) ExecToFunc(ctx context.Context, w io.Writer, queryString string, operationName string, variables map[string]interface{}, before func(ResponseInfo) error) error {
+	if !s.res.Resolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+	req, resp := s.prepareRequest(ctx, queryString, operationName, variables)
+	return s.execTo(w, req, resp, before)
+}
+
+// execTo executes the request and writes the response to w, or writes the response of a request
+// which failed before the execution. before is called with the ResponseInfo first, if it is set.
+func (s *Schema) execTo(w io.Writer, req *request, resp *Response, before func(ResponseInfo) error) error {
+	if before != nil {
+		info := ResponseInfo{RequestError: resp != nil}
+		if req != nil {
+			info.OperationType = req.op.Type
+		}
+		if err := before(info); err != nil {
+			if req != nil {
+				req.finish([]*errors.QueryError{errors.Errorf("%s", err)})
+			}
+			return err
+		}
+	}
+
+	if resp != nil {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	if _, err := io.WriteString(w, `{"data":`); err != nil {
+		return err
+	}
+	errs, err := req.exec.ExecuteTo(req.ctx, s.res, req.op, w)
+	req.finish(errs)
+	if err != nil {
+		return err
+	}
+
+	// the data was written already
+	tail, err := json.Marshal(&Response{Errors: errs, Extensions: req.extensions})
+	if err != nil {
+		return err
+	}
+	if len(tail) > 2 {
+		tail[0] = ','
+	} else {
+		tail = tail[1:]
+	}
+	_, err = w.Write(tail)
+	return err
+}
+
+// request is an operation which is ready to be executed.
+type request struct {
+	exec       *exec.Request
+	op         *types.OperationDefinition
+	ctx        context.Context
+	finish     func([]*errors.QueryError)
+	extensions map[string]interface{}
+}
+
+// prepareRequest parses and validates the query and starts tracing it. If the operation can not be
+// executed, the response reporting the errors is returned instead.
+func (s *Schema) prepareRequest(ctx context.Context, queryString string, operationName string, variables map[string]interface{}) (*request, *Response) {
+	doc, errs := s.parseAndValidate(ctx, queryString, variables)
 	if len(errs) != 0 {
-		return &Response{Errors: errs}
+		return nil, &Response{Errors: errs}
 	}
 
 	op, err := getOperation(doc, operationName)
 	if err != nil {
-		return &Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
+		return nil, &Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
 	}
 
 	// If the optional "operationName" POST parameter is not provided then
@@ -227,17 +550,18 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 		operationName = op.Name.Name
 	}
 
-	// Subscriptions are not valid in Exec. Use schema.Subscribe() instead.
-	if op.Type == query.Subscription {
-		return &Response{Errors: []*errors.QueryError{{Message: "graphql-ws protocol header is missing"}}}
+	if err := s.checkOperation(op); err != nil {
+		return nil, &Response{Errors: []*errors.QueryError{err}}
 	}
-	if op.Type == query.Mutation {
-		if _, ok := s.schema.RootOperationTypes["mutation"]; !ok {
-			return &Response{Errors: []*errors.QueryError{{Message: "no mutations are offered by the schema"}}}
-		}
+	varTypes, qErr := s.variableTypes(op)
+	if qErr != nil {
+		return nil, &Response{Errors: []*errors.QueryError{qErr}}
 	}
+	return s.newRequest(ctx, queryString, operationName, doc, op, variables, varTypes, nil)
+}
 
-	// Fill in variables with the defaults from the operation
+// applyVariableDefaults fills in the variables with the defaults from the operation.
+func applyVariableDefaults(op *types.OperationDefinition, variables map[string]interface{}) map[string]interface{} {
 	if variables == nil {
 		variables = make(map[string]interface{}, len(op.Vars))
 	}
@@ -246,6 +570,48 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 			variables[v.Name.Name] = v.Default.Deserialize(nil)
 		}
 	}
+	return variables
+}
+
+// checkOperation reports whether the operation can be executed by Exec.
+func (s *Schema) checkOperation(op *types.OperationDefinition) *errors.QueryError {
+	// Subscriptions are not valid in Exec. Use schema.Subscribe() instead.
+	if op.Type == query.Subscription {
+		return &errors.QueryError{Message: "graphql-ws protocol header is missing"}
+	}
+	if op.Type == query.Mutation {
+		if _, ok := s.schema.RootOperationTypes["mutation"]; !ok {
+			return &errors.QueryError{Message: "no mutations are offered by the schema"}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) variableTypes(op *types.OperationDefinition) (map[string]*introspection.Type, *errors.QueryError) {
+	varTypes := make(map[string]*introspection.Type)
+	for _, v := range op.Vars {
+		t, err := common.ResolveType(v.Type, s.schema.Resolve)
+		if err != nil {
+			return nil, err
+		}
+		varTypes[v.Name.Name] = introspection.WrapType(t)
+	}
+	return varTypes, nil
+}
+
+// newRequest returns the request executing a validated operation with the given variables. The
+// selections of the operation are applied during the execution if sels is nil.
+func (s *Schema) newRequest(ctx context.Context, queryString string, operationName string, doc *types.ExecutableDefinition, op *types.OperationDefinition, variables map[string]interface{}, varTypes map[string]*introspection.Type, sels []selected.Selection) (*request, *Response) {
+	variables = applyVariableDefaults(op, variables)
+
+	var extensions map[string]interface{}
+	if s.maxComplexity > 0 {
+		cost, errs := validation.ValidateComplexity(s.schema, doc, op, variables, s.maxComplexity)
+		extensions = s.costExtensions(cost)
+		if len(errs) != 0 {
+			return nil, &Response{Errors: errs, Extensions: extensions}
+		}
+	}
 
 	r := &exec.Request{
 		Request: selected.Request{
@@ -254,27 +620,76 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 			Schema:               s.schema,
 			DisableIntrospection: s.disableIntrospection,
 		},
-		Limiter:      make(chan struct{}, s.maxParallelism),
-		Tracer:       s.tracer,
-		Logger:       s.logger,
-		PanicHandler: s.panicHandler,
+		Limiter:          make(chan struct{}, s.maxParallelism),
+		Tracer:           s.tracer,
+		Logger:           s.logger,
+		PanicHandler:     s.panicHandler,
+		FieldMiddleware:  s.fieldMiddleware,
+		MiddlewareFields: s.middlewareFields,
+		Selections:       sels,
 	}
-	varTypes := make(map[string]*introspection.Type)
-	for _, v := range op.Vars {
-		t, err := common.ResolveType(v.Type, s.schema.Resolve)
-		if err != nil {
-			return &Response{Errors: []*errors.QueryError{err}}
+	traceCtx, finish := s.tracer.TraceQuery(ctx, queryString, operationName, variables, varTypes)
+	return &request{
+		exec:       r,
+		op:         op,
+		ctx:        traceCtx,
+		finish:     finish,
+		extensions: extensions,
+	}, nil
+}
+
+// costExtensions returns the response extensions reporting the cost of an operation.
+func (s *Schema) costExtensions(cost int) map[string]interface{} {
+	return map[string]interface{}{
+		"cost": map[string]interface{}{
+			"requestedQueryCost": cost,
+			"maximumAvailable":   s.maxComplexity,
+		},
+	}
+}
+
+// parseAndValidate parses and validates the query with the variables, using the query cache if it
+// is enabled.
+func (s *Schema) parseAndValidate(ctx context.Context, queryString string, variables map[string]interface{}) (*types.ExecutableDefinition, []*errors.QueryError) {
+	return s.parseAndValidateDocument(ctx, queryString, func(doc *types.ExecutableDefinition) []*errors.QueryError {
+		return validation.ValidateVariables(s.schema, doc, variables)
+	})
+}
+
+// parseAndValidateDocument parses and validates the query, using the query cache if it is enabled.
+// The errors of validateVariables, if not nil, are added to the traced validation errors.
+func (s *Schema) parseAndValidateDocument(ctx context.Context, queryString string, validateVariables func(doc *types.ExecutableDefinition) []*errors.QueryError) (*types.ExecutableDefinition, []*errors.QueryError) {
+	var entry *queryCacheEntry
+	ok := false
+	if s.queryCache != nil {
+		entry, ok = s.queryCache.get(queryString)
+	}
+	if !ok {
+		doc, qErr := query.Parse(queryString)
+		if qErr != nil {
+			return nil, []*errors.QueryError{qErr}
 		}
-		varTypes[v.Name.Name] = introspection.WrapType(t)
+		entry = &queryCacheEntry{query: queryString, doc: doc}
 	}
-	traceCtx, finish := s.tracer.TraceQuery(ctx, queryString, operationName, variables, varTypes)
-	data, errs := r.Execute(traceCtx, res, op)
-	finish(errs)
 
-	return &Response{
-		Data:   data,
-		Errors: errs,
+	validationFinish := s.validationTracer.TraceValidation(ctx)
+	if !ok {
+		entry.errs = validation.ValidateDocument(s.schema, entry.doc, s.maxDepth)
+		if s.queryCache != nil {
+			s.queryCache.add(entry)
+		}
+	}
+	// copy the cached errors, callers may modify them
+	var errs []*errors.QueryError
+	for _, err := range entry.errs {
+		e := *err
+		errs = append(errs, &e)
 	}
+	if validateVariables != nil {
+		errs = append(errs, validateVariables(entry.doc)...)
+	}
+	validationFinish(errs)
+	return entry.doc, errs
 }
 
 func (s *Schema) validateSchema() error {
@@ -2,9 +2,15 @@ package graphql_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +19,7 @@ import (
 	"github.com/graph-gophers/graphql-go/example/starwars"
 	"github.com/graph-gophers/graphql-go/gqltesting"
 	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace/noop"
 	"github.com/graph-gophers/graphql-go/trace/tracer"
 )
 
@@ -1528,6 +1535,102 @@ func TestSpecifiedByDirective(t *testing.T) {
 	})
 }
 
+type oneOfResolver struct{}
+
+func (r *oneOfResolver) User(args struct {
+	By struct {
+		ID    *graphql.ID
+		Email *string
+	}
+}) string {
+	if args.By.ID != nil {
+		return "user with id " + string(*args.By.ID)
+	}
+	return "user with email " + *args.By.Email
+}
+
+func TestOneOfDirective(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		input UserBy @oneOf {
+			id: ID
+			email: String
+		}
+
+		type Query {
+			user(by: UserBy!): String!
+		}
+	`, &oneOfResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($email: String!) {
+					byID: user(by: {id: "1"})
+					byEmail: user(by: {email: $email})
+				}
+			`,
+			Variables: map[string]interface{}{"email": "a@example.com"},
+			ExpectedResult: `
+				{
+					"byID": "user with id 1",
+					"byEmail": "user with email a@example.com"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($by: UserBy!) {
+					user(by: $by)
+				}
+			`,
+			Variables: map[string]interface{}{"by": map[string]interface{}{"id": "2"}},
+			ExpectedResult: `
+				{
+					"user": "user with id 2"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query {
+					userBy: __type(name: "UserBy") {
+						isOneOf
+					}
+					query: __type(name: "Query") {
+						isOneOf
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"userBy": {
+						"isOneOf": true
+					},
+					"query": {
+						"isOneOf": null
+					}
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query {
+					user(by: {id: "1", email: "a@example.com"})
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"by\" has invalid value {id: \"1\", email: \"a@example.com\"}.\nExactly one field must be specified for oneOf input object \"UserBy\".",
+				Locations: []gqlerrors.Location{{Line: 3, Column: 15}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+	})
+}
+
 type testBadEnumResolver struct{}
 
 func (r *testBadEnumResolver) Hero() *testBadEnumCharacterResolver {
@@ -2527,6 +2630,14 @@ func TestIntrospection(t *testing.T) {
 										}
 									]
 								},
+								{
+									"name": "oneOf",
+									"description": "Indicates exactly one field must be supplied and this field must not be ` + "`" + `null` + "`" + `.",
+									"locations": [
+										"INPUT_OBJECT"
+									],
+									"args": []
+								},
 								{
 									"name": "skip",
 									"description": "Directs the executor to skip this field or fragment when the ` + "`" + `if` + "`" + ` argument is true.",
@@ -4550,3 +4661,1822 @@ func TestQueryService(t *testing.T) {
 		},
 	})
 }
+
+type batchQueryResolver struct {
+	calls *int32
+}
+
+type batchUserResolver struct {
+	id    int32
+	calls *int32
+}
+
+type batchPostResolver struct {
+	Title string
+}
+
+func (r *batchQueryResolver) Users() []*batchUserResolver {
+	return []*batchUserResolver{{1, r.calls}, {2, r.calls}, nil, {3, r.calls}}
+}
+
+func (r *batchQueryResolver) User() *batchUserResolver {
+	return &batchUserResolver{4, r.calls}
+}
+
+func (r *batchUserResolver) ID() int32 {
+	return r.id
+}
+
+func (r *batchUserResolver) BatchPosts(ctx context.Context, parents []*batchUserResolver, args struct{ Prefix string }) ([][]*batchPostResolver, error) {
+	atomic.AddInt32(r.calls, 1)
+	res := make([][]*batchPostResolver, len(parents))
+	for i, p := range parents {
+		res[i] = []*batchPostResolver{{Title: fmt.Sprintf("%s%d", args.Prefix, p.id)}}
+	}
+	return res, nil
+}
+
+func (r *batchUserResolver) BatchFailing(parents []*batchUserResolver) ([]*string, error) {
+	return nil, errors.New("batch failed")
+}
+
+func TestBatchResolvers(t *testing.T) {
+	t.Parallel()
+
+	schemaString := `
+		type Query {
+			users: [User]!
+			user: User!
+		}
+
+		type User {
+			id: Int!
+			posts(prefix: String!): [Post!]!
+			failing: String
+		}
+
+		type Post {
+			title: String!
+		}
+	`
+
+	var calls int32
+	schema := graphql.MustParseSchema(schemaString, &batchQueryResolver{calls: &calls}, graphql.UseFieldResolvers())
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					users {
+						id
+						posts(prefix: "post-") {
+							title
+						}
+					}
+					user {
+						posts(prefix: "single-") {
+							title
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"users": [
+						{"id": 1, "posts": [{"title": "post-1"}]},
+						{"id": 2, "posts": [{"title": "post-2"}]},
+						null,
+						{"id": 3, "posts": [{"title": "post-3"}]}
+					],
+					"user": {
+						"posts": [{"title": "single-4"}]
+					}
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					users {
+						id
+						failing
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"users": [
+						{"id": 1, "failing": null},
+						{"id": 2, "failing": null},
+						null,
+						{"id": 3, "failing": null}
+					]
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:       "batch failed",
+					Path:          []interface{}{"users", 0, "failing"},
+					ResolverError: errors.New("batch failed"),
+				},
+				{
+					Message:       "batch failed",
+					Path:          []interface{}{"users", 1, "failing"},
+					ResolverError: errors.New("batch failed"),
+				},
+				{
+					Message:       "batch failed",
+					Path:          []interface{}{"users", 3, "failing"},
+					ResolverError: errors.New("batch failed"),
+				},
+			},
+		},
+	})
+
+	// one call for the list of users and one for the single user
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("want 2 batch calls, got %d", got)
+	}
+}
+
+type batchMismatchResolver struct{}
+
+func (r *batchMismatchResolver) User() *batchMismatchResolver {
+	return r
+}
+
+func (r *batchMismatchResolver) BatchName(parents []*batchUserResolver) []string {
+	return nil
+}
+
+func TestBatchResolvers_invalidSignature(t *testing.T) {
+	t.Parallel()
+
+	_, err := graphql.ParseSchema(`
+		type Query {
+			user: User
+		}
+
+		type User {
+			name: String!
+		}
+	`, &batchMismatchResolver{})
+	if err == nil {
+		t.Fatal("expected an error for a batch method with mismatching parents")
+	}
+	want := "must have `[]*graphql_test.batchMismatchResolver` argument for the batched parents\n\tused by (*graphql_test.batchMismatchResolver).BatchName\n\tused by (*graphql_test.batchMismatchResolver).User"
+	if err.Error() != want {
+		t.Fatalf("unexpected error:\nwant: %q\ngot:  %q", want, err.Error())
+	}
+}
+
+type batchNamedResolver struct{}
+
+func (r *batchNamedResolver) User() *batchNamedResolver {
+	return r
+}
+
+func (r *batchNamedResolver) Name() string {
+	return "Alice"
+}
+
+func (r *batchNamedResolver) BatchName(limit int32) []string {
+	return nil
+}
+
+func TestBatchResolvers_ignoredSignature(t *testing.T) {
+	t.Parallel()
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema: graphql.MustParseSchema(`
+			type Query {
+				user: User
+			}
+
+			type User {
+				name: String!
+			}
+		`, &batchNamedResolver{}),
+		Query: `
+			{
+				user {
+					name
+				}
+			}
+		`,
+		ExpectedResult: `
+			{
+				"user": {
+					"name": "Alice"
+				}
+			}
+		`,
+	})
+}
+
+type incrementalQueryResolver struct{}
+
+func (r *incrementalQueryResolver) Hero() *incrementalHeroResolver {
+	return &incrementalHeroResolver{}
+}
+
+type incrementalHeroResolver struct{}
+
+func (r *incrementalHeroResolver) Name() string { return "R2-D2" }
+
+func (r *incrementalHeroResolver) Friends() []string {
+	return []string{"Luke", "Han", "Leia"}
+}
+
+func (r *incrementalHeroResolver) Bad() (string, error) { return "", errors.New("bad") }
+
+func TestExecIncremental(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hero: Hero!
+		}
+
+		type Hero {
+			name: String!
+			friends: [String!]!
+			bad: String!
+		}
+	`, &incrementalQueryResolver{}, graphql.IncrementalDelivery())
+
+	collect := func(t *testing.T, query string) (string, []string) {
+		t.Helper()
+		resp, subsequent := schema.ExecIncremental(context.Background(), query, "", nil)
+		initial, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subsequent == nil {
+			return string(initial), nil
+		}
+		var rest []string
+		for p := range subsequent {
+			b, err := json.Marshal(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest = append(rest, string(b))
+		}
+		return string(initial), rest
+	}
+
+	t.Run("defer", func(t *testing.T) {
+		initial, rest := collect(t, `{ hero { name ... @defer(label: "friends") { friends } } }`)
+		if want := `{"data":{"hero":{"name":"R2-D2"}},"hasNext":true}`; initial != want {
+			t.Fatalf("unexpected initial response:\nwant: %s\ngot:  %s", want, initial)
+		}
+		want := []string{`{"incremental":[{"data":{"friends":["Luke","Han","Leia"]},"path":["hero"],"label":"friends"}],"hasNext":false}`}
+		if !reflect.DeepEqual(rest, want) {
+			t.Fatalf("unexpected subsequent responses:\nwant: %v\ngot:  %v", want, rest)
+		}
+	})
+
+	t.Run("defer fields of the initial result", func(t *testing.T) {
+		initial, rest := collect(t, `{ hero { name ... @defer(label: "friends") { name friends } } }`)
+		if want := `{"data":{"hero":{"name":"R2-D2"}},"hasNext":true}`; initial != want {
+			t.Fatalf("unexpected initial response:\nwant: %s\ngot:  %s", want, initial)
+		}
+		// the name is only delivered with the initial result
+		want := []string{`{"incremental":[{"data":{"friends":["Luke","Han","Leia"]},"path":["hero"],"label":"friends"}],"hasNext":false}`}
+		if !reflect.DeepEqual(rest, want) {
+			t.Fatalf("unexpected subsequent responses:\nwant: %v\ngot:  %v", want, rest)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		initial, rest := collect(t, `{ hero { friends @stream(initialCount: 1) } }`)
+		if want := `{"data":{"hero":{"friends":["Luke"]}},"hasNext":true}`; initial != want {
+			t.Fatalf("unexpected initial response:\nwant: %s\ngot:  %s", want, initial)
+		}
+		want := []string{
+			`{"incremental":[{"items":["Han"],"path":["hero","friends",1]}],"hasNext":true}`,
+			`{"incremental":[{"items":["Leia"],"path":["hero","friends",2]}],"hasNext":false}`,
+		}
+		if !reflect.DeepEqual(rest, want) {
+			t.Fatalf("unexpected subsequent responses:\nwant: %v\ngot:  %v", want, rest)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		initial, rest := collect(t, `{ hero { ... @defer(if: false) { name } friends @stream(if: false) } }`)
+		if want := `{"data":{"hero":{"name":"R2-D2","friends":["Luke","Han","Leia"]}}}`; initial != want {
+			t.Fatalf("unexpected initial response:\nwant: %s\ngot:  %s", want, initial)
+		}
+		if len(rest) != 0 {
+			t.Fatalf("unexpected subsequent responses: %v", rest)
+		}
+	})
+
+	t.Run("nulled parent", func(t *testing.T) {
+		initial, rest := collect(t, `{ hero { bad ... @defer { name } } }`)
+		if want := `{"errors":[{"message":"bad","path":["hero","bad"]}],"data":null}`; initial != want {
+			t.Fatalf("unexpected initial response:\nwant: %s\ngot:  %s", want, initial)
+		}
+		if len(rest) != 0 {
+			t.Fatalf("unexpected subsequent responses: %v", rest)
+		}
+	})
+
+	t.Run("cancelled without reading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		_, subsequent := schema.ExecIncremental(ctx, `{ hero { friends @stream(initialCount: 0) } }`, "", nil)
+		cancel()
+
+		// the delivery stops and drains the payloads instead of blocking on the unread channel
+		time.Sleep(100 * time.Millisecond)
+		if p, ok := <-subsequent; ok {
+			t.Fatalf("expected the channel to be closed, got %v", p)
+		}
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		resp := schema.Exec(context.Background(), `{ hero { ... @defer { name } friends @stream } }`, "", nil)
+		got, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"data":{"hero":{"name":"R2-D2","friends":["Luke","Han","Leia"]}}}`; string(got) != want {
+			t.Fatalf("unexpected response:\nwant: %s\ngot:  %s", want, got)
+		}
+	})
+}
+
+type complexityResolver struct{}
+
+func (r *complexityResolver) Users(args struct{ First *int32 }) []*helloWorldResolver1 {
+	return []*helloWorldResolver1{{}}
+}
+
+func TestMaxComplexity(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		type Query {
+			users(first: Int): [User!]! @listSize(slicingArguments: ["first"], assumedSize: 100)
+		}
+
+		type User @cost(weight: 2) {
+			hello: String!
+		}
+	`, &complexityResolver{}, graphql.MaxComplexity(50))
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `{ users(first: 25) { hello } }`,
+			want:  `{"data":{"users":[{"hello":"Hello world!"}]},"extensions":{"cost":{"maximumAvailable":50,"requestedQueryCost":50}}}`,
+		},
+		{
+			query: `{ users { hello } }`,
+			want:  `{"errors":[{"message":"The operation cost 200 exceeds the maximum cost of 50.","locations":[{"line":1,"column":1}]}],"extensions":{"cost":{"maximumAvailable":50,"requestedQueryCost":200}}}`,
+		},
+	}
+	for _, tc := range tests {
+		resp := schema.Exec(context.Background(), tc.query, "", nil)
+		got, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("unexpected response:\nwant: %s\ngot:  %s", tc.want, got)
+		}
+		if len(resp.Errors) > 0 && resp.Errors[0].Rule != "MaxComplexity" {
+			t.Errorf("expected rule MaxComplexity, got %q", resp.Errors[0].Rule)
+		}
+	}
+}
+
+func TestQueryCache(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		type Query {
+			greet(name: String!): String!
+		}
+	`, &queryCacheResolver{}, graphql.QueryCache(1))
+
+	query := `query($name: String!) { greet(name: $name) }`
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         schema,
+			Query:          query,
+			Variables:      map[string]interface{}{"name": "Alice"},
+			ExpectedResult: `{"greet": "Hello, Alice!"}`,
+		},
+		{
+			Schema:         schema,
+			Query:          query,
+			Variables:      map[string]interface{}{"name": "Bob"},
+			ExpectedResult: `{"greet": "Hello, Bob!"}`,
+		},
+		{
+			Schema:    schema,
+			Query:     query,
+			Variables: map[string]interface{}{"name": nil},
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Variable \"name\" has invalid value null.\nExpected type \"String!\", found null.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 7}},
+				Rule:      "VariablesOfCorrectType",
+			}},
+		},
+	})
+
+	if got, want := schema.QueryCacheStats(), (graphql.QueryCacheStats{Hits: 2, Misses: 1, Size: 1}); got != want {
+		t.Fatalf("unexpected stats: want %+v, got %+v", want, got)
+	}
+
+	schema.Exec(context.Background(), `{ greet(name: "Carol") }`, "", nil)
+	schema.Exec(context.Background(), query, "", map[string]interface{}{"name": "Dave"})
+	if got, want := schema.QueryCacheStats(), (graphql.QueryCacheStats{Hits: 2, Misses: 3, Size: 1}); got != want {
+		t.Fatalf("unexpected stats after eviction: want %+v, got %+v", want, got)
+	}
+}
+
+type queryCacheResolver struct{}
+
+func (r *queryCacheResolver) Greet(args struct{ Name string }) string {
+	return "Hello, " + args.Name + "!"
+}
+
+type middlewareQueryResolver struct{}
+
+func (r *middlewareQueryResolver) Greet(args struct{ Name string }) string {
+	return "Hello, " + args.Name + "!"
+}
+
+func (r *middlewareQueryResolver) Secret() *string {
+	s := "42"
+	return &s
+}
+
+func (r *middlewareQueryResolver) User() *middlewareUserResolver {
+	return &middlewareUserResolver{}
+}
+
+type middlewareUserResolver struct{}
+
+func (r *middlewareUserResolver) Email() string { return "alice@example.com" }
+
+func TestFieldMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(ctx context.Context, info graphql.FieldInfo, next graphql.Resolve) (interface{}, error) {
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%s.%s %v", info.ParentType, info.Field.Name, info.Path))
+		mu.Unlock()
+		return next(ctx, info.Args)
+	}
+	policy := func(ctx context.Context, info graphql.FieldInfo, next graphql.Resolve) (interface{}, error) {
+		switch {
+		case info.Field.Directives.Get("private") != nil:
+			return nil, errors.New("forbidden")
+		case info.Field.Name == "greet" && info.Args["name"] == "Mallory":
+			return next(ctx, map[string]interface{}{"name": "stranger"})
+		case info.ParentType == "User" && info.Field.Name == "email":
+			return "***", nil
+		case info.Field.Name == "user" && info.Directives.Get("include") != nil:
+			return "not a user", nil
+		}
+		return next(ctx, info.Args)
+	}
+
+	schema := graphql.MustParseSchema(`
+		directive @private on FIELD_DEFINITION
+
+		type Query {
+			greet(name: String!): String!
+			secret: String @private
+			user: User
+		}
+
+		type User {
+			email: String!
+		}
+	`, &middlewareQueryResolver{}, graphql.FieldMiddleware(record, policy))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					alice: greet(name: "Alice")
+					mallory: greet(name: "Mallory")
+					user {
+						email
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"alice": "Hello, Alice!",
+					"mallory": "Hello, stranger!",
+					"user": {"email": "***"}
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query:  `{ secret }`,
+			ExpectedResult: `
+				{
+					"secret": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "forbidden",
+				Path:          []interface{}{"secret"},
+				ResolverError: errors.New("forbidden"),
+			}},
+		},
+		{
+			Schema: schema,
+			Query:  `{ user @include(if: true) { email } }`,
+			ExpectedResult: `
+				{
+					"user": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       `field middleware returned string for field "user", expected *graphql_test.middlewareUserResolver`,
+				Path:          []interface{}{"user"},
+				ResolverError: fmt.Errorf(`field middleware returned string for field "user", expected *graphql_test.middlewareUserResolver`),
+			}},
+		},
+	})
+
+	want := "User.email [user email]"
+	var found bool
+	for _, c := range calls {
+		if c == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the middleware to be called with %q, got %v", want, calls)
+	}
+}
+
+func TestFieldMiddleware_batch(t *testing.T) {
+	t.Parallel()
+
+	policy := func(ctx context.Context, info graphql.FieldInfo, next graphql.Resolve) (interface{}, error) {
+		if info.Field.Name != "posts" {
+			return next(ctx, info.Args)
+		}
+		switch info.Args["prefix"] {
+		case "hidden-":
+			return []*batchPostResolver{}, nil
+		case "edit-":
+			info.Args["prefix"] = "edited-"
+		case "post-":
+			if info.Path[1] == 1 {
+				return []*batchPostResolver{{Title: "hidden"}}, nil
+			}
+		}
+		return next(ctx, info.Args)
+	}
+
+	var calls int32
+	schema := graphql.MustParseSchema(`
+		type Query {
+			users: [User]!
+		}
+
+		type User {
+			id: Int!
+			posts(prefix: String!): [Post!]!
+		}
+
+		type Post {
+			title: String!
+		}
+	`, &batchQueryResolver{calls: &calls}, graphql.UseFieldResolvers(), graphql.FieldMiddleware(policy))
+
+	for _, tc := range []struct {
+		name   string
+		prefix string
+		want   string
+		calls  int32
+	}{
+		{
+			name:   "short-circuited for every parent",
+			prefix: "hidden-",
+			want:   `{"users": [{"posts": []}, {"posts": []}, null, {"posts": []}]}`,
+			calls:  0,
+		},
+		{
+			name:   "short-circuited for a single parent",
+			prefix: "post-",
+			want:   `{"users": [{"posts": [{"title": "post-1"}]}, {"posts": [{"title": "hidden"}]}, null, {"posts": [{"title": "post-3"}]}]}`,
+			calls:  1,
+		},
+		{
+			name:   "arguments changed in place",
+			prefix: "edit-",
+			want:   `{"users": [{"posts": [{"title": "edited-1"}]}, {"posts": [{"title": "edited-2"}]}, null, {"posts": [{"title": "edited-3"}]}]}`,
+			calls:  3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			gqltesting.RunTest(t, &gqltesting.Test{
+				Schema:         schema,
+				Query:          `query($prefix: String!) { users { posts(prefix: $prefix) { title } } }`,
+				Variables:      map[string]interface{}{"prefix": tc.prefix},
+				ExpectedResult: tc.want,
+			})
+			if got := atomic.LoadInt32(&calls); got != tc.calls {
+				t.Errorf("want %d batch calls, got %d", tc.calls, got)
+			}
+		})
+	}
+}
+
+type directivesQueryResolver struct{}
+
+func (r *directivesQueryResolver) Greet(args struct{ Name string }) string {
+	return "Hello, " + args.Name + "!"
+}
+
+func (r *directivesQueryResolver) Salary() *int32 {
+	s := int32(100)
+	return &s
+}
+
+func (r *directivesQueryResolver) Node() *directivesEmployeeResolver {
+	return &directivesEmployeeResolver{}
+}
+
+func (r *directivesQueryResolver) Search() []*directivesEmployeeResolver {
+	return []*directivesEmployeeResolver{{}}
+}
+
+type directivesEmployeeResolver struct{}
+
+func (r *directivesEmployeeResolver) ID() graphql.ID { return "1" }
+
+func (r *directivesEmployeeResolver) Salary() *int32 {
+	s := int32(100)
+	return &s
+}
+
+func (r *directivesEmployeeResolver) Name() string { return "Bob" }
+
+func (r *directivesEmployeeResolver) Title() string { return "Boss" }
+
+func (r *directivesEmployeeResolver) ToEmployee() (*directivesEmployeeResolver, bool) {
+	return r, true
+}
+
+func TestDirectives(t *testing.T) {
+	t.Parallel()
+
+	schemaString := `
+		directive @uppercase on FIELD_DEFINITION
+		directive @suffix(text: String = "!") repeatable on FIELD_DEFINITION
+		directive @auth(role: Role!) on FIELD_DEFINITION
+
+		enum Role {
+			ADMIN
+			USER
+		}
+
+		type Query {
+			greet(name: String!): String! @suffix @uppercase @suffix(text: "?")
+			salary: Int @auth(role: ADMIN)
+			node: Node!
+			search: [SearchResult!]!
+		}
+
+		interface Node {
+			id: ID!
+			salary: Int
+			name: String! @suffix
+			title: String! @suffix
+		}
+
+		type Employee implements Node {
+			id: ID!
+			salary: Int @auth(role: ADMIN)
+			name: String!
+			title: String! @suffix(text: "?")
+		}
+
+		union SearchResult = Employee
+	`
+	directives := map[string]graphql.DirectiveFunc{
+		"uppercase": func(ctx context.Context, args map[string]interface{}, info graphql.FieldInfo, next graphql.Resolve) (interface{}, error) {
+			v, err := next(ctx, info.Args)
+			if err != nil {
+				return nil, err
+			}
+			return strings.ToUpper(v.(string)), nil
+		},
+		"suffix": func(ctx context.Context, args map[string]interface{}, info graphql.FieldInfo, next graphql.Resolve) (interface{}, error) {
+			v, err := next(ctx, info.Args)
+			if err != nil {
+				return nil, err
+			}
+			return v.(string) + args["text"].(string), nil
+		},
+		"auth": func(ctx context.Context, args map[string]interface{}, info graphql.FieldInfo, next graphql.Resolve) (interface{}, error) {
+			if args["role"] != "USER" {
+				return nil, fmt.Errorf("requires role %s", args["role"])
+			}
+			return next(ctx, info.Args)
+		},
+	}
+	schema := graphql.MustParseSchema(schemaString, &directivesQueryResolver{}, graphql.Directives(directives))
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema: schema,
+		Query:  `{ greet(name: "Alice") salary }`,
+		ExpectedResult: `
+			{
+				"greet": "HELLO, ALICE!?!",
+				"salary": null
+			}
+		`,
+		ExpectedErrors: []*gqlerrors.QueryError{{
+			Message:       "requires role ADMIN",
+			Path:          []interface{}{"salary"},
+			ResolverError: fmt.Errorf("requires role ADMIN"),
+		}},
+	})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query:  `{ node { id salary } }`,
+			ExpectedResult: `
+				{
+					"node": {"id": "1", "salary": null}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "requires role ADMIN",
+				Path:          []interface{}{"node", "salary"},
+				ResolverError: fmt.Errorf("requires role ADMIN"),
+			}},
+		},
+		{
+			// the directives of the interface apply to the fields of the object type, with the
+			// default values of their arguments, unless the object field applies them itself
+			Schema: schema,
+			Query:  `{ node { name title } search { ... on Employee { name title } } }`,
+			ExpectedResult: `
+				{
+					"node": {"name": "Bob!", "title": "Boss?"},
+					"search": [{"name": "Bob!", "title": "Boss?"}]
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query:  `{ search { ... on Employee { salary } } }`,
+			ExpectedResult: `
+				{
+					"search": [{"salary": null}]
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "requires role ADMIN",
+				Path:          []interface{}{"search", 0, "salary"},
+				ResolverError: fmt.Errorf("requires role ADMIN"),
+			}},
+		},
+	})
+
+	_, err := graphql.ParseSchema(schemaString, &directivesQueryResolver{}, graphql.Directives(map[string]graphql.DirectiveFunc{
+		"unknown": directives["uppercase"],
+	}))
+	if err == nil || err.Error() != `directive "unknown" is implemented but not defined in the schema` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type execToResolver struct{}
+
+func (r *execToResolver) Hello() string { return "Hello, world!" }
+
+func (r *execToResolver) Fail() (*string, error) { return nil, errors.New("failed") }
+
+func (r *execToResolver) Required() (string, error) { return "", errors.New("required failed") }
+
+func (r *execToResolver) Items() []*execToItemResolver {
+	return []*execToItemResolver{{"1"}, {"2"}, {"fail"}}
+}
+
+func (r *execToResolver) NullableItems() *[]*execToItemResolver {
+	l := []*execToItemResolver{{"1"}, nil, {"fail"}}
+	return &l
+}
+
+type execToItemResolver struct {
+	id string
+}
+
+func (r *execToItemResolver) ID() (string, error) {
+	if r.id == "fail" {
+		return "", errors.New("id failed")
+	}
+	return r.id, nil
+}
+
+func (r *execToItemResolver) Name() *string {
+	name := "item " + r.id
+	return &name
+}
+
+func TestExecTo(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+			fail: String
+			required: String!
+			items: [Item!]!
+			nullableItems: [Item]
+		}
+
+		type Item {
+			id: String!
+			name: String
+		}
+	`, &execToResolver{})
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "data",
+			query: `{ hello greeting: hello }`,
+			want:  `{"data":{"hello":"Hello, world!","greeting":"Hello, world!"}}`,
+		},
+		{
+			name:  "errors after data",
+			query: `{ hello fail }`,
+			want:  `{"data":{"hello":"Hello, world!","fail":null},"errors":[{"message":"failed","path":["fail"]}]}`,
+		},
+		{
+			name:  "null data",
+			query: `{ hello required }`,
+			want:  `{"data":null,"errors":[{"message":"required failed","path":["required"]}]}`,
+		},
+		{
+			name:  "list",
+			query: `{ items { name } }`,
+			want:  `{"data":{"items":[{"name":"item 1"},{"name":"item 2"},{"name":"item fail"}]}}`,
+		},
+		{
+			name:  "nullable list entries",
+			query: `{ nullableItems { id } }`,
+			want:  `{"data":{"nullableItems":[{"id":"1"},null,null]},"errors":[{"message":"id failed","path":["nullableItems",2,"id"]}]}`,
+		},
+		{
+			name:  "non-null list entry nulled",
+			query: `{ hello items { id } }`,
+			want:  `{"data":null,"errors":[{"message":"id failed","path":["items",2,"id"]}]}`,
+		},
+		{
+			name:  "invalid query",
+			query: `{ unknown }`,
+			want:  `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := schema.ExecTo(context.Background(), &buf, tc.query, "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("unexpected response\ngot:  %s\nwant: %s", got, tc.want)
+			}
+
+			// the streamed response must be equivalent to the response of Exec
+			want, err := json.Marshal(schema.Exec(context.Background(), tc.query, "", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal([]byte(buf.String()), &gotValue); err != nil {
+				t.Fatalf("invalid JSON %s: %s", buf.String(), err)
+			}
+			if err := json.Unmarshal(want, &wantValue); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("response differs from Exec\ngot:  %s\nwant: %s", buf.String(), want)
+			}
+		})
+	}
+}
+
+type execToLargeListResolver struct {
+	resolved *int64
+}
+
+func (r *execToLargeListResolver) Items() []*execToLargeListResolver {
+	l := make([]*execToLargeListResolver, 1000)
+	for i := range l {
+		l[i] = r
+	}
+	return l
+}
+
+func (r *execToLargeListResolver) Name() *string {
+	name := strings.Repeat("x", 1000)
+	atomic.AddInt64(r.resolved, int64(len(name)))
+	return &name
+}
+
+// peakWriter records the largest difference between the bytes resolved and the bytes written.
+type peakWriter struct {
+	resolved *int64
+	written  int64
+	peak     int64
+}
+
+func (w *peakWriter) Write(p []byte) (int, error) {
+	if d := atomic.LoadInt64(w.resolved) - w.written; d > w.peak {
+		w.peak = d
+	}
+	w.written += int64(len(p))
+	return len(p), nil
+}
+
+func TestExecTo_largeList(t *testing.T) {
+	t.Parallel()
+
+	var resolved int64
+	schema := graphql.MustParseSchema(`
+		type Query {
+			items: [Item!]!
+		}
+
+		type Item {
+			name: String
+		}
+	`, &execToLargeListResolver{resolved: &resolved})
+
+	w := &peakWriter{resolved: &resolved}
+	if err := schema.ExecTo(context.Background(), w, `{ items { name } }`, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if resolved != 1000*1000 {
+		t.Fatalf("want %d bytes resolved, got %d", 1000*1000, resolved)
+	}
+	// the entries are written one after another instead of collecting the whole list
+	if w.peak > 10*1000 {
+		t.Errorf("want at most %d bytes resolved before they are written, got %d", 10*1000, w.peak)
+	}
+}
+
+// execToBarrier lets its callers wait until all of them are waiting.
+type execToBarrier struct {
+	mu      sync.Mutex
+	n       int
+	all     chan struct{}
+	parties int
+}
+
+func (b *execToBarrier) wait() bool {
+	b.mu.Lock()
+	b.n++
+	if b.n == b.parties {
+		close(b.all)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.all:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+type execToSiblingsResolver struct {
+	barrier *execToBarrier
+}
+
+func (r *execToSiblingsResolver) A() *execToSiblingsResolver { return r }
+
+func (r *execToSiblingsResolver) B() *execToSiblingsResolver { return r }
+
+func (r *execToSiblingsResolver) Overlaps(ctx context.Context) bool {
+	return r.barrier.wait()
+}
+
+func TestExecTo_concurrentSiblings(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		type Query {
+			a: Item!
+			b: Item
+		}
+
+		type Item {
+			overlaps: Boolean!
+		}
+	`, &execToSiblingsResolver{barrier: &execToBarrier{all: make(chan struct{}), parties: 2}})
+
+	// the resolvers of a and b are synchronous, the nested resolvers only overlap if the values of
+	// the sibling fields are executed concurrently
+	var buf strings.Builder
+	if err := schema.ExecTo(context.Background(), &buf, `{ a { overlaps } b { overlaps } }`, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":{"a":{"overlaps":true},"b":{"overlaps":true}}}`; buf.String() != want {
+		t.Errorf("unexpected response\ngot:  %s\nwant: %s", buf.String(), want)
+	}
+}
+
+type execToFuncResolver struct{}
+
+func (r *execToFuncResolver) Hello() string {
+	return "Hello!"
+}
+
+func (r *execToFuncResolver) SetHello() string {
+	return "Hello!"
+}
+
+func TestExecToFunc(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+		}
+
+		type Mutation {
+			setHello: String!
+		}
+	`, &execToFuncResolver{})
+
+	for _, test := range []struct {
+		name  string
+		query string
+		info  graphql.ResponseInfo
+		want  string
+	}{
+		{
+			name:  "query",
+			query: `{ hello }`,
+			info:  graphql.ResponseInfo{OperationType: "QUERY"},
+			want:  `{"data":{"hello":"Hello!"}}`,
+		},
+		{
+			name:  "mutation",
+			query: `mutation { setHello }`,
+			info:  graphql.ResponseInfo{OperationType: "MUTATION"},
+			want:  `{"data":{"setHello":"Hello!"}}`,
+		},
+		{
+			name:  "invalid",
+			query: `{ nope }`,
+			info:  graphql.ResponseInfo{RequestError: true},
+			want:  `{"errors":[{"message":"Cannot query field \"nope\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf strings.Builder
+			var got graphql.ResponseInfo
+			err := schema.ExecToFunc(context.Background(), &buf, test.query, "", nil, func(info graphql.ResponseInfo) error {
+				if buf.Len() != 0 {
+					t.Error("the response was written before the function was called")
+				}
+				got = info
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.info {
+				t.Errorf("got %+v, want %+v", got, test.info)
+			}
+			if buf.String() != test.want {
+				t.Errorf("unexpected response\ngot:  %s\nwant: %s", buf.String(), test.want)
+			}
+		})
+	}
+
+	t.Run("aborted", func(t *testing.T) {
+		var buf strings.Builder
+		abort := errors.New("abort")
+		err := schema.ExecToFunc(context.Background(), &buf, `{ hello }`, "", nil, func(graphql.ResponseInfo) error {
+			return abort
+		})
+		if err != abort {
+			t.Fatalf("got error %v, want %v", err, abort)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("expected no response, got %s", buf.String())
+		}
+	})
+}
+
+func TestSchema_ToSDL(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		"The root query."
+		type Query {
+			hello(name: String = "World"): String!
+		}
+
+		extend type Query {
+			version: Int
+		}
+	`, nil, graphql.UseStringDescriptions())
+
+	want := `"The root query."
+type Query {
+  hello(name: String = "World"): String!
+  version: Int
+}
+`
+	if got := s.ToSDL(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	exec := func(t *testing.T, op *graphql.PreparedOperation, vars map[string]interface{}, want string) {
+		t.Helper()
+		resp := op.Exec(context.Background(), vars)
+		got, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("unexpected response\ngot:  %s\nwant: %s", got, want)
+		}
+
+		var buf strings.Builder
+		if err := op.ExecTo(context.Background(), &buf, vars); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("unexpected response of ExecTo\ngot:  %s\nwant: %s", buf.String(), want)
+		}
+	}
+
+	t.Run("variables", func(t *testing.T) {
+		query := `
+			query HeroName($episode: Episode = JEDI, $withId: Boolean!) {
+				hero(episode: $episode) {
+					id @include(if: $withId)
+					name
+				}
+			}
+		`
+		op, errs := starwarsSchema.Prepare(context.Background(), query, "")
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		if op.OperationName() != "HeroName" || op.OperationType() != "QUERY" {
+			t.Errorf("unexpected operation %q of type %q", op.OperationName(), op.OperationType())
+		}
+		sum := sha256.Sum256([]byte(query))
+		if op.Hash() != hex.EncodeToString(sum[:]) {
+			t.Errorf("unexpected hash %s", op.Hash())
+		}
+
+		exec(t, op, map[string]interface{}{"withId": false}, `{"data":{"hero":{"name":"R2-D2"}}}`)
+		exec(t, op, map[string]interface{}{"episode": "EMPIRE", "withId": true}, `{"data":{"hero":{"id":"1000","name":"Luke Skywalker"}}}`)
+		exec(t, op, map[string]interface{}{}, `{"errors":[{"message":"Variable \"withId\" has invalid value null.\nExpected type \"Boolean!\", found null.","locations":[{"line":2,"column":45}]}]}`)
+	})
+
+	t.Run("variable fragments", func(t *testing.T) {
+		query := `
+			query($episode: Episode!, $first: Int!, $withFriends: Boolean!, $skipName: Boolean!) {
+				hero(episode: $episode) {
+					name @skip(if: $skipName)
+					...Friends @include(if: $withFriends)
+				}
+			}
+
+			fragment Friends on Character {
+				friendsConnection(first: $first) {
+					friends { name }
+				}
+				appearsIn
+			}
+		`
+		op, errs := starwarsSchema.Prepare(context.Background(), query, "")
+		if errs != nil {
+			t.Fatal(errs)
+		}
+
+		// concurrent executions with different variables share the prepared selections
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				exec(t, op, map[string]interface{}{"episode": "JEDI", "first": 1, "withFriends": true, "skipName": false},
+					`{"data":{"hero":{"name":"R2-D2","friendsConnection":{"friends":[{"name":"Luke Skywalker"}]},"appearsIn":["NEWHOPE","EMPIRE","JEDI"]}}}`)
+			}()
+			go func() {
+				defer wg.Done()
+				exec(t, op, map[string]interface{}{"episode": "EMPIRE", "first": 2, "withFriends": false, "skipName": true},
+					`{"data":{"hero":{}}}`)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("query cache and validation tracer", func(t *testing.T) {
+		tracer := &prepareValidationTracer{}
+		schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.QueryCache(1), graphql.Tracer(tracer))
+		query := `query($id: ID!) { human(id: $id) { name } }`
+		for i := 0; i < 2; i++ {
+			if _, errs := schema.Prepare(context.Background(), query, ""); errs != nil {
+				t.Fatal(errs)
+			}
+		}
+		if got, want := schema.QueryCacheStats(), (graphql.QueryCacheStats{Hits: 1, Misses: 1, Size: 1}); got != want {
+			t.Errorf("unexpected stats: want %+v, got %+v", want, got)
+		}
+
+		if _, errs := schema.Prepare(context.Background(), `{ unknown }`, ""); len(errs) != 1 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		if tracer.calls != 3 || len(tracer.errs) != 1 {
+			t.Errorf("expected 3 traced validations with 1 error, got %d with %v", tracer.calls, tracer.errs)
+		}
+	})
+
+	t.Run("static", func(t *testing.T) {
+		op, errs := starwarsSchema.Prepare(context.Background(), `{ human(id: "1000") { name } }`, "")
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				exec(t, op, nil, `{"data":{"human":{"name":"Luke Skywalker"}}}`)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("operation name", func(t *testing.T) {
+		query := `
+			query A { hero { name } }
+			query B($id: ID!) { human(id: $id) { name } }
+		`
+		op, errs := starwarsSchema.Prepare(context.Background(), query, "A")
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		// the variables of other operations are not validated
+		exec(t, op, nil, `{"data":{"hero":{"name":"R2-D2"}}}`)
+
+		if _, errs := starwarsSchema.Prepare(context.Background(), query, ""); len(errs) != 1 || errs[0].Message != "more than one operation in query document and no operation name given" {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, errs := starwarsSchema.Prepare(context.Background(), `{ unknown }`, "")
+		if len(errs) != 1 || errs[0].Message != `Cannot query field "unknown" on type "Query".` {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+}
+
+type prepareValidationTracer struct {
+	noop.Tracer
+	calls int
+	errs  []*gqlerrors.QueryError
+}
+
+func (t *prepareValidationTracer) TraceValidation(ctx context.Context) func([]*gqlerrors.QueryError) {
+	t.calls++
+	return func(errs []*gqlerrors.QueryError) {
+		t.errs = append(t.errs, errs...)
+	}
+}
+
+type resolverMapQueryResolver struct{}
+
+func (r *resolverMapQueryResolver) Greeting() string { return "Hello!" }
+
+func TestResolverMap(t *testing.T) {
+	t.Parallel()
+
+	schemaString := `
+		type Query {
+			greeting: String!
+			table(name: String!): Table
+			tables(prefix: String = "t_"): [Table!]!
+			search(text: String!): [Result!]!
+			named: [Named!]!
+		}
+
+		interface Named {
+			name: String!
+		}
+
+		type Table implements Named {
+			name: String!
+			kind: Kind!
+			columns: [Column!]!
+		}
+
+		type Column implements Named {
+			name: String!
+			nullable: Boolean!
+		}
+
+		enum Kind {
+			TABLE
+			VIEW
+		}
+
+		union Result = Table | Column
+	`
+	type row = map[string]interface{}
+	tables := []row{
+		{"name": "users", "kind": "TABLE", "columns": []row{{"name": "id", "nullable": false}, {"name": "email", "nullable": true}}},
+		{"name": "active_users", "kind": "VIEW", "columns": []row{{"name": "id", "nullable": false}}},
+	}
+	get := func(key string) graphql.ResolverFunc {
+		return func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+			return parent.(row)[key], nil
+		}
+	}
+	resolvers := graphql.ResolverMap{
+		"Query": {
+			"table": func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+				for _, t := range tables {
+					if t["name"] == args["name"] {
+						return t, nil
+					}
+				}
+				return nil, nil
+			},
+			"tables": func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return []interface{}{args["prefix"].(string) + "users"}, errors.New("not implemented")
+			},
+			"search": func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return []interface{}{tables[1], tables[0]["columns"].([]row)[1]}, nil
+			},
+			"named": func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+				return []interface{}{tables[0], tables[0]["columns"].([]row)[0]}, nil
+			},
+		},
+		"Table":  {"name": get("name"), "kind": get("kind"), "columns": get("columns")},
+		"Column": {"name": get("name"), "nullable": get("nullable")},
+	}
+	typeOf := func(value interface{}) string {
+		if _, ok := value.(row)["kind"]; ok {
+			return "Table"
+		}
+		return "Column"
+	}
+	typeResolvers := map[string]graphql.TypeResolverFunc{"Result": typeOf, "Named": typeOf}
+	schema := graphql.MustParseSchema(schemaString, &resolverMapQueryResolver{}, graphql.Resolvers(resolvers), graphql.TypeResolvers(typeResolvers))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					greeting
+					users: table(name: "users") { name kind columns { name nullable } }
+					missing: table(name: "missing") { name }
+					search(text: "a") {
+						__typename
+						... on Table { name kind }
+						... on Column { name }
+					}
+					named {
+						name
+						... on Column { nullable }
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"greeting": "Hello!",
+					"users": {
+						"name": "users",
+						"kind": "TABLE",
+						"columns": [{"name": "id", "nullable": false}, {"name": "email", "nullable": true}]
+					},
+					"missing": null,
+					"search": [
+						{"__typename": "Table", "name": "active_users", "kind": "VIEW"},
+						{"__typename": "Column", "name": "email"}
+					],
+					"named": [
+						{"name": "users"},
+						{"name": "id", "nullable": false}
+					]
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query:  `{ greeting tables { name } }`,
+			ExpectedResult: `
+				null
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "not implemented",
+				Path:          []interface{}{"tables"},
+				ResolverError: errors.New("not implemented"),
+			}},
+		},
+	})
+
+	t.Run("without root resolver", func(t *testing.T) {
+		schema := graphql.MustParseSchema(`
+			type Query {
+				hello(name: String = "world"): String!
+			}
+		`, nil, graphql.Resolvers(graphql.ResolverMap{
+			"Query": {
+				"hello": func(ctx context.Context, parent interface{}, args map[string]interface{}) (interface{}, error) {
+					if parent != nil {
+						return nil, fmt.Errorf("unexpected parent %v", parent)
+					}
+					return fmt.Sprintf("Hello, %s!", args["name"]), nil
+				},
+			},
+		}))
+		gqltesting.RunTest(t, &gqltesting.Test{
+			Schema:         schema,
+			Query:          `query($name: String) { default: hello named: hello(name: "Alice") variable: hello(name: $name) }`,
+			Variables:      map[string]interface{}{"name": "Bob"},
+			ExpectedResult: `{"default": "Hello, world!", "named": "Hello, Alice!", "variable": "Hello, Bob!"}`,
+		})
+	})
+
+	for _, tc := range []struct {
+		name          string
+		resolvers     graphql.ResolverMap
+		typeResolvers map[string]graphql.TypeResolverFunc
+		err           string
+	}{
+		{
+			name:          "missing field function",
+			resolvers:     graphql.ResolverMap{"Query": resolvers["Query"], "Table": {"name": get("name")}, "Column": resolvers["Column"]},
+			typeResolvers: typeResolvers,
+			err:           `resolver map does not resolve "Table": missing function for field "kind"` + "\n\tused by resolver map entry Query.table",
+		},
+		{
+			name:      "missing type resolver",
+			resolvers: resolvers,
+			err:       `resolver map does not resolve "Result": missing type resolver` + "\n\tused by resolver map entry Query.search",
+		},
+		{
+			name:          "unknown field",
+			resolvers:     graphql.ResolverMap{"Table": {"size": get("size")}},
+			typeResolvers: typeResolvers,
+			err:           `resolver map binds unknown field "size" of "Table"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := graphql.ParseSchema(schemaString, &resolverMapQueryResolver{}, graphql.Resolvers(tc.resolvers), graphql.TypeResolvers(tc.typeResolvers))
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("unexpected error\ngot:  %v\nwant: %s", err, tc.err)
+			}
+		})
+	}
+}
+
+type federationResolver struct {
+	calls int32
+}
+
+type federationUser struct {
+	id   graphql.ID
+	name string
+}
+
+func (u *federationUser) ID() graphql.ID { return u.id }
+func (u *federationUser) Name() string   { return u.name }
+
+type federationProduct struct {
+	upc string
+}
+
+func (p *federationProduct) Upc() string { return p.upc }
+func (p *federationProduct) Reviews() []string {
+	return []string{"Great " + p.upc}
+}
+
+func (r *federationResolver) Me() *federationUser {
+	return &federationUser{id: "1", name: "Alice"}
+}
+
+func (r *federationResolver) UserEntities(ctx context.Context, representations []map[string]interface{}) ([]*federationUser, error) {
+	atomic.AddInt32(&r.calls, 1)
+	users := make([]*federationUser, len(representations))
+	for i, rep := range representations {
+		switch rep["id"] {
+		case "1":
+			users[i] = &federationUser{id: "1", name: "Alice"}
+		case "2":
+			users[i] = &federationUser{id: "2", name: "Bob"}
+		case "error":
+			return nil, errors.New("user service unavailable")
+		}
+	}
+	return users, nil
+}
+
+func (r *federationResolver) ProductEntities(representations []map[string]interface{}) []*federationProduct {
+	atomic.AddInt32(&r.calls, 1)
+	products := make([]*federationProduct, len(representations))
+	for i, rep := range representations {
+		products[i] = &federationProduct{upc: rep["upc"].(string)}
+	}
+	return products
+}
+
+type federationQuery struct{}
+
+func (*federationQuery) Me() *federationUser { return nil }
+
+func TestFederation(t *testing.T) {
+	t.Parallel()
+
+	schemaString := `
+		type Query {
+			me: User
+		}
+
+		type User @key(fields: "id") {
+			id: ID!
+			name: String!
+		}
+
+		type Product @key(fields: "upc") @extends {
+			upc: String! @external
+			reviews: [String!]!
+		}
+	`
+	resolver := &federationResolver{}
+	schema := graphql.MustParseSchema(schemaString, resolver, graphql.Federation())
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($representations: [_Any!]!) {
+					_entities(representations: $representations) {
+						__typename
+						... on User {
+							name
+						}
+						... on Product {
+							upc
+							reviews
+						}
+					}
+				}
+			`,
+			Variables: map[string]interface{}{
+				"representations": []interface{}{
+					map[string]interface{}{"__typename": "User", "id": "2"},
+					map[string]interface{}{"__typename": "Product", "upc": "1"},
+					map[string]interface{}{"__typename": "User", "id": "3"},
+					map[string]interface{}{"__typename": "User", "id": "1"},
+				},
+			},
+			ExpectedResult: `
+				{
+					"_entities": [
+						{"__typename": "User", "name": "Bob"},
+						{"__typename": "Product", "upc": "1", "reviews": ["Great 1"]},
+						null,
+						{"__typename": "User", "name": "Alice"}
+					]
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					_entities(representations: [{__typename: "Product", upc: "2"}]) {
+						... on Product {
+							upc
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"_entities": [{"upc": "2"}]
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					_entities(representations: [{__typename: "Review", id: "1"}]) {
+						__typename
+					}
+				}
+			`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       `representation 0: "Review" is not an entity type`,
+				ResolverError: errors.New(`representation 0: "Review" is not an entity type`),
+				Path:          []interface{}{"_entities"},
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					_entities(representations: [{__typename: "User", id: "error"}]) {
+						__typename
+					}
+				}
+			`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       `user service unavailable`,
+				ResolverError: errors.New(`user service unavailable`),
+				Path:          []interface{}{"_entities"},
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					__type(name: "_Entity") {
+						possibleTypes {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"__type": {
+						"possibleTypes": [{"name": "User"}, {"name": "Product"}]
+					}
+				}
+			`,
+		},
+	})
+
+	t.Run("batches the representations per type", func(t *testing.T) {
+		before := atomic.LoadInt32(&resolver.calls)
+		resp := schema.Exec(context.Background(), `{
+			_entities(representations: [
+				{__typename: "User", id: "1"},
+				{__typename: "User", id: "2"},
+				{__typename: "Product", upc: "1"},
+				{__typename: "User", id: "3"}
+			]) { __typename }
+		}`, "", nil)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if calls := atomic.LoadInt32(&resolver.calls) - before; calls != 2 {
+			t.Fatalf("got %d calls of the entity resolvers, want 2", calls)
+		}
+	})
+
+	t.Run("requires an entity resolver per entity type", func(t *testing.T) {
+		_, err := graphql.ParseSchema(schemaString, &federationQuery{}, graphql.Federation())
+		if err == nil || !strings.Contains(err.Error(), `does not resolve entities of "User": missing method "UserEntities"`) {
+			t.Fatalf("got error %v", err)
+		}
+	})
+}
+
+func TestFederationV2(t *testing.T) {
+	t.Parallel()
+
+	schemaString := `
+		extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key", "@shareable", {name: "@tag", as: "@label"}])
+
+		type Query {
+			me: User
+		}
+
+		type User @key(fields: "id") @shareable {
+			id: ID!
+			name: String! @label(name: "public") @federation__inaccessible
+		}
+
+		type Product @key(fields: "upc", resolvable: false) {
+			upc: String!
+			reviews: [String!]!
+		}
+	`
+	schema := graphql.MustParseSchema(schemaString, &federationResolver{}, graphql.Federation())
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					_entities(representations: [{__typename: "User", id: "1"}]) {
+						... on User {
+							name
+						}
+					}
+					__type(name: "_Entity") {
+						possibleTypes {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"_entities": [{"name": "Alice"}],
+					"__type": {
+						"possibleTypes": [{"name": "User"}]
+					}
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					_service {
+						sdl
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"_service": {
+						"sdl": "extend schema @link(url: \"https://specs.apollo.dev/federation/v2.3\", import: [\"@key\", \"@shareable\", {name: \"@tag\", as: \"@label\"}])\n\ntype Query {\n  me: User\n}\n\ntype User @key(fields: \"id\") @shareable {\n  id: ID!\n  name: String! @label(name: \"public\") @federation__inaccessible\n}\n\ntype Product @key(fields: \"upc\", resolvable: false) {\n  upc: String!\n  reviews: [String!]!\n}\n"
+					}
+				}
+			`,
+		},
+	})
+
+	for _, test := range []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{
+			name: "directives which are not imported are namespaced",
+			schema: `
+				extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+				type Query { me: User }
+				type User @key(fields: "id") { id: ID! @shareable }
+			`,
+			err: `directive "shareable" not found`,
+		},
+		{
+			name: "unknown imports",
+			schema: `
+				extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key", "@unknown"])
+				type Query { me: User }
+				type User @key(fields: "id") { id: ID! }
+			`,
+			err: `"@unknown" is not an element of the federation specification "https://specs.apollo.dev/federation/v2.0"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := graphql.ParseSchema(test.schema, &federationResolver{}, graphql.Federation())
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
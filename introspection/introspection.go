@@ -203,6 +203,15 @@ func (r *Type) SpecifiedByURL() *string {
 	return nil
 }
 
+func (r *Type) IsOneOf() *bool {
+	t, ok := r.typ.(*types.InputObject)
+	if !ok {
+		return nil
+	}
+	isOneOf := t.IsOneOf()
+	return &isOneOf
+}
+
 type Field struct {
 	field *types.FieldDefinition
 }
@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/exec/selected"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/types"
+	"github.com/graph-gophers/graphql-go/validation"
+)
+
+// PreparedOperation is an operation which is parsed, validated and applied to the schema once by
+// Schema.Prepare and can then be executed many times with different variables. It is safe for
+// concurrent use.
+type PreparedOperation struct {
+	schema        *Schema
+	queryString   string
+	operationName string
+	hash          string
+	doc           *types.ExecutableDefinition
+	op            *types.OperationDefinition
+	varTypes      map[string]*introspection.Type
+
+	// sels are the selections of the operation prepared with selected.PrepareOperation, or nil if
+	// the operation is applied on every execution
+	sels []selected.Selection
+}
+
+// Prepare parses and validates the query like Exec, using the QueryCache and the validation tracer,
+// and selects the operation to execute. The operation is also applied to the schema, so executing it
+// only validates the variables and runs the resolvers. The arguments of the fields and the conditions
+// of @skip and @include which depend on the variables are the only parts of the operation which are
+// evaluated again on every execution.
+func (s *Schema) Prepare(ctx context.Context, queryString string, operationName string) (*PreparedOperation, []*errors.QueryError) {
+	doc, errs := s.parseAndValidateDocument(ctx, queryString, nil)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return nil, []*errors.QueryError{errors.Errorf("%s", err)}
+	}
+	if operationName == "" {
+		operationName = op.Name.Name
+	}
+	if qErr := s.checkOperation(op); qErr != nil {
+		return nil, []*errors.QueryError{qErr}
+	}
+	varTypes, qErr := s.variableTypes(op)
+	if qErr != nil {
+		return nil, []*errors.QueryError{qErr}
+	}
+
+	sum := sha256.Sum256([]byte(queryString))
+	return &PreparedOperation{
+		schema:        s,
+		queryString:   queryString,
+		operationName: operationName,
+		hash:          hex.EncodeToString(sum[:]),
+		doc:           doc,
+		op:            op,
+		varTypes:      varTypes,
+		sels:          s.prepareSelections(doc, op),
+	}, nil
+}
+
+// prepareSelections returns the selections of an operation prepared independently of the variables,
+// or nil if they have to be applied for every execution.
+func (s *Schema) prepareSelections(doc *types.ExecutableDefinition, op *types.OperationDefinition) (sels []selected.Selection) {
+	if !s.res.Resolver.IsValid() {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			sels = nil // reported by the execution
+		}
+	}()
+
+	r := &selected.Request{
+		Doc:                  doc,
+		Vars:                 map[string]interface{}{},
+		Schema:               s.schema,
+		DisableIntrospection: s.disableIntrospection,
+	}
+	sels = selected.PrepareOperation(r, s.res, op)
+	if len(r.Errs) != 0 {
+		return nil
+	}
+	return sels
+}
+
+// Exec executes the prepared operation with the given variables.
+func (p *PreparedOperation) Exec(ctx context.Context, variables map[string]interface{}) *Response {
+	req, resp := p.request(ctx, variables)
+	if resp != nil {
+		return resp
+	}
+	data, errs := req.exec.Execute(req.ctx, p.schema.res, req.op)
+	req.finish(errs)
+
+	return &Response{
+		Data:       data,
+		Errors:     errs,
+		Extensions: req.extensions,
+	}
+}
+
+// ExecTo executes the prepared operation with the given variables like Schema.ExecTo, writing the
+// JSON encoded response to w while it is being executed.
+func (p *PreparedOperation) ExecTo(ctx context.Context, w io.Writer, variables map[string]interface{}) error {
+	req, resp := p.request(ctx, variables)
+	return p.schema.execTo(w, req, resp, nil)
+}
+
+// request validates the variables and returns the request to execute, or the response if the
+// request failed.
+func (p *PreparedOperation) request(ctx context.Context, variables map[string]interface{}) (*request, *Response) {
+	s := p.schema
+	if !s.res.Resolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+
+	validationFinish := s.validationTracer.TraceValidation(ctx)
+	errs := validation.ValidateOperationVariables(s.schema, p.doc, p.op, variables)
+	validationFinish(errs)
+	if len(errs) != 0 {
+		return nil, &Response{Errors: errs}
+	}
+	return s.newRequest(ctx, p.queryString, p.operationName, p.doc, p.op, variables, p.varTypes, p.sels)
+}
+
+// OperationName returns the name of the operation, which is empty for an anonymous operation.
+func (p *PreparedOperation) OperationName() string {
+	return p.operationName
+}
+
+// OperationType returns the type of the operation, query.Query or query.Mutation.
+func (p *PreparedOperation) OperationType() types.OperationType {
+	return p.op.Type
+}
+
+// Hash returns the hex encoded SHA-256 hash of the query, as used by automatic persisted queries.
+func (p *PreparedOperation) Hash() string {
+	return p.hash
+}
+
+// Complexity returns the static cost of the operation with the given variables, see MaxComplexity.
+func (p *PreparedOperation) Complexity(variables map[string]interface{}) int {
+	return validation.Complexity(p.schema.schema, p.doc, p.op, applyVariableDefaults(p.op, copyVariables(variables)))
+}
+
+// copyVariables returns a shallow copy of the variables, which applyVariableDefaults may change.
+func copyVariables(variables map[string]interface{}) map[string]interface{} {
+	vars := make(map[string]interface{}, len(variables))
+	for name, v := range variables {
+		vars[name] = v
+	}
+	return vars
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// QueryCacheStats reports the usage of the query cache enabled with QueryCache.
+type QueryCacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// queryCache is a concurrency-safe LRU cache of parsed documents and the results of their
+// variable independent validation, keyed by the query string.
+type queryCache struct {
+	// accessed atomically, first for 64-bit alignment on 32-bit platforms
+	hits   uint64
+	misses uint64
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type queryCacheEntry struct {
+	query string
+	doc   *types.ExecutableDefinition
+	errs  []*errors.QueryError
+}
+
+func newQueryCache(size int) *queryCache {
+	return &queryCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *queryCache) get(query string) (*queryCacheEntry, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[query]
+	if ok {
+		c.order.MoveToFront(e)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return e.Value.(*queryCacheEntry), true
+}
+
+func (c *queryCache) add(entry *queryCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[entry.query]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[entry.query] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).query)
+	}
+}
+
+func (c *queryCache) stats() QueryCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return QueryCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Size:   size,
+	}
+}
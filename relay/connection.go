@@ -0,0 +1,293 @@
+package relay
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ConnectionArguments are the arguments of a connection field, see
+// https://relay.dev/graphql/connections.htm, e.g.
+// "users(" + relay.ConnectionArguments + "): UserConnection!".
+const ConnectionArguments = "first: Int, after: String, last: Int, before: String"
+
+// PageInfoSDL is the definition of the PageInfo type shared by all connections. It has to be added
+// to a schema once, along with the definitions of ConnectionSDL.
+const PageInfoSDL = `
+type PageInfo {
+	hasPreviousPage: Boolean!
+	hasNextPage: Boolean!
+	startCursor: String
+	endCursor: String
+}
+`
+
+// ConnectionSDL returns the definitions of the connection and edge types of the node type, e.g.
+// UserConnection and UserEdge for User. Their resolvers embed a Connection and its Edges.
+func ConnectionSDL(nodeType string) string {
+	return fmt.Sprintf(`
+type %[1]sConnection {
+	edges: [%[1]sEdge!]!
+	pageInfo: PageInfo!
+	totalCount: Int!
+}
+
+type %[1]sEdge {
+	cursor: String!
+	node: %[1]s!
+}
+`, nodeType)
+}
+
+const cursorPrefix = "cursor:"
+
+// EncodeCursor returns the opaque cursor of the node at the offset of a connection.
+func EncodeCursor(offset int) string {
+	return base64.URLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// DecodeCursor returns the offset of the node of a cursor returned by EncodeCursor.
+func DecodeCursor(cursor string) (int, error) {
+	s, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(s), cursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(s), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
+
+// ConnectionArgs are the pagination arguments of a connection field. They can be embedded in the
+// arguments struct of the resolver of the field:
+//
+//	func (r *Resolver) Users(args struct {
+//		relay.ConnectionArgs
+//		Role string
+//	}) (*userConnection, error)
+type ConnectionArgs struct {
+	First  *int32
+	After  *string
+	Last   *int32
+	Before *string
+}
+
+// Validate reports an error if first or last are negative or if a cursor is invalid.
+func (a ConnectionArgs) Validate() error {
+	if a.First != nil && *a.First < 0 {
+		return fmt.Errorf(`"first" must not be negative, got %d`, *a.First)
+	}
+	if a.Last != nil && *a.Last < 0 {
+		return fmt.Errorf(`"last" must not be negative, got %d`, *a.Last)
+	}
+	if a.After != nil {
+		if _, err := DecodeCursor(*a.After); err != nil {
+			return fmt.Errorf(`"after": %s`, err)
+		}
+	}
+	if a.Before != nil {
+		if _, err := DecodeCursor(*a.Before); err != nil {
+			return fmt.Errorf(`"before": %s`, err)
+		}
+	}
+	return nil
+}
+
+// Page returns the page of a connection with the total number of nodes selected by the arguments.
+// The nodes of the page are the slice nodes[page.Offset:page.End()] of all nodes, or the result of
+// a query with the Offset and Limit of the page.
+func (a ConnectionArgs) Page(total int) (*Page, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	start, end := 0, total
+	if a.Before != nil {
+		before, _ := DecodeCursor(*a.Before)
+		if before < end {
+			end = before
+		}
+	}
+	if a.After != nil {
+		// the offsets are compared before adding one, a cursor can hold the largest int
+		after, _ := DecodeCursor(*a.After)
+		if after < end {
+			start = after + 1
+		} else {
+			start = end
+		}
+	}
+	if a.First != nil && int(*a.First) < end-start {
+		end = start + int(*a.First)
+	}
+	if a.Last != nil && int(*a.Last) < end-start {
+		start = end - int(*a.Last)
+	}
+
+	return &Page{Offset: start, Limit: end - start, Total: total}, nil
+}
+
+// Page is a page of a connection.
+type Page struct {
+	// Offset is the offset of the first node of the page.
+	Offset int
+	// Limit is the number of nodes of the page.
+	Limit int
+	// Total is the number of nodes of the connection.
+	Total int
+}
+
+// End returns the offset after the last node of the page.
+func (p *Page) End() int {
+	return p.Offset + p.Limit
+}
+
+// Cursor returns the cursor of the i-th node of the page.
+func (p *Page) Cursor(i int) string {
+	return EncodeCursor(p.Offset + i)
+}
+
+// PageInfo returns the resolver of the PageInfo of the page.
+func (p *Page) PageInfo() *PageInfo {
+	info := &PageInfo{
+		hasPreviousPage: p.Offset > 0,
+		hasNextPage:     p.End() < p.Total,
+	}
+	if p.Limit > 0 {
+		start, end := p.Cursor(0), p.Cursor(p.Limit-1)
+		info.startCursor, info.endCursor = &start, &end
+	}
+	return info
+}
+
+// PageInfo resolves the PageInfo type of PageInfoSDL.
+type PageInfo struct {
+	hasPreviousPage bool
+	hasNextPage     bool
+	startCursor     *string
+	endCursor       *string
+}
+
+func (p *PageInfo) HasPreviousPage() bool {
+	return p.hasPreviousPage
+}
+
+func (p *PageInfo) HasNextPage() bool {
+	return p.hasNextPage
+}
+
+func (p *PageInfo) StartCursor() *string {
+	return p.startCursor
+}
+
+func (p *PageInfo) EndCursor() *string {
+	return p.endCursor
+}
+
+// Connection resolves the pageInfo and totalCount fields of a connection type of ConnectionSDL. It
+// is built by ConnectionFromSlice or ConnectionFromSource and embedded in the resolver of the
+// connection type, which only adds the edges with the resolver of the node type:
+//
+//	type userConnection struct{ *relay.Connection }
+//
+//	func (c userConnection) Edges() []userEdge {
+//		edges := make([]userEdge, len(c.Connection.Edges()))
+//		for i, e := range c.Connection.Edges() {
+//			edges[i] = userEdge{e}
+//		}
+//		return edges
+//	}
+//
+//	type userEdge struct{ *relay.Edge }
+//
+//	func (e userEdge) Node() *User {
+//		return e.Value().(*User)
+//	}
+type Connection struct {
+	page  *Page
+	edges []*Edge
+}
+
+// ConnectionFromSlice returns the connection of the page of nodes selected by the arguments. The
+// nodes must be a slice of all nodes of the connection.
+func ConnectionFromSlice(args ConnectionArgs, nodes interface{}) (*Connection, error) {
+	v := reflect.ValueOf(nodes)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("nodes must be a slice, got %T", nodes)
+	}
+	page, err := args.Page(v.Len())
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(page, v.Slice(page.Offset, page.End())), nil
+}
+
+// ConnectionFromSource returns the connection of the page of nodes selected by the arguments out of
+// total nodes. The nodes of the page are loaded by calling load with the offset and limit of the
+// page, it must return a slice of at most limit nodes.
+func ConnectionFromSource(args ConnectionArgs, total int, load func(offset, limit int) (interface{}, error)) (*Connection, error) {
+	page, err := args.Page(total)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := load(page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(nodes)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("load must return a slice, got %T", nodes)
+	}
+	if v.Len() > page.Limit {
+		return nil, fmt.Errorf("load returned %d nodes, the limit is %d", v.Len(), page.Limit)
+	}
+	// the source may have shrunk since total was counted
+	page.Limit = v.Len()
+	return newConnection(page, v), nil
+}
+
+func newConnection(page *Page, nodes reflect.Value) *Connection {
+	c := &Connection{page: page, edges: make([]*Edge, nodes.Len())}
+	for i := range c.edges {
+		c.edges[i] = &Edge{cursor: page.Cursor(i), value: nodes.Index(i).Interface()}
+	}
+	return c
+}
+
+// Page returns the page of the connection.
+func (c *Connection) Page() *Page {
+	return c.page
+}
+
+// Edges returns the edges of the page. The resolver of the connection type wraps them with the
+// resolver of its edge type.
+func (c *Connection) Edges() []*Edge {
+	return c.edges
+}
+
+func (c *Connection) PageInfo() *PageInfo {
+	return c.page.PageInfo()
+}
+
+func (c *Connection) TotalCount() int32 {
+	return int32(c.page.Total)
+}
+
+// Edge resolves the cursor field of an edge type of ConnectionSDL. It is embedded in the resolver of
+// the edge type, which adds the node field returning Value as the resolver of the node type.
+type Edge struct {
+	cursor string
+	value  interface{}
+}
+
+func (e *Edge) Cursor() string {
+	return e.cursor
+}
+
+// Value returns the node of the edge.
+func (e *Edge) Value() interface{} {
+	return e.value
+}
@@ -0,0 +1,242 @@
+package relay_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+type connectionResolver struct {
+	users []*user
+}
+
+func (r *connectionResolver) Users(args struct{ relay.ConnectionArgs }) (*userConnection, error) {
+	c, err := relay.ConnectionFromSlice(args.ConnectionArgs, r.users)
+	if err != nil {
+		return nil, err
+	}
+	return &userConnection{c}, nil
+}
+
+func (r *connectionResolver) PagedUsers(args struct{ relay.ConnectionArgs }) (*userConnection, error) {
+	c, err := relay.ConnectionFromSource(args.ConnectionArgs, len(r.users), func(offset, limit int) (interface{}, error) {
+		return r.users[offset : offset+limit], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &userConnection{c}, nil
+}
+
+type userConnection struct {
+	*relay.Connection
+}
+
+func (c *userConnection) Edges() []userEdge {
+	edges := make([]userEdge, len(c.Connection.Edges()))
+	for i, e := range c.Connection.Edges() {
+		edges[i] = userEdge{e}
+	}
+	return edges
+}
+
+type userEdge struct {
+	*relay.Edge
+}
+
+func (e userEdge) Node() *user {
+	return e.Value().(*user)
+}
+
+type user struct {
+	name string
+}
+
+func (u *user) Name() string {
+	return u.name
+}
+
+var connectionSchema = graphql.MustParseSchema(`
+	type Query {
+		users(`+relay.ConnectionArguments+`): UserConnection!
+		pagedUsers(`+relay.ConnectionArguments+`): UserConnection!
+	}
+
+	type User {
+		name: String!
+	}
+`+relay.ConnectionSDL("User")+relay.PageInfoSDL, &connectionResolver{users: []*user{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}})
+
+const maxInt = int(^uint(0) >> 1)
+
+func TestConnection(t *testing.T) {
+	const query = `query($first: Int, $after: String, $last: Int, $before: String) {
+		users(first: $first, after: $after, last: $last, before: $before) { ...connection }
+		pagedUsers(first: $first, after: $after, last: $last, before: $before) { ...connection }
+	}
+
+	fragment connection on UserConnection {
+		edges { cursor node { name } }
+		pageInfo { hasPreviousPage hasNextPage startCursor endCursor }
+		totalCount
+	}`
+	c := relay.EncodeCursor
+
+	for _, test := range []struct {
+		name      string
+		variables map[string]interface{}
+		want      []string
+		prev      bool
+		next      bool
+		err       string
+	}{
+		{
+			name: "all",
+			want: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:      "first",
+			variables: map[string]interface{}{"first": 2},
+			want:      []string{"a", "b"},
+			next:      true,
+		},
+		{
+			name:      "first after",
+			variables: map[string]interface{}{"first": 2, "after": c(1)},
+			want:      []string{"c", "d"},
+			prev:      true,
+			next:      true,
+		},
+		{
+			name:      "last",
+			variables: map[string]interface{}{"last": 2},
+			want:      []string{"d", "e"},
+			prev:      true,
+		},
+		{
+			name:      "last before",
+			variables: map[string]interface{}{"last": 2, "before": c(2)},
+			want:      []string{"a", "b"},
+			next:      true,
+		},
+		{
+			name:      "after and before",
+			variables: map[string]interface{}{"after": c(0), "before": c(4)},
+			want:      []string{"b", "c", "d"},
+			prev:      true,
+			next:      true,
+		},
+		{
+			name:      "after the end",
+			variables: map[string]interface{}{"after": c(10)},
+			prev:      true,
+		},
+		{
+			name:      "after the largest cursor",
+			variables: map[string]interface{}{"first": 2, "after": c(maxInt)},
+			prev:      true,
+		},
+		{
+			name:      "first zero",
+			variables: map[string]interface{}{"first": 0},
+			next:      true,
+		},
+		{
+			name:      "negative first",
+			variables: map[string]interface{}{"first": -1},
+			err:       `"first" must not be negative, got -1`,
+		},
+		{
+			name:      "negative last",
+			variables: map[string]interface{}{"last": -1},
+			err:       `"last" must not be negative, got -1`,
+		},
+		{
+			name:      "invalid cursor",
+			variables: map[string]interface{}{"after": "abc"},
+			err:       `"after": invalid cursor "abc"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resp := connectionSchema.Exec(context.Background(), query, "", test.variables)
+			if test.err != "" {
+				if len(resp.Errors) != 2 || resp.Errors[0].Message != test.err || resp.Errors[1].Message != test.err {
+					t.Fatalf("expected error %q, got %v", test.err, resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) != 0 {
+				t.Fatal(resp.Errors)
+			}
+
+			var data map[string]struct {
+				Edges []struct {
+					Cursor string
+					Node   struct{ Name string }
+				}
+				PageInfo struct {
+					HasPreviousPage bool
+					HasNextPage     bool
+					StartCursor     *string
+					EndCursor       *string
+				}
+				TotalCount int
+			}
+			if err := json.Unmarshal(resp.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, field := range []string{"users", "pagedUsers"} {
+				users := data[field]
+				var names, cursors []string
+				for _, e := range users.Edges {
+					names = append(names, e.Node.Name)
+					cursors = append(cursors, e.Cursor)
+				}
+				if len(names) != len(test.want) {
+					t.Fatalf("%s: expected nodes %v, got %v", field, test.want, names)
+				}
+				for i := range names {
+					if names[i] != test.want[i] {
+						t.Fatalf("%s: expected nodes %v, got %v", field, test.want, names)
+					}
+				}
+				info := users.PageInfo
+				if info.HasPreviousPage != test.prev || info.HasNextPage != test.next {
+					t.Errorf("%s: expected hasPreviousPage %t and hasNextPage %t, got %t and %t", field, test.prev, test.next, info.HasPreviousPage, info.HasNextPage)
+				}
+				if len(cursors) == 0 {
+					if info.StartCursor != nil || info.EndCursor != nil {
+						t.Errorf("%s: expected no start and end cursors for an empty page", field)
+					}
+				} else if info.StartCursor == nil || *info.StartCursor != cursors[0] || info.EndCursor == nil || *info.EndCursor != cursors[len(cursors)-1] {
+					t.Errorf("%s: expected start and end cursors %s and %s", field, cursors[0], cursors[len(cursors)-1])
+				}
+				if users.TotalCount != 5 {
+					t.Errorf("%s: expected totalCount 5, got %d", field, users.TotalCount)
+				}
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	for _, offset := range []int{0, 1, 42, maxInt} {
+		got, err := relay.DecodeCursor(relay.EncodeCursor(offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != offset {
+			t.Errorf("expected offset %d, got %d", offset, got)
+		}
+	}
+
+	for _, cursor := range []string{"", "abc", relay.EncodeCursor(-1), string(relay.MarshalID("User", 1))} {
+		if _, err := relay.DecodeCursor(cursor); err == nil {
+			t.Errorf("expected an error for cursor %q", cursor)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// InvalidIDError is the error of an ID which was not returned by MarshalID.
+type InvalidIDError struct {
+	ID graphql.ID
+}
+
+func (e *InvalidIDError) Error() string {
+	return fmt.Sprintf("invalid ID %q", string(e.ID))
+}
+
+func (e *InvalidIDError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "INVALID_ID"}
+}
+
+// UnknownKindError is the error of an ID of a kind without a fetcher in the NodeRegistry.
+type UnknownKindError struct {
+	ID   graphql.ID
+	Kind string
+}
+
+func (e *UnknownKindError) Error() string {
+	return fmt.Sprintf("unknown kind %q of ID %q", e.Kind, string(e.ID))
+}
+
+func (e *UnknownKindError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "UNKNOWN_KIND"}
+}
+
+// KindOf returns the kind of an ID returned by MarshalID. Unlike UnmarshalKind it reports an
+// *InvalidIDError for a malformed ID.
+func KindOf(id graphql.ID) (string, error) {
+	kind := UnmarshalKind(id)
+	if kind == "" {
+		return "", &InvalidIDError{ID: id}
+	}
+	return kind, nil
+}
+
+// NodeFetcher fetches the nodes of one kind. It returns the resolvers of the nodes in the order of
+// the IDs, with nil for the nodes which do not exist. The specs of the IDs are read with
+// UnmarshalSpec.
+type NodeFetcher func(ctx context.Context, ids []graphql.ID) ([]interface{}, error)
+
+// NodeRegistry fetches the nodes of the node and nodes fields of the Global Object Identification
+// specification, see https://graphql.org/learn/global-object-identification/, by dispatching the
+// IDs to the fetchers of their kinds:
+//
+//	interface Node {
+//		id: ID!
+//	}
+//
+//	type Query {
+//		node(id: ID!): Node
+//		nodes(ids: [ID!]!): [Node]!
+//	}
+//
+// The resolver of the Node interface embeds the *Node returned by the registry, which resolves the
+// id field, and converts it to the resolvers of the object types with Node.As:
+//
+//	type nodeResolver struct{ *relay.Node }
+//
+//	func (r nodeResolver) ToUser() (u *userResolver, ok bool) {
+//		ok = r.As(&u)
+//		return
+//	}
+//
+// The To<Type> methods depend on the resolvers of the schema, so the node and nodes methods of the
+// root resolver return the nodeResolver of the schema. The nodes method returns a partial list, with
+// null for the IDs which can not be fetched:
+//
+//	func (r *Resolver) Nodes(ctx context.Context, args struct{ IDs []graphql.ID }) ([]*nodeResolver, error) {
+//		nodes, _, err := r.nodes.Nodes(ctx, args.IDs)
+//		if err != nil {
+//			return nil, err
+//		}
+//		res := make([]*nodeResolver, len(nodes))
+//		for i, n := range nodes {
+//			if n != nil {
+//				res[i] = &nodeResolver{n}
+//			}
+//		}
+//		return res, nil
+//	}
+type NodeRegistry struct {
+	mu       sync.RWMutex
+	fetchers map[string]NodeFetcher
+}
+
+// NewNodeRegistry returns an empty NodeRegistry.
+func NewNodeRegistry() *NodeRegistry {
+	return &NodeRegistry{fetchers: make(map[string]NodeFetcher)}
+}
+
+// Register sets the fetcher of the nodes of a kind, i.e. of the IDs returned by MarshalID(kind, ...).
+func (r *NodeRegistry) Register(kind string, fetch NodeFetcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetchers[kind] = fetch
+}
+
+// Node fetches the node with the ID. It returns nil if the node does not exist, an *InvalidIDError
+// if the ID is malformed and an *UnknownKindError if its kind is not registered.
+func (r *NodeRegistry) Node(ctx context.Context, id graphql.ID) (*Node, error) {
+	nodes, idErrs, err := r.Nodes(ctx, []graphql.ID{id})
+	if err != nil {
+		return nil, err
+	}
+	if idErrs[0] != nil {
+		return nil, idErrs[0]
+	}
+	return nodes[0], nil
+}
+
+// Nodes fetches the nodes with the IDs, in their order. The IDs of each kind are fetched with a
+// single call of the fetcher of the kind, and repeated IDs are only fetched once. The nodes of
+// malformed IDs and of IDs of unknown kinds are nil, and the errors, in the order of the IDs, hold
+// their *InvalidIDError and *UnknownKindError. The error of a fetcher fails all nodes.
+func (r *NodeRegistry) Nodes(ctx context.Context, ids []graphql.ID) ([]*Node, []error, error) {
+	var kinds []string
+	batches := make(map[string][]graphql.ID)
+	indexes := make(map[graphql.ID][]int)
+	idErrs := make([]error, len(ids))
+	r.mu.RLock()
+	for i, id := range ids {
+		if _, ok := indexes[id]; ok {
+			indexes[id] = append(indexes[id], i)
+			continue
+		}
+		kind, err := KindOf(id)
+		if err != nil {
+			idErrs[i] = err
+			continue
+		}
+		if _, ok := r.fetchers[kind]; !ok {
+			idErrs[i] = &UnknownKindError{ID: id, Kind: kind}
+			continue
+		}
+		if _, ok := batches[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		batches[kind] = append(batches[kind], id)
+		indexes[id] = []int{i}
+	}
+	fetchers := make(map[string]NodeFetcher, len(kinds))
+	for _, kind := range kinds {
+		fetchers[kind] = r.fetchers[kind]
+	}
+	r.mu.RUnlock()
+
+	nodes := make([]*Node, len(ids))
+	for _, kind := range kinds {
+		batch := batches[kind]
+		resolvers, err := fetchers[kind](ctx, batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(resolvers) != len(batch) {
+			return nil, nil, fmt.Errorf("fetcher of kind %q returned %d nodes for %d IDs", kind, len(resolvers), len(batch))
+		}
+		for j, id := range batch {
+			if isNil(resolvers[j]) {
+				continue
+			}
+			n := &Node{id: id, Kind: kind, Resolver: resolvers[j]}
+			for _, i := range indexes[id] {
+				nodes[i] = n
+			}
+		}
+	}
+	return nodes, idErrs, nil
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// Node is a node fetched by a NodeRegistry.
+type Node struct {
+	id graphql.ID
+	// Kind is the kind of the ID of the node.
+	Kind string
+	// Resolver is the resolver of the node returned by the fetcher of its kind.
+	Resolver interface{}
+}
+
+// ID resolves the id field of the Node interface.
+func (n *Node) ID() graphql.ID {
+	return n.id
+}
+
+// As sets the target, a non-nil pointer, to the resolver of the node and reports true if the
+// resolver is assignable to the type of the target. It implements the To<Type> methods of the
+// resolver of the Node interface.
+func (n *Node) As(target interface{}) bool {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic("relay: target must be a non-nil pointer")
+	}
+	r := reflect.ValueOf(n.Resolver)
+	if !r.Type().AssignableTo(v.Elem().Type()) {
+		return false
+	}
+	v.Elem().Set(r)
+	return true
+}
@@ -0,0 +1,270 @@
+package relay_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+type nodeUser struct {
+	id   graphql.ID
+	name string
+}
+
+func (u *nodeUser) ID() graphql.ID {
+	return u.id
+}
+
+func (u *nodeUser) Name() string {
+	return u.name
+}
+
+type nodePost struct {
+	id    graphql.ID
+	title string
+}
+
+func (p *nodePost) ID() graphql.ID {
+	return p.id
+}
+
+func (p *nodePost) Title() string {
+	return p.title
+}
+
+type nodeResolver struct{ *relay.Node }
+
+func (r nodeResolver) ToUser() (u *nodeUser, ok bool) {
+	ok = r.As(&u)
+	return
+}
+
+func (r nodeResolver) ToPost() (p *nodePost, ok bool) {
+	ok = r.As(&p)
+	return
+}
+
+type nodeQueryResolver struct {
+	nodes *relay.NodeRegistry
+}
+
+func (r *nodeQueryResolver) Node(ctx context.Context, args struct{ ID graphql.ID }) (*nodeResolver, error) {
+	n, err := r.nodes.Node(ctx, args.ID)
+	if n == nil {
+		return nil, err
+	}
+	return &nodeResolver{n}, nil
+}
+
+func (r *nodeQueryResolver) Nodes(ctx context.Context, args struct{ IDs []graphql.ID }) ([]*nodeResolver, error) {
+	nodes, _, err := r.nodes.Nodes(ctx, args.IDs)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*nodeResolver, len(nodes))
+	for i, n := range nodes {
+		if n != nil {
+			res[i] = &nodeResolver{n}
+		}
+	}
+	return res, nil
+}
+
+func newNodeRegistry(fetches map[string][][]graphql.ID) *relay.NodeRegistry {
+	users := map[int]string{1: "Alice", 2: "Bob"}
+	posts := map[int]string{1: "Hello"}
+
+	reg := relay.NewNodeRegistry()
+	reg.Register("User", func(ctx context.Context, ids []graphql.ID) ([]interface{}, error) {
+		fetches["User"] = append(fetches["User"], ids)
+		res := make([]interface{}, len(ids))
+		for i, id := range ids {
+			var n int
+			if err := relay.UnmarshalSpec(id, &n); err != nil {
+				return nil, err
+			}
+			if name, ok := users[n]; ok {
+				res[i] = &nodeUser{id: id, name: name}
+			}
+		}
+		return res, nil
+	})
+	reg.Register("Post", func(ctx context.Context, ids []graphql.ID) ([]interface{}, error) {
+		fetches["Post"] = append(fetches["Post"], ids)
+		res := make([]interface{}, len(ids))
+		for i, id := range ids {
+			var n int
+			if err := relay.UnmarshalSpec(id, &n); err != nil {
+				return nil, err
+			}
+			if title, ok := posts[n]; ok {
+				res[i] = &nodePost{id: id, title: title}
+			} else {
+				res[i] = (*nodePost)(nil)
+			}
+		}
+		return res, nil
+	})
+	return reg
+}
+
+func newNodeSchema(reg *relay.NodeRegistry) *graphql.Schema {
+	return graphql.MustParseSchema(`
+		interface Node {
+			id: ID!
+		}
+
+		type User implements Node {
+			id: ID!
+			name: String!
+		}
+
+		type Post implements Node {
+			id: ID!
+			title: String!
+		}
+
+		type Query {
+			node(id: ID!): Node
+			nodes(ids: [ID!]!): [Node]!
+		}
+	`, &nodeQueryResolver{nodes: reg})
+}
+
+func TestNodeRegistry(t *testing.T) {
+	const query = `query($ids: [ID!]!) {
+		nodes(ids: $ids) {
+			__typename
+			id
+			... on User { name }
+			... on Post { title }
+		}
+	}`
+
+	t.Run("nodes", func(t *testing.T) {
+		fetches := make(map[string][][]graphql.ID)
+		schema := newNodeSchema(newNodeRegistry(fetches))
+		alice, bob, hello := relay.MarshalID("User", 1), relay.MarshalID("User", 2), relay.MarshalID("Post", 1)
+		ids := []interface{}{string(alice), string(hello), string(relay.MarshalID("User", 3)), string(bob), string(alice), string(relay.MarshalID("Post", 2))}
+
+		resp := schema.Exec(context.Background(), query, "", map[string]interface{}{"ids": ids})
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		want := `{"nodes":[` +
+			`{"__typename":"User","id":"` + string(alice) + `","name":"Alice"},` +
+			`{"__typename":"Post","id":"` + string(hello) + `","title":"Hello"},` +
+			`null,` +
+			`{"__typename":"User","id":"` + string(bob) + `","name":"Bob"},` +
+			`{"__typename":"User","id":"` + string(alice) + `","name":"Alice"},` +
+			`null]}`
+		if string(resp.Data) != want {
+			t.Fatalf("unexpected response\ngot:  %s\nwant: %s", resp.Data, want)
+		}
+
+		// the IDs of each kind are fetched at once, without the repeated ID
+		if len(fetches["User"]) != 1 || len(fetches["User"][0]) != 3 {
+			t.Errorf("expected a single fetch of 3 users, got %v", fetches["User"])
+		}
+		if len(fetches["Post"]) != 1 || len(fetches["Post"][0]) != 2 {
+			t.Errorf("expected a single fetch of 2 posts, got %v", fetches["Post"])
+		}
+	})
+
+	t.Run("invalid IDs", func(t *testing.T) {
+		reg := newNodeRegistry(make(map[string][][]graphql.ID))
+		alice, comment := relay.MarshalID("User", 1), relay.MarshalID("Comment", 1)
+		ids := []graphql.ID{"abc", alice, comment}
+
+		nodes, idErrs, err := reg.Nodes(context.Background(), ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nodes[0] != nil || nodes[1] == nil || nodes[1].ID() != alice || nodes[2] != nil {
+			t.Fatalf("expected only the node of %q, got %v", alice, nodes)
+		}
+		wantErrs := []error{&relay.InvalidIDError{ID: "abc"}, nil, &relay.UnknownKindError{ID: comment, Kind: "Comment"}}
+		if !reflect.DeepEqual(idErrs, wantErrs) {
+			t.Fatalf("expected errors %v, got %v", wantErrs, idErrs)
+		}
+
+		// the resolver returns a partial list
+		vars := map[string]interface{}{"ids": []interface{}{string(ids[0]), string(ids[1]), string(ids[2])}}
+		resp := newNodeSchema(reg).Exec(context.Background(), query, "", vars)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		want := `{"nodes":[null,{"__typename":"User","id":"` + string(alice) + `","name":"Alice"},null]}`
+		if string(resp.Data) != want {
+			t.Fatalf("unexpected response\ngot:  %s\nwant: %s", resp.Data, want)
+		}
+	})
+
+	t.Run("node", func(t *testing.T) {
+		schema := newNodeSchema(newNodeRegistry(make(map[string][][]graphql.ID)))
+		id := relay.MarshalID("Post", 1)
+		resp := schema.Exec(context.Background(), `query($id: ID!) { node(id: $id) { id ... on Post { title } } }`, "", map[string]interface{}{"id": string(id)})
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		want := `{"node":{"id":"` + string(id) + `","title":"Hello"}}`
+		if string(resp.Data) != want {
+			t.Fatalf("unexpected response\ngot:  %s\nwant: %s", resp.Data, want)
+		}
+	})
+
+	for _, test := range []struct {
+		name string
+		id   graphql.ID
+		err  error
+	}{
+		{
+			name: "malformed ID",
+			id:   "abc",
+			err:  &relay.InvalidIDError{ID: "abc"},
+		},
+		{
+			name: "unknown kind",
+			id:   relay.MarshalID("Comment", 1),
+			err:  &relay.UnknownKindError{ID: relay.MarshalID("Comment", 1), Kind: "Comment"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			schema := newNodeSchema(newNodeRegistry(make(map[string][][]graphql.ID)))
+			resp := schema.Exec(context.Background(), `query($id: ID!) { node(id: $id) { id } }`, "", map[string]interface{}{"id": string(test.id)})
+			if len(resp.Errors) != 1 {
+				t.Fatalf("expected one error, got %v", resp.Errors)
+			}
+			err := resp.Errors[0]
+			if err.Message != test.err.Error() {
+				t.Errorf("expected error %q, got %q", test.err, err.Message)
+			}
+			switch want := test.err.(type) {
+			case *relay.InvalidIDError:
+				var got *relay.InvalidIDError
+				if !errors.As(err.ResolverError, &got) || *got != *want {
+					t.Errorf("expected %#v, got %#v", want, err.ResolverError)
+				}
+			case *relay.UnknownKindError:
+				var got *relay.UnknownKindError
+				if !errors.As(err.ResolverError, &got) || *got != *want {
+					t.Errorf("expected %#v, got %#v", want, err.ResolverError)
+				}
+			}
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	kind, err := relay.KindOf(relay.MarshalID("User", 1))
+	if err != nil || kind != "User" {
+		t.Errorf("expected kind User, got %q and %v", kind, err)
+	}
+	if _, err := relay.KindOf("abc"); err == nil {
+		t.Error("expected an error for a malformed ID")
+	}
+}
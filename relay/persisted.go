@@ -0,0 +1,134 @@
+package relay
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// DefaultPersistedQueryCacheSize is the number of queries kept by the default persisted query store
+// of the Handler.
+const DefaultPersistedQueryCacheSize = 1000
+
+// PersistedQueryStore stores the queries of the automatic persisted queries protocol by the hex
+// encoded SHA-256 hash of the query. Implementations must be safe for concurrent use.
+type PersistedQueryStore interface {
+	// Get returns the query with the given hash, or false if it is unknown.
+	Get(ctx context.Context, hash string) (string, bool)
+	// Set registers the query with the given hash.
+	Set(ctx context.Context, hash string, query string)
+}
+
+// LRUPersistedQueryStore is an in-memory PersistedQueryStore which evicts the least recently used
+// query once it is full.
+type LRUPersistedQueryStore struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type persistedQuery struct {
+	hash  string
+	query string
+}
+
+// NewLRUPersistedQueryStore returns an in-memory store holding up to size queries.
+func NewLRUPersistedQueryStore(size int) *LRUPersistedQueryStore {
+	return &LRUPersistedQueryStore{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get implements PersistedQueryStore.
+func (s *LRUPersistedQueryStore) Get(_ context.Context, hash string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[hash]
+	if !ok {
+		return "", false
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*persistedQuery).query, true
+}
+
+// Set implements PersistedQueryStore.
+func (s *LRUPersistedQueryStore) Set(_ context.Context, hash string, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[hash]; ok {
+		s.order.MoveToFront(e)
+		return
+	}
+	s.entries[hash] = s.order.PushFront(&persistedQuery{hash: hash, query: query})
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*persistedQuery).hash)
+	}
+}
+
+type persistedQueryExtension struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// errPersistedQueryNotFound asks the client to send the full query along with its hash.
+func errPersistedQueryNotFound() *qerrors.QueryError {
+	return &qerrors.QueryError{
+		Message:    "PersistedQueryNotFound",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"},
+	}
+}
+
+func errPersistedQueryNotSupported() *qerrors.QueryError {
+	return &qerrors.QueryError{
+		Message:    "PersistedQueryNotSupported",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_SUPPORTED"},
+	}
+}
+
+// resolvePersistedQuery returns the query of a request using automatic persisted queries. A query
+// sent along with its hash is registered in the store.
+func (h *Handler) resolvePersistedQuery(ctx context.Context, ext *persistedQueryExtension, query string) (string, *qerrors.QueryError) {
+	if ext.Version != 1 {
+		return "", errPersistedQueryNotSupported()
+	}
+
+	store := h.persistedQueryStore()
+	if query == "" {
+		q, ok := store.Get(ctx, ext.Sha256Hash)
+		if !ok {
+			return "", errPersistedQueryNotFound()
+		}
+		return q, nil
+	}
+
+	sum := sha256.Sum256([]byte(query))
+	if hex.EncodeToString(sum[:]) != ext.Sha256Hash {
+		return "", &qerrors.QueryError{
+			Message:    "provided sha does not match query",
+			Extensions: map[string]interface{}{"code": "BAD_USER_INPUT"},
+		}
+	}
+	store.Set(ctx, ext.Sha256Hash, query)
+	return query, nil
+}
+
+func (h *Handler) persistedQueryStore() PersistedQueryStore {
+	if h.PersistedQueries != nil {
+		return h.PersistedQueries
+	}
+	h.defaultStoreOnce.Do(func() {
+		h.defaultStore = NewLRUPersistedQueryStore(DefaultPersistedQueryCacheSize)
+	})
+	return h.defaultStore
+}
@@ -1,16 +1,39 @@
 package relay
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/types"
 )
 
+// DefaultMaxBatchSize is the default limit of the number of operations of a batched request.
+const DefaultMaxBatchSize = 10
+
+// DefaultMaxBodySize is the default limit of the size of a request body without file uploads.
+const DefaultMaxBodySize = 1 << 20
+
+// graphqlResponseJSON is the media type of GraphQL responses, which unlike application/json
+// reports the requests failing before the execution with a 4xx status.
+const graphqlResponseJSON = "application/graphql-response+json"
+
+// responseMediaTypes are the media types of the responses of the Handler, in the order of preference
+// for media types the client accepts equally.
+var responseMediaTypes = []string{"text/event-stream", "multipart/mixed", graphqlResponseJSON, "application/json"}
+
 func MarshalID(kind string, spec interface{}) graphql.ID {
 	d, err := json.Marshal(spec)
 	if err != nil {
@@ -43,22 +66,317 @@ func UnmarshalSpec(id graphql.ID, v interface{}) error {
 	return json.Unmarshal(s[i+1:], v)
 }
 
+// Handler serves GraphQL over HTTP, see https://graphql.github.io/graphql-over-http/draft/. It
+// accepts GET requests with the parameters in the URL and POST requests with a JSON,
+// application/graphql or multipart/form-data body. Mutations are only executed for POST requests,
+// multipart requests must have an Apollo-Require-Preflight or X-Apollo-Operation-Name header.
+// A POST request with an array of operations is a batch, the response is the array of their
+// responses.
+//
+// The response is written in application/graphql-response+json if the client accepts it, with the
+// status 400 if the request fails before the execution, e.g. because the query is invalid. Otherwise
+// it is written in application/json with the status 200.
 type Handler struct {
 	Schema *graphql.Schema
+
+	// MaxBodySize limits the size of the request body without file uploads. It defaults to
+	// DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// MaxBatchSize limits the number of operations of a batched request. It defaults to
+	// DefaultMaxBatchSize.
+	MaxBatchSize int
+
+	// BatchParallelism is the number of operations of a batched request which are executed
+	// concurrently. The operations are executed one after another by default.
+	BatchParallelism int
+
+	// PersistedQueries stores the queries of the automatic persisted queries protocol. It defaults
+	// to an in-memory LRU store of DefaultPersistedQueryCacheSize queries.
+	PersistedQueries PersistedQueryStore
+
+	// MaxUploadSize limits the size of a multipart request with file uploads. It defaults to
+	// DefaultMaxUploadSize.
+	MaxUploadSize int64
+
+	// MaxUploadFileSize limits the size of each uploaded file. Zero only limits the size of the
+	// request.
+	MaxUploadFileSize int64
+
+	// UploadMemory is the size of the uploaded files kept in memory, the others are spooled to
+	// temporary files which are removed after the request. It defaults to DefaultUploadMemory.
+	UploadMemory int64
+
+	defaultStoreOnce sync.Once
+	defaultStore     PersistedQueryStore
+}
+
+// operations are the parameters of the operations of a request, either a single operation or a
+// batch sent as an array.
+type operations struct {
+	list    []requestParams
+	batched bool
+}
+
+func (ops *operations) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) != 0 && trimmed[0] == '[' {
+		ops.batched = true
+		if err := json.Unmarshal(data, &ops.list); err != nil {
+			return err
+		}
+		if len(ops.list) == 0 {
+			return errors.New("the batch has no operations")
+		}
+		return nil
+	}
+	ops.list = make([]requestParams, 1)
+	return json.Unmarshal(data, &ops.list[0])
+}
+
+// requestParams are the parameters of a GraphQL request.
+type requestParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+	} `json:"extensions"`
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var params struct {
-		Query         string                 `json:"query"`
-		OperationName string                 `json:"operationName"`
-		Variables     map[string]interface{} `json:"variables"`
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	responseType := negotiate(r, responseMediaTypes)
+	if responseType == "" {
+		http.Error(w, "the accepted media types are not supported, accept application/graphql-response+json or application/json", http.StatusNotAcceptable)
+		return
+	}
+
+	var ops operations
+	cleanup, ok := h.readParams(w, r, &ops)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	if ops.batched {
+		h.serveBatch(w, r, ops.list)
+		return
+	}
+	params := &ops.list[0]
+
+	if ext := params.Extensions.PersistedQuery; ext != nil {
+		query, err := h.resolvePersistedQuery(r.Context(), ext, params.Query)
+		if err != nil {
+			writeRequestError(w, responseType, &graphql.Response{Errors: []*qerrors.QueryError{err}})
+			return
+		}
+		params.Query = query
+	}
+
+	switch responseType {
+	case "text/event-stream":
+		h.serveEventStream(w, r, params.Query, params.OperationName, params.Variables)
+
+	case "multipart/mixed":
+		if r.Method == http.MethodGet && operationType(params.Query, params.OperationName) == query.Mutation {
+			rejectGETMutation(w)
+			return
+		}
+		response, subsequent := h.Schema.ExecIncremental(r.Context(), params.Query, params.OperationName, params.Variables)
+		if subsequent != nil {
+			writeMultipart(w, response, subsequent)
+			return
+		}
+		writeJSON(w, response)
+
+	default:
+		h.serveJSON(w, r, responseType, params)
+	}
+}
+
+// errGETMutation aborts the execution of a mutation requested with GET.
+var errGETMutation = errors.New("mutations can only be executed with POST requests")
+
+// serveJSON writes the response in application/graphql-response+json or application/json. A
+// graphql-response+json response has the status 400 if the request fails before the execution, e.g.
+// because it is invalid.
+func (h *Handler) serveJSON(w http.ResponseWriter, r *http.Request, responseType string, params *requestParams) {
+	err := h.Schema.ExecToFunc(r.Context(), w, params.Query, params.OperationName, params.Variables, func(info graphql.ResponseInfo) error {
+		if r.Method == http.MethodGet && info.OperationType == query.Mutation {
+			return errGETMutation
+		}
+		w.Header().Set("Content-Type", responseType)
+		if responseType == graphqlResponseJSON && info.RequestError {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		return nil
+	})
+	if err == errGETMutation {
+		rejectGETMutation(w)
+		return
+	}
+	h.execTo(err)
+}
+
+// rejectGETMutation replies to a mutation requested with GET.
+func rejectGETMutation(w http.ResponseWriter) {
+	w.Header().Set("Allow", "POST")
+	http.Error(w, errGETMutation.Error(), http.StatusMethodNotAllowed)
+}
+
+// execTo handles the error of writing a response with ExecTo. If the response can not be written,
+// the connection is most likely gone, and the handler is aborted so that the response is not
+// mistaken for a complete one.
+func (h *Handler) execTo(err error) {
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// readParams reads the parameters of a request from the URL of a GET request or the body of a POST
+// request. It replies with an error and reports false if the request is invalid. Otherwise the
+// returned function must be called after the request.
+func (h *Handler) readParams(w http.ResponseWriter, r *http.Request, ops *operations) (func(), bool) {
+	if r.Method == http.MethodGet {
+		ops.list = make([]requestParams, 1)
+		params := &ops.list[0]
+		q := r.URL.Query()
+		params.Query = q.Get("query")
+		params.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &params.Variables); err != nil {
+				http.Error(w, fmt.Sprintf("invalid variables: %s", err), http.StatusBadRequest)
+				return nil, false
+			}
+		}
+		if v := q.Get("extensions"); v != "" {
+			if err := json.Unmarshal([]byte(v), &params.Extensions); err != nil {
+				http.Error(w, fmt.Sprintf("invalid extensions: %s", err), http.StatusBadRequest)
+				return nil, false
+			}
+		}
+		return func() {}, true
+	}
+
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid content type: %s", err), http.StatusBadRequest)
+			return nil, false
+		}
+		mediaType = mt
+	}
+	if mediaType == "multipart/form-data" {
+		return h.parseUploads(w, r, ops)
 	}
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+	if mediaType != "application/json" && mediaType != "application/graphql" {
+		http.Error(w, fmt.Sprintf("content type %s is not supported, send application/json", mediaType), http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	maxSize := h.MaxBodySize
+	if maxSize == 0 {
+		maxSize = DefaultMaxBodySize
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			http.Error(w, fmt.Sprintf("the request is larger than %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if mediaType == "application/graphql" {
+		ops.list = []requestParams{{
+			Query:         string(body),
+			OperationName: r.URL.Query().Get("operationName"),
+		}}
+		return func() {}, true
+	}
+	if err := json.Unmarshal(body, ops); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return func() {}, true
+}
+
+// serveBatch executes the operations of a batched request and writes their responses in order. An
+// operation failing does not affect the others.
+func (h *Handler) serveBatch(w http.ResponseWriter, r *http.Request, batch []requestParams) {
+	maxSize := h.MaxBatchSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxBatchSize
+	}
+	if len(batch) > maxSize {
+		http.Error(w, fmt.Sprintf("the batch has %d operations, the maximum is %d", len(batch), maxSize), http.StatusBadRequest)
+		return
+	}
+	parallelism := h.BatchParallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	responses := make([]*graphql.Response, len(batch))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			responses[i] = h.execBatched(r, &batch[i])
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := json.Marshal(responses)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", negotiate(r, []string{graphqlResponseJSON, "application/json"}))
+	w.Write(data)
+}
+
+// execBatched executes an operation of a batched request.
+func (h *Handler) execBatched(r *http.Request, params *requestParams) *graphql.Response {
+	if ext := params.Extensions.PersistedQuery; ext != nil {
+		query, err := h.resolvePersistedQuery(r.Context(), ext, params.Query)
+		if err != nil {
+			return &graphql.Response{Errors: []*qerrors.QueryError{err}}
+		}
+		params.Query = query
+	}
+	return h.Schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+}
 
-	response := h.Schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+// writeRequestError writes the response of a request which failed before the execution in the
+// negotiated media type. It is written in application/graphql-response+json with the status 400, or
+// in application/json with the status 200 for the other media types.
+func writeRequestError(w http.ResponseWriter, responseType string, response *graphql.Response) {
+	if responseType != graphqlResponseJSON {
+		writeJSON(w, response)
+		return
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", graphqlResponseJSON)
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, response *graphql.Response) {
 	responseJSON, err := json.Marshal(response)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -68,3 +386,99 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(responseJSON)
 }
+
+// operationType returns the type of the operation of a query, or "" if the query is invalid or
+// the operation does not exist.
+func operationType(queryString string, operationName string) types.OperationType {
+	doc, err := query.Parse(queryString)
+	if err != nil {
+		return ""
+	}
+	for _, op := range doc.Operations {
+		if op.Name.Name == operationName || operationName == "" && len(doc.Operations) == 1 {
+			return op.Type
+		}
+	}
+	return ""
+}
+
+// negotiate returns the media type of the offers the client prefers according to the quality values
+// of its Accept header, or "" if it accepts none of them. Offers accepted equally are chosen in the
+// given order. Only application/json is matched by the ranges application/* and */*, the other media
+// types have to be accepted explicitly. Requests without an Accept header accept application/json.
+func negotiate(r *http.Request, offers []string) string {
+	if len(r.Header["Accept"]) == 0 {
+		return "application/json"
+	}
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(r, offer); q > bestQuality {
+			best, bestQuality = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality value of the most specific media range of the Accept header
+// matching the media type, or 0 if none matches.
+func acceptQuality(r *http.Request, mediaType string) float64 {
+	quality, specificity := 0.0, -1
+	for _, accept := range r.Header["Accept"] {
+		for _, accepted := range strings.Split(accept, ",") {
+			mt, params, err := mime.ParseMediaType(accepted)
+			if err != nil {
+				continue
+			}
+			var s int
+			switch {
+			case mt == mediaType:
+				s = 2
+			case mediaType != "application/json":
+				continue
+			case mt == "application/*":
+				s = 1
+			case mt == "*/*":
+				s = 0
+			default:
+				continue
+			}
+			if s <= specificity {
+				continue
+			}
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+					continue
+				}
+			}
+			quality, specificity = q, s
+		}
+	}
+	return quality
+}
+
+// writeMultipart writes the initial response and every subsequent response as a part of a
+// multipart/mixed response, flushing after each part.
+func writeMultipart(w http.ResponseWriter, initial *graphql.Response, subsequent <-chan *graphql.SubsequentResponse) {
+	w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	writePart := func(v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			data, _ = json.Marshal(&graphql.Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
+		}
+		io.WriteString(w, "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n")
+		w.Write(data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	writePart(initial)
+	for resp := range subsequent {
+		writePart(resp)
+	}
+	io.WriteString(w, "\r\n-----\r\n")
+}
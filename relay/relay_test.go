@@ -1,13 +1,22 @@
 package relay_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/example/starwars"
 	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/graph-gophers/graphql-go/trace/noop"
 )
 
 var starwarsSchema = graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
@@ -34,3 +43,469 @@ func TestServeHTTP(t *testing.T) {
 		t.Fatalf("Invalid response. Expected [%s], but instead got [%s]", expectedResponse, actualResponse)
 	}
 }
+
+// failingResponseWriter fails to write the body of a response.
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingResponseWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestServeHTTP_writeError(t *testing.T) {
+	w := failingResponseWriter{httptest.NewRecorder()}
+	r := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ hero { name } }"}`))
+	h := relay.Handler{Schema: starwarsSchema}
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Fatalf("expected the handler to be aborted, got %v", v)
+		}
+	}()
+	h.ServeHTTP(w, r)
+}
+
+type incrementalResolver struct{}
+
+func (r *incrementalResolver) Hello() string { return "Hello" }
+
+func (r *incrementalResolver) World() string { return "world!" }
+
+func TestServeHTTP_multipart(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			hello: String!
+			world: String!
+		}
+	`, &incrementalResolver{}, graphql.IncrementalDelivery())
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/some/path/here", strings.NewReader(`{"query":"{ hello ... @defer { world } }"}`))
+	r.Header.Set("Accept", "multipart/mixed, application/json")
+	h := relay.Handler{Schema: schema}
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status code 200, got %d.", w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if contentType != `multipart/mixed; boundary="-"` {
+		t.Fatalf("Invalid content-type. Expected [multipart/mixed; boundary=\"-\"], but instead got [%s]", contentType)
+	}
+
+	expectedResponse := "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n" +
+		`{"data":{"hello":"Hello"},"hasNext":true}` +
+		"\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n" +
+		`{"incremental":[{"data":{"world":"world!"},"path":[]}],"hasNext":false}` +
+		"\r\n-----\r\n"
+	actualResponse := w.Body.String()
+	if expectedResponse != actualResponse {
+		t.Fatalf("Invalid response. Expected [%q], but instead got [%q]", expectedResponse, actualResponse)
+	}
+}
+
+func TestServeHTTP_persistedQuery(t *testing.T) {
+	h := relay.Handler{Schema: starwarsSchema}
+	query := "{ hero { name } }"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	serve := func(body string) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/some/path/here", strings.NewReader(body))
+		h.ServeHTTP(w, r)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d.", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "unknown hash",
+			body:     `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			expected: `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`,
+		},
+		{
+			name:     "mismatching hash",
+			body:     `{"query":"{ hero { id } }","extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			expected: `{"errors":[{"message":"provided sha does not match query","extensions":{"code":"BAD_USER_INPUT"}}]}`,
+		},
+		{
+			name:     "registration",
+			body:     `{"query":"{ hero { name } }","extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			expected: `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "known hash",
+			body:     `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			expected: `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "unsupported version",
+			body:     `{"extensions":{"persistedQuery":{"version":2,"sha256Hash":"` + hash + `"}}}`,
+			expected: `{"errors":[{"message":"PersistedQueryNotSupported","extensions":{"code":"PERSISTED_QUERY_NOT_SUPPORTED"}}]}`,
+		},
+	}
+	for _, tc := range tests {
+		if actual := serve(tc.body); actual != tc.expected {
+			t.Fatalf("%s: Invalid response. Expected [%s], but instead got [%s]", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestServeHTTP_persistedQueryGraphQLResponse(t *testing.T) {
+	h := relay.Handler{Schema: starwarsSchema}
+	sum := sha256.Sum256([]byte("{ hero { name } }"))
+	hash := hex.EncodeToString(sum[:])
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/some/path/here", strings.NewReader(`{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"`+hash+`"}}}`))
+	r.Header.Set("Accept", "application/graphql-response+json")
+	h.ServeHTTP(w, r)
+
+	if w.Code != 400 {
+		t.Fatalf("Expected status code 400, got %d.", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/graphql-response+json" {
+		t.Fatalf("Expected content type application/graphql-response+json, got %s.", contentType)
+	}
+	if expected := `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`; w.Body.String() != expected {
+		t.Fatalf("Invalid response. Expected [%s], but instead got [%s]", expected, w.Body)
+	}
+}
+
+func TestLRUPersistedQueryStore(t *testing.T) {
+	ctx := context.Background()
+	s := relay.NewLRUPersistedQueryStore(2)
+	s.Set(ctx, "a", "{ a }")
+	s.Set(ctx, "b", "{ b }")
+	if _, ok := s.Get(ctx, "a"); !ok {
+		t.Fatal("Expected query a to be stored.")
+	}
+	s.Set(ctx, "c", "{ c }")
+
+	if _, ok := s.Get(ctx, "b"); ok {
+		t.Fatal("Expected the least recently used query b to be evicted.")
+	}
+	for _, hash := range []string{"a", "c"} {
+		if _, ok := s.Get(ctx, hash); !ok {
+			t.Fatalf("Expected query %s to be stored.", hash)
+		}
+	}
+}
+
+// validationCounter counts the validations of a schema.
+type validationCounter struct {
+	noop.Tracer
+	n int32
+}
+
+func (t *validationCounter) TraceValidation(context.Context) func([]*qerrors.QueryError) {
+	atomic.AddInt32(&t.n, 1)
+	return func([]*qerrors.QueryError) {}
+}
+
+func TestServeHTTP_validatedOnce(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{name: "valid", query: `{ hero { name } }`, status: 200},
+		{name: "invalid", query: `{ hero { nope } }`, status: 400},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			counter := &validationCounter{}
+			schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.Tracer(counter), graphql.QueryCache(10))
+			h := &relay.Handler{Schema: schema}
+			for i := 0; i < 2; i++ {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"`+test.query+`"}`))
+				r.Header.Set("Accept", "application/graphql-response+json")
+				h.ServeHTTP(w, r)
+				if w.Code != test.status {
+					t.Fatalf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+				}
+			}
+			if n := atomic.LoadInt32(&counter.n); n != 2 {
+				t.Fatalf("expected one validation per request, got %d for 2 requests", n)
+			}
+		})
+	}
+}
+
+func TestServeHTTP_graphqlOverHTTP(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		accept      string
+		body        string
+		handler     *relay.Handler
+		status      int
+		allow       string
+		respType    string
+		want        string
+	}{
+		{
+			name:     "GET query",
+			method:   "GET",
+			target:   "/graphql?query=" + url.QueryEscape(`query($id: ID!) { character(id: $id) { name } }`) + "&variables=" + url.QueryEscape(`{"id":"1000"}`),
+			status:   200,
+			respType: "application/json",
+			want:     `{"data":{"character":{"name":"Luke Skywalker"}}}`,
+		},
+		{
+			name:   "GET mutation",
+			method: "GET",
+			target: "/graphql?query=" + url.QueryEscape(`mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`),
+			status: 405,
+			allow:  "POST",
+			want:   "mutations can only be executed with POST requests\n",
+		},
+		{
+			name:   "GET mutation accepting graphql-response+json",
+			method: "GET",
+			target: "/graphql?query=" + url.QueryEscape(`mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`),
+			accept: "application/graphql-response+json",
+			status: 405,
+			allow:  "POST",
+			want:   "mutations can only be executed with POST requests\n",
+		},
+		{
+			name:   "GET mutation accepting text/event-stream",
+			method: "GET",
+			target: "/graphql?query=" + url.QueryEscape(`mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`),
+			accept: "text/event-stream",
+			status: 405,
+			allow:  "POST",
+			want:   "mutations can only be executed with POST requests\n",
+		},
+		{
+			name:   "GET invalid variables",
+			method: "GET",
+			target: "/graphql?query=" + url.QueryEscape(`{ hero { name } }`) + "&variables=nope",
+			status: 400,
+			want:   "invalid variables: invalid character 'o' in literal null (expecting 'u')\n",
+		},
+		{
+			name:   "unsupported method",
+			method: "PUT",
+			body:   `{"query":"{ hero { name } }"}`,
+			status: 405,
+			allow:  "GET, POST",
+			want:   "method PUT is not allowed\n",
+		},
+		{
+			name:        "application/graphql body",
+			method:      "POST",
+			contentType: "application/graphql",
+			body:        `{ hero { name } }`,
+			status:      200,
+			respType:    "application/json",
+			want:        `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:        "unsupported content type",
+			method:      "POST",
+			contentType: "text/plain",
+			body:        `{ hero { name } }`,
+			status:      415,
+			want:        "content type text/plain is not supported, send application/json\n",
+		},
+		{
+			name:   "unsupported accept",
+			method: "POST",
+			accept: "text/html",
+			body:   `{"query":"{ hero { name } }"}`,
+			status: 406,
+			want:   "the accepted media types are not supported, accept application/graphql-response+json or application/json\n",
+		},
+		{
+			name:    "body too large",
+			method:  "POST",
+			body:    `{"query":"{ hero { name } }"}`,
+			handler: &relay.Handler{Schema: starwarsSchema, MaxBodySize: 10},
+			status:  413,
+			want:    "the request is larger than 10 bytes\n",
+		},
+		{
+			name:     "graphql-response+json",
+			method:   "POST",
+			accept:   "application/graphql-response+json, application/json",
+			body:     `{"query":"{ hero { name } }"}`,
+			status:   200,
+			respType: "application/graphql-response+json",
+			want:     `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "graphql-response+json with a validation error",
+			method:   "POST",
+			accept:   "application/graphql-response+json",
+			body:     `{"query":"{ unknown }"}`,
+			status:   400,
+			respType: "application/graphql-response+json",
+			want:     `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name:     "graphql-response+json with a syntax error",
+			method:   "GET",
+			target:   "/graphql?query=" + url.QueryEscape(`{ hero `),
+			accept:   "application/graphql-response+json",
+			status:   400,
+			respType: "application/graphql-response+json",
+			want:     `{"errors":[{"message":"syntax error: unexpected \"\", expecting Ident","locations":[{"line":1,"column":8}]}]}`,
+		},
+		{
+			name:     "graphql-response+json with invalid variables",
+			method:   "POST",
+			accept:   "application/graphql-response+json",
+			body:     `{"query":"query($id: ID!) { character(id: $id) { name } }"}`,
+			status:   400,
+			respType: "application/graphql-response+json",
+			want:     `{"errors":[{"message":"Variable \"id\" has invalid value null.\nExpected type \"ID!\", found null.","locations":[{"line":1,"column":7}]}]}`,
+		},
+		{
+			name:     "quality values",
+			method:   "POST",
+			accept:   "application/json, text/event-stream;q=0.1",
+			body:     `{"query":"{ hero { name } }"}`,
+			status:   200,
+			respType: "application/json",
+			want:     `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "quality values of graphql-response+json",
+			method:   "POST",
+			accept:   "application/graphql-response+json;q=0.5, application/json;q=0.9",
+			body:     `{"query":"{ hero { name } }"}`,
+			status:   200,
+			respType: "application/json",
+			want:     `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "not acceptable media type",
+			method:   "POST",
+			accept:   "application/graphql-response+json;q=0, */*",
+			body:     `{"query":"{ hero { name } }"}`,
+			status:   200,
+			respType: "application/json",
+			want:     `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "media range",
+			method:   "POST",
+			accept:   "text/html, application/*;q=0.8",
+			body:     `{"query":"{ hero { name } }"}`,
+			status:   200,
+			respType: "application/json",
+			want:     `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:     "application/json with a validation error",
+			method:   "POST",
+			accept:   "application/json",
+			body:     `{"query":"{ unknown }"}`,
+			status:   200,
+			respType: "application/json",
+			want:     `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			target := test.target
+			if target == "" {
+				target = "/graphql"
+			}
+			r := httptest.NewRequest(test.method, target, strings.NewReader(test.body))
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			h := test.handler
+			if h == nil {
+				h = &relay.Handler{Schema: starwarsSchema}
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			if got := w.Header().Get("Allow"); got != test.allow {
+				t.Errorf("got Allow %q, want %q", got, test.allow)
+			}
+			if test.respType != "" {
+				if got := w.Header().Get("Content-Type"); got != test.respType {
+					t.Errorf("got content type %q, want %q", got, test.respType)
+				}
+			}
+			if got := w.Body.String(); got != test.want {
+				t.Errorf("unexpected response\ngot:  %q\nwant: %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTP_batch(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		body    string
+		handler *relay.Handler
+		status  int
+		want    string
+	}{
+		{
+			name:   "operations in order",
+			body:   `[{"query":"{ hero { name } }"}, {"query":"{ unknown }"}, {"query":"query($id: ID!) { character(id: $id) { name } }","variables":{"id":"1000"}}]`,
+			status: 200,
+			want:   `[{"data":{"hero":{"name":"R2-D2"}}},{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]},{"data":{"character":{"name":"Luke Skywalker"}}}]`,
+		},
+		{
+			name:    "parallel operations",
+			body:    `[{"query":"{ a: hero { name } }"}, {"query":"{ b: hero(episode: EMPIRE) { name } }"}, {"query":"{ c: hero { id } }"}]`,
+			handler: &relay.Handler{Schema: starwarsSchema, BatchParallelism: 3},
+			status:  200,
+			want:    `[{"data":{"a":{"name":"R2-D2"}}},{"data":{"b":{"name":"Luke Skywalker"}}},{"data":{"c":{"id":"2001"}}}]`,
+		},
+		{
+			name:    "too many operations",
+			body:    `[{"query":"{ hero { name } }"}, {"query":"{ hero { name } }"}]`,
+			handler: &relay.Handler{Schema: starwarsSchema, MaxBatchSize: 1},
+			status:  400,
+			want:    "the batch has 2 operations, the maximum is 1\n",
+		},
+		{
+			name:   "empty batch",
+			body:   `[]`,
+			status: 400,
+			want:   "the batch has no operations\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := test.handler
+			if h == nil {
+				h = &relay.Handler{Schema: starwarsSchema}
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(test.body)))
+
+			if w.Code != test.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			if got := w.Body.String(); got != test.want {
+				t.Errorf("unexpected response\ngot:  %s\nwant: %s", got, test.want)
+			}
+		})
+	}
+}
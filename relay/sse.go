@@ -0,0 +1,91 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/query"
+)
+
+type lastEventIDKey struct{}
+
+// LastEventID returns the Last-Event-ID header of an event stream request, i.e. the ID of the last
+// event received by a reconnecting client, or "" for a new stream. The events are numbered
+// sequentially, so subscriptions of sources which can resume skip the events up to this ID.
+func LastEventID(ctx context.Context) string {
+	id, _ := ctx.Value(lastEventIDKey{}).(string)
+	return id
+}
+
+// serveEventStream serves the operation as Server-Sent Events in the distinct connections mode of
+// the GraphQL over SSE protocol, see https://github.com/enisdenjo/graphql-sse/blob/master/PROTOCOL.md.
+// Every response is written as a next event, followed by a complete event once the operation is
+// done. The subscription is cancelled when the client disconnects.
+func (h *Handler) serveEventStream(w http.ResponseWriter, r *http.Request, queryString string, operationName string, variables map[string]interface{}) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	opType := operationType(queryString, operationName)
+	if r.Method == http.MethodGet && opType == query.Mutation {
+		rejectGETMutation(w)
+		return
+	}
+
+	// invalid operations are rejected before the stream is opened
+	if errs := h.Schema.ValidateWithVariables(queryString, variables); len(errs) != 0 {
+		writeJSON(w, &graphql.Response{Errors: errs})
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	ctx := context.WithValue(r.Context(), lastEventIDKey{}, lastEventID)
+
+	var responses <-chan interface{}
+	if opType == query.Subscription {
+		c, err := h.Schema.Subscribe(ctx, queryString, operationName, variables)
+		if err != nil {
+			writeJSON(w, &graphql.Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
+			return
+		}
+		responses = c
+	} else {
+		c := make(chan interface{}, 1)
+		c <- h.Schema.Exec(ctx, queryString, operationName, variables)
+		close(c)
+		responses = c
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disables the buffering of nginx
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// the IDs continue after the last event received by a reconnecting client
+	id, _ := strconv.Atoi(lastEventID)
+	for resp := range responses {
+		if ctx.Err() != nil {
+			continue // drain the responses of the cancelled subscription
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			data, _ = json.Marshal(&graphql.Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
+		}
+		id++
+		fmt.Fprintf(w, "event: next\nid: %d\ndata: %s\n\n", id, data)
+		flusher.Flush()
+	}
+	if ctx.Err() == nil {
+		io.WriteString(w, "event: complete\ndata:\n\n")
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,126 @@
+package relay_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+type sseResolver struct{}
+
+func (r *sseResolver) Hello() string {
+	return "Hello!"
+}
+
+// Count counts from 1, or resumes after the last event received by an event stream client.
+func (r *sseResolver) Count(ctx context.Context, args struct{ To int32 }) <-chan int32 {
+	last, _ := strconv.Atoi(relay.LastEventID(ctx))
+	c := make(chan int32)
+	go func() {
+		defer close(c)
+		for i := int32(last) + 1; i <= args.To; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+var sseSchema = graphql.MustParseSchema(`
+	type Query {
+		hello: String!
+	}
+
+	type Subscription {
+		count(to: Int!): Int!
+	}
+`, &sseResolver{})
+
+func TestServeHTTP_eventStream(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		body        string
+		lastEventID string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "subscription",
+			body:        `{"query":"subscription { count(to: 2) }"}`,
+			contentType: "text/event-stream",
+			want: "event: next\nid: 1\ndata: {\"data\":{\"count\":1}}\n\n" +
+				"event: next\nid: 2\ndata: {\"data\":{\"count\":2}}\n\n" +
+				"event: complete\ndata:\n\n",
+		},
+		{
+			name:        "resumed subscription",
+			body:        `{"query":"subscription { count(to: 3) }"}`,
+			lastEventID: "2",
+			contentType: "text/event-stream",
+			want: "event: next\nid: 3\ndata: {\"data\":{\"count\":3}}\n\n" +
+				"event: complete\ndata:\n\n",
+		},
+		{
+			name:        "query",
+			body:        `{"query":"{ hello }"}`,
+			contentType: "text/event-stream",
+			want: "event: next\nid: 1\ndata: {\"data\":{\"hello\":\"Hello!\"}}\n\n" +
+				"event: complete\ndata:\n\n",
+		},
+		{
+			name:        "invalid operation",
+			body:        `{"query":"subscription { unknown }"}`,
+			contentType: "application/json",
+			want:        `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Subscription\".","locations":[{"line":1,"column":16}]}]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/graphql", strings.NewReader(test.body))
+			r.Header.Set("Accept", "text/event-stream")
+			if test.lastEventID != "" {
+				r.Header.Set("Last-Event-ID", test.lastEventID)
+			}
+
+			h := relay.Handler{Schema: sseSchema}
+			h.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Type"); got != test.contentType {
+				t.Fatalf("got content type %q, want %q", got, test.contentType)
+			}
+			if got := w.Body.String(); got != test.want {
+				t.Fatalf("unexpected response\ngot:  %q\nwant: %q", got, test.want)
+			}
+		})
+	}
+
+	t.Run("cancelled by the client", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"subscription { count(to: 1000000000) }"}`)).WithContext(ctx)
+		r.Header.Set("Accept", "text/event-stream")
+
+		done := make(chan struct{})
+		go func() {
+			h := relay.Handler{Schema: sseSchema}
+			h.ServeHTTP(w, r)
+			close(done)
+		}()
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the event stream was not closed")
+		}
+	})
+}
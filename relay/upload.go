@@ -0,0 +1,230 @@
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// DefaultMaxUploadSize is the default limit of the size of a multipart request with file uploads.
+const DefaultMaxUploadSize = 32 << 20
+
+// DefaultUploadMemory is the default size of the uploaded files kept in memory.
+const DefaultUploadMemory = 10 << 20
+
+// parseUploads reads a multipart request with file uploads, see
+// https://github.com/jaydenseric/graphql-multipart-request-spec. The "operations" part holds the
+// parameters of the request, the "map" part maps the file parts to the paths of the variables they
+// are set to as a graphql.Upload, e.g. {"0": ["variables.file"]}, or {"0": ["1.variables.file"]}
+// for the second operation of a batch. It replies with an error and reports false if the request
+// is invalid. Otherwise the returned function must be called to close and remove the files after
+// the request.
+//
+// Browsers send multipart/form-data requests of other sites without a CORS preflight request, so
+// the request must have a header which forces a preflight request, an Apollo-Require-Preflight
+// header or a non-empty X-Apollo-Operation-Name header, to prevent cross-site request forgery.
+func (h *Handler) parseUploads(w http.ResponseWriter, r *http.Request, ops *operations) (func(), bool) {
+	if _, ok := r.Header["Apollo-Require-Preflight"]; !ok && r.Header.Get("X-Apollo-Operation-Name") == "" {
+		http.Error(w, "multipart requests must have an Apollo-Require-Preflight or a non-empty X-Apollo-Operation-Name header", http.StatusBadRequest)
+		return nil, false
+	}
+
+	maxSize := h.MaxUploadSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxUploadSize
+	}
+	memory := h.UploadMemory
+	if memory == 0 {
+		memory = DefaultUploadMemory
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	values := make(map[string][]string)
+	uploads := make(map[string][]graphql.Upload)
+	var files []*os.File
+	cleanup := func() {
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}
+	fail := func(status int, format string, args ...interface{}) (func(), bool) {
+		cleanup()
+		http.Error(w, fmt.Sprintf(format, args...), status)
+		return nil, false
+	}
+	readErr := func(err error) (func(), bool) {
+		if strings.Contains(err.Error(), "request body too large") {
+			return fail(http.StatusRequestEntityTooLarge, "the request is larger than %d bytes", maxSize)
+		}
+		return fail(http.StatusBadRequest, "%s", err)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return readErr(err)
+		}
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(part)
+			if err != nil {
+				return readErr(err)
+			}
+			values[name] = append(values[name], string(value))
+			continue
+		}
+
+		file, size, tmp, err := readFilePart(part, h.MaxUploadFileSize, &memory)
+		if tmp != nil {
+			files = append(files, tmp)
+		}
+		if err == errFileTooLarge {
+			return fail(http.StatusRequestEntityTooLarge, "file %q is larger than %d bytes", part.FileName(), h.MaxUploadFileSize)
+		}
+		if err != nil {
+			return readErr(err)
+		}
+		uploads[name] = append(uploads[name], graphql.Upload{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        size,
+			File:        file,
+		})
+	}
+
+	if len(values["operations"]) != 1 {
+		return fail(http.StatusBadRequest, `the request must have one "operations" part`)
+	}
+	if err := json.Unmarshal([]byte(values["operations"][0]), ops); err != nil {
+		return fail(http.StatusBadRequest, `invalid "operations" part: %s`, err)
+	}
+	var fileMap map[string][]string
+	if len(values["map"]) != 1 {
+		return fail(http.StatusBadRequest, `the request must have one "map" part`)
+	}
+	if err := json.Unmarshal([]byte(values["map"][0]), &fileMap); err != nil {
+		return fail(http.StatusBadRequest, `invalid "map" part: %s`, err)
+	}
+
+	for key, paths := range fileMap {
+		if len(uploads[key]) != 1 {
+			return fail(http.StatusBadRequest, "the request must have one file part %q", key)
+		}
+		for _, path := range paths {
+			if err := setUpload(ops, path, uploads[key][0]); err != nil {
+				return fail(http.StatusBadRequest, "%s", err)
+			}
+		}
+	}
+	return cleanup, true
+}
+
+var errFileTooLarge = errors.New("file too large")
+
+// readFilePart reads an uploaded file while it fits into the remaining memory and spools it to a
+// temporary file otherwise, which is returned to be removed after the request. The file is read
+// through a limit of maxFileSize bytes, if set, and fails with errFileTooLarge as soon as it exceeds
+// the limit.
+func readFilePart(part io.Reader, maxFileSize int64, memory *int64) (io.Reader, int64, *os.File, error) {
+	if maxFileSize > 0 {
+		part = io.LimitReader(part, maxFileSize+1)
+	}
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, part, *memory+1)
+	if err != nil && err != io.EOF {
+		return nil, 0, nil, err
+	}
+	if maxFileSize > 0 && n > maxFileSize {
+		return nil, 0, nil, errFileTooLarge
+	}
+	if n <= *memory {
+		*memory -= n
+		return bytes.NewReader(buf.Bytes()), n, nil, nil
+	}
+
+	f, err := ioutil.TempFile("", "graphql-upload-")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	size, err := io.Copy(f, io.MultiReader(&buf, part))
+	if err != nil {
+		return nil, 0, f, err
+	}
+	if maxFileSize > 0 && size > maxFileSize {
+		return nil, 0, f, errFileTooLarge
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, f, err
+	}
+	return f, size, f, nil
+}
+
+// setUpload sets the value at an object path of the operations to the upload, e.g.
+// "variables.input.files.0", prefixed with the index of the operation in a batch.
+func setUpload(ops *operations, path string, upload graphql.Upload) error {
+	keys := strings.Split(path, ".")
+	params := &ops.list[0]
+	if ops.batched {
+		idx, err := strconv.Atoi(keys[0])
+		if err != nil || idx < 0 || idx >= len(ops.list) {
+			return fmt.Errorf("invalid upload path %q", path)
+		}
+		params = &ops.list[idx]
+		keys = keys[1:]
+	}
+	if len(keys) < 2 || keys[0] != "variables" || params.Variables == nil {
+		return fmt.Errorf("invalid upload path %q", path)
+	}
+
+	var parent interface{} = params.Variables
+	for i, key := range keys[1:] {
+		last := i == len(keys)-2
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			v, ok := p[key]
+			if !ok {
+				return fmt.Errorf("invalid upload path %q", path)
+			}
+			if last {
+				p[key] = upload
+				return nil
+			}
+			parent = v
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(p) {
+				return fmt.Errorf("invalid upload path %q", path)
+			}
+			if last {
+				p[idx] = upload
+				return nil
+			}
+			parent = p[idx]
+		default:
+			return fmt.Errorf("invalid upload path %q", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package relay_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+type uploadResolver struct{}
+
+func (r *uploadResolver) Hello() string { return "Hello" }
+
+func (r *uploadResolver) Upload(args struct{ File graphql.Upload }) (string, error) {
+	return describeUpload(args.File)
+}
+
+func (r *uploadResolver) UploadMany(args struct{ Files []graphql.Upload }) ([]string, error) {
+	var res []string
+	for _, f := range args.Files {
+		s, err := describeUpload(f)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (r *uploadResolver) UploadInput(args struct {
+	Input struct {
+		Name string
+		File *graphql.Upload
+	}
+}) (string, error) {
+	if args.Input.File == nil {
+		return args.Input.Name + ": no file", nil
+	}
+	s, err := describeUpload(*args.Input.File)
+	return args.Input.Name + ": " + s, err
+}
+
+func describeUpload(u graphql.Upload) (string, error) {
+	data, err := ioutil.ReadAll(u.File)
+	if err != nil {
+		return "", err
+	}
+	return u.Filename + " (" + u.ContentType + ", " + strconv.FormatInt(u.Size, 10) + " bytes): " + string(data), nil
+}
+
+var uploadSchema = graphql.MustParseSchema(`
+	scalar Upload
+
+	type Query {
+		hello: String!
+	}
+
+	type Mutation {
+		upload(file: Upload!): String!
+		uploadMany(files: [Upload!]!): [String!]!
+		uploadInput(input: UploadInput!): String!
+	}
+
+	input UploadInput {
+		name: String!
+		file: Upload
+	}
+`, &uploadResolver{})
+
+type filePart struct {
+	key, filename, content string
+}
+
+func newUploadRequest(t *testing.T, operations, fileMap string, files ...filePart) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveUpload(t, &relay.Handler{Schema: uploadSchema}, operations, fileMap, files...)
+}
+
+func serveUpload(t *testing.T, h *relay.Handler, operations, fileMap string, files ...filePart) *httptest.ResponseRecorder {
+	t.Helper()
+	r := newMultipartRequest(t, operations, fileMap, files...)
+	r.Header.Set("Apollo-Require-Preflight", "true")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func newMultipartRequest(t *testing.T, operations, fileMap string, files ...filePart) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("operations", operations)
+	mw.WriteField("map", fileMap)
+	for _, f := range files {
+		fw, err := mw.CreateFormFile(f.key, f.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(f.content))
+	}
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/graphql", &body).WithContext(context.Background())
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestServeHTTP_upload(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		operations string
+		fileMap    string
+		files      []filePart
+		want       string
+	}{
+		{
+			name:       "argument",
+			operations: `{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`,
+			fileMap:    `{"0":["variables.file"]}`,
+			files:      []filePart{{"0", "a.txt", "hello"}},
+			want:       `{"data":{"upload":"a.txt (application/octet-stream, 5 bytes): hello"}}`,
+		},
+		{
+			name:       "list",
+			operations: `{"query":"mutation($files: [Upload!]!) { uploadMany(files: $files) }","variables":{"files":[null,null]}}`,
+			fileMap:    `{"0":["variables.files.0"],"1":["variables.files.1"]}`,
+			files:      []filePart{{"0", "a.txt", "a"}, {"1", "b.txt", "bb"}},
+			want:       `{"data":{"uploadMany":["a.txt (application/octet-stream, 1 bytes): a","b.txt (application/octet-stream, 2 bytes): bb"]}}`,
+		},
+		{
+			name:       "input object",
+			operations: `{"query":"mutation($input: UploadInput!) { uploadInput(input: $input) }","variables":{"input":{"name":"doc","file":null}}}`,
+			fileMap:    `{"0":["variables.input.file"]}`,
+			files:      []filePart{{"0", "a.txt", "content"}},
+			want:       `{"data":{"uploadInput":"doc: a.txt (application/octet-stream, 7 bytes): content"}}`,
+		},
+		{
+			name:       "batch",
+			operations: `[{"query":"{ hello }"},{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}]`,
+			fileMap:    `{"0":["1.variables.file"]}`,
+			files:      []filePart{{"0", "a.txt", "hello"}},
+			want:       `[{"data":{"hello":"Hello"}},{"data":{"upload":"a.txt (application/octet-stream, 5 bytes): hello"}}]`,
+		},
+		{
+			name:       "literal",
+			operations: `{"query":"mutation { upload(file: \"a.txt\") }"}`,
+			fileMap:    `{}`,
+			want:       `{"data":{},"errors":[{"message":"wrong type for Upload: string, files must be uploaded as variables of a multipart request"}]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := newUploadRequest(t, test.operations, test.fileMap, test.files...)
+			if w.Code != 200 {
+				t.Fatalf("got status %d: %s", w.Code, w.Body)
+			}
+			if got := w.Body.String(); got != test.want {
+				t.Fatalf("unexpected response\ngot:  %s\nwant: %s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("files spooled to temporary files", func(t *testing.T) {
+		h := &relay.Handler{Schema: uploadSchema, UploadMemory: 1}
+		w := serveUpload(t, h, `{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`, `{"0":["variables.file"]}`, filePart{"0", "a.txt", "hello"})
+		if want := `{"data":{"upload":"a.txt (application/octet-stream, 5 bytes): hello"}}`; w.Body.String() != want {
+			t.Fatalf("got %s, want %s", w.Body, want)
+		}
+	})
+
+	t.Run("preflight headers", func(t *testing.T) {
+		const operations = `{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`
+		for _, test := range []struct {
+			name   string
+			header map[string]string
+			status int
+		}{
+			{name: "none", status: 400},
+			{name: "empty operation name", header: map[string]string{"X-Apollo-Operation-Name": ""}, status: 400},
+			{name: "require preflight", header: map[string]string{"Apollo-Require-Preflight": ""}, status: 200},
+			{name: "operation name", header: map[string]string{"X-Apollo-Operation-Name": "Upload"}, status: 200},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				r := newMultipartRequest(t, operations, `{"0":["variables.file"]}`, filePart{"0", "a.txt", "hello"})
+				for k, v := range test.header {
+					r.Header.Set(k, v)
+				}
+				w := httptest.NewRecorder()
+				(&relay.Handler{Schema: uploadSchema}).ServeHTTP(w, r)
+				if w.Code != test.status {
+					t.Fatalf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("file too large read up to the limit", func(t *testing.T) {
+		const size = 1 << 20
+		r := newMultipartRequest(t, `{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`, `{"0":["variables.file"]}`, filePart{"0", "a.txt", strings.Repeat("a", size)})
+		r.Header.Set("Apollo-Require-Preflight", "true")
+		body := &countingReader{r: r.Body}
+		r.Body = ioutil.NopCloser(body)
+		w := httptest.NewRecorder()
+		(&relay.Handler{Schema: uploadSchema, MaxUploadFileSize: 4}).ServeHTTP(w, r)
+		if w.Code != 413 {
+			t.Fatalf("got status %d, want 413", w.Code)
+		}
+		if body.n >= size {
+			t.Fatalf("read %d bytes of the request, expected to stop at the limit", body.n)
+		}
+	})
+
+	for _, test := range []struct {
+		name    string
+		handler *relay.Handler
+		fileMap string
+		status  int
+		message string
+	}{
+		{
+			name:    "request too large",
+			handler: &relay.Handler{Schema: uploadSchema, MaxUploadSize: 100},
+			fileMap: `{"0":["variables.file"]}`,
+			status:  413,
+			message: "the request is larger than 100 bytes",
+		},
+		{
+			name:    "file too large",
+			handler: &relay.Handler{Schema: uploadSchema, MaxUploadFileSize: 4},
+			fileMap: `{"0":["variables.file"]}`,
+			status:  413,
+			message: `file "a.txt" is larger than 4 bytes`,
+		},
+		{
+			name:    "missing file",
+			handler: &relay.Handler{Schema: uploadSchema},
+			fileMap: `{"1":["variables.file"]}`,
+			status:  400,
+			message: `the request must have one file part "1"`,
+		},
+		{
+			name:    "invalid path",
+			handler: &relay.Handler{Schema: uploadSchema},
+			fileMap: `{"0":["variables.other"]}`,
+			status:  400,
+			message: `invalid upload path "variables.other"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := serveUpload(t, test.handler, `{"query":"mutation($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`, test.fileMap, filePart{"0", "a.txt", "hello"})
+			if w.Code != test.status {
+				t.Fatalf("got status %d, want %d", w.Code, test.status)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != test.message {
+				t.Fatalf("got message %q, want %q", got, test.message)
+			}
+		})
+	}
+}
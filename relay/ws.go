@@ -0,0 +1,369 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/query"
+)
+
+// WSProtocol is the WebSocket subprotocol of the WSHandler.
+const WSProtocol = "graphql-transport-ws"
+
+// DefaultConnectionInitTimeout is the time a client of the WSHandler has to send its
+// connection_init message by default.
+const DefaultConnectionInitTimeout = 3 * time.Second
+
+// The close codes of the graphql-transport-ws protocol.
+const (
+	wsCloseBadRequest          = 4400
+	wsCloseUnauthorized        = 4401
+	wsCloseForbidden           = 4403
+	wsCloseSubprotocol         = 4406
+	wsCloseInitTimeout         = 4408
+	wsCloseSubscriberExists    = 4409
+	wsCloseTooManyInitRequests = 4429
+)
+
+// The message types of the graphql-transport-ws protocol.
+const (
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+)
+
+// WSHandler serves queries, mutations and subscriptions over WebSocket with the graphql-transport-ws
+// protocol, see https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+type WSHandler struct {
+	Schema *graphql.Schema
+
+	// OnConnect is called with the payload of the connection_init message. It returns the context of
+	// the operations of the connection, e.g. with the authenticated user, and the payload of the
+	// connection_ack message. An error rejects the connection with the close code 4403.
+	OnConnect func(ctx context.Context, payload map[string]interface{}) (context.Context, map[string]interface{}, error)
+
+	// ConnectionInitTimeout is the time the client has to send its connection_init message before the
+	// connection is closed with the close code 4408. It defaults to DefaultConnectionInitTimeout.
+	ConnectionInitTimeout time.Duration
+
+	// KeepAlive is the interval of the ping messages sent to the client. The connection is closed if
+	// the client does not answer a ping with a pong before the next ping is due. Zero disables the
+	// pings.
+	KeepAlive time.Duration
+
+	// CheckOrigin reports whether the WebSocket handshake is accepted from the origin of the request.
+	// By default only requests without an Origin header or from the same host are accepted.
+	CheckOrigin func(r *http.Request) bool
+}
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsSubscribePayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// wsConn is a connection of the WSHandler.
+type wsConn struct {
+	h    *WSHandler
+	conn *websocket.Conn
+	ctx  context.Context
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	initialized bool
+	acked       bool
+	awaitPong   bool
+	operations  map[string]*wsOperation
+}
+
+// wsOperation is a running operation of a connection.
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+func (h *WSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{WSProtocol},
+		CheckOrigin:  h.CheckOrigin,
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader replied with an error
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	c := &wsConn{
+		h:          h,
+		conn:       conn,
+		ctx:        ctx,
+		operations: make(map[string]*wsOperation),
+	}
+	if conn.Subprotocol() != WSProtocol {
+		c.close(wsCloseSubprotocol, "Subprotocol not acceptable")
+		return
+	}
+
+	timeout := h.ConnectionInitTimeout
+	if timeout == 0 {
+		timeout = DefaultConnectionInitTimeout
+	}
+	initTimer := time.AfterFunc(timeout, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if !c.initialized {
+			c.close(wsCloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	if h.KeepAlive > 0 {
+		go c.keepAlive(ctx, h.KeepAlive)
+	}
+
+	c.readMessages()
+}
+
+// readMessages handles the messages of the client until the connection is closed.
+func (c *wsConn) readMessages() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case wsConnectionInit:
+			c.mu.Lock()
+			initialized := c.initialized
+			c.initialized = true
+			c.mu.Unlock()
+			if initialized {
+				c.close(wsCloseTooManyInitRequests, "Too many initialisation requests")
+				return
+			}
+			if !c.init(msg.Payload) {
+				return
+			}
+
+		case wsPing:
+			if err := c.send(wsPong, "", msg.Payload); err != nil {
+				return
+			}
+
+		case wsPong:
+			c.mu.Lock()
+			c.awaitPong = false
+			c.mu.Unlock()
+
+		case wsSubscribe:
+			if !c.subscribe(msg) {
+				return
+			}
+
+		case wsComplete:
+			c.mu.Lock()
+			op := c.operations[msg.ID]
+			c.mu.Unlock()
+			if op != nil {
+				c.finish(msg.ID, op)
+			}
+
+		default:
+			c.close(wsCloseBadRequest, fmt.Sprintf("Invalid message type %q", msg.Type))
+			return
+		}
+	}
+}
+
+// init calls the OnConnect hook and acknowledges the connection. It reports false if the
+// connection was closed.
+func (c *wsConn) init(rawPayload json.RawMessage) bool {
+	var payload map[string]interface{}
+	if len(rawPayload) != 0 {
+		if err := json.Unmarshal(rawPayload, &payload); err != nil {
+			c.close(wsCloseBadRequest, "Invalid connection_init payload")
+			return false
+		}
+	}
+
+	var ackPayload map[string]interface{}
+	if c.h.OnConnect != nil {
+		ctx, ack, err := c.h.OnConnect(c.ctx, payload)
+		if err != nil {
+			c.close(wsCloseForbidden, "Forbidden")
+			return false
+		}
+		c.mu.Lock()
+		c.ctx = ctx
+		c.mu.Unlock()
+		ackPayload = ack
+	}
+
+	c.mu.Lock()
+	c.acked = true
+	c.mu.Unlock()
+	if ackPayload == nil {
+		return c.send(wsConnectionAck, "", nil) == nil
+	}
+	return c.send(wsConnectionAck, "", ackPayload) == nil
+}
+
+// subscribe starts the operation of a subscribe message. It reports false if the connection was
+// closed.
+func (c *wsConn) subscribe(msg wsMessage) bool {
+	var payload wsSubscribePayload
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+		c.close(wsCloseBadRequest, "Invalid subscribe message")
+		return false
+	}
+
+	c.mu.Lock()
+	if !c.acked {
+		c.mu.Unlock()
+		c.close(wsCloseUnauthorized, "Unauthorized")
+		return false
+	}
+	if _, ok := c.operations[msg.ID]; ok {
+		c.mu.Unlock()
+		c.close(wsCloseSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+		return false
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	op := &wsOperation{cancel: cancel}
+	c.operations[msg.ID] = op
+	c.mu.Unlock()
+
+	go c.execute(ctx, msg.ID, op, payload)
+	return true
+}
+
+// execute runs an operation and sends its results. The complete message is only sent if the
+// client did not complete the operation itself.
+func (c *wsConn) execute(ctx context.Context, id string, op *wsOperation, payload wsSubscribePayload) {
+	if errs := c.h.Schema.ValidateWithVariables(payload.Query, payload.Variables); len(errs) != 0 {
+		// the error message completes the operation
+		if c.finish(id, op) {
+			c.send(wsError, id, errs)
+		}
+		return
+	}
+
+	if operationType(payload.Query, payload.OperationName) != query.Subscription {
+		resp := c.h.Schema.Exec(ctx, payload.Query, payload.OperationName, payload.Variables)
+		if ctx.Err() == nil {
+			c.send(wsNext, id, resp)
+		}
+	} else if responses, err := c.h.Schema.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables); err != nil {
+		// the error message completes the operation
+		if c.finish(id, op) {
+			c.send(wsError, id, []*qerrors.QueryError{qerrors.Errorf("%s", err)})
+		}
+		return
+	} else {
+		for resp := range responses {
+			if ctx.Err() != nil {
+				continue // drain the responses of the completed subscription
+			}
+			c.send(wsNext, id, resp)
+		}
+	}
+
+	if c.finish(id, op) {
+		c.send(wsComplete, id, nil)
+	}
+}
+
+// finish cancels the operation and reports whether it was still running, i.e. whether the client
+// did not complete it. The id may have been reused by a new operation since.
+func (c *wsConn) finish(id string, op *wsOperation) bool {
+	c.mu.Lock()
+	running := c.operations[id] == op
+	if running {
+		delete(c.operations, id)
+	}
+	c.mu.Unlock()
+	op.cancel()
+	return running
+}
+
+// keepAlive pings the client until the context is done and closes the connection if a ping is not
+// answered in time.
+func (c *wsConn) keepAlive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			timedOut := c.awaitPong
+			c.awaitPong = true
+			c.mu.Unlock()
+			if timedOut {
+				c.close(websocket.CloseGoingAway, "Keep-alive timeout")
+				return
+			}
+			if err := c.send(wsPing, "", nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send writes a message to the client.
+func (c *wsConn) send(typ string, id string, payload interface{}) error {
+	msg := wsMessage{ID: id, Type: typ}
+	switch p := payload.(type) {
+	case nil:
+	case json.RawMessage:
+		msg.Payload = p
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			data, _ = json.Marshal(&graphql.Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
+		}
+		msg.Payload = data
+	}
+
+	data, err := json.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// close closes the connection with the close code and reason.
+func (c *wsConn) close(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	c.conn.Close()
+}
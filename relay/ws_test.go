@@ -0,0 +1,251 @@
+package relay_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+type userKey struct{}
+
+type wsResolver struct{}
+
+func (r *wsResolver) Hello(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return "Hello, " + user + "!"
+}
+
+// Count counts from 1, or resumes after the last event received by an event stream client.
+func (r *wsResolver) Count(ctx context.Context, args struct{ To int32 }) <-chan int32 {
+	last, _ := strconv.Atoi(relay.LastEventID(ctx))
+	c := make(chan int32)
+	go func() {
+		defer close(c)
+		for i := int32(last) + 1; i <= args.To; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+var wsSchema = graphql.MustParseSchema(`
+	type Query {
+		hello: String!
+	}
+
+	type Subscription {
+		count(to: Int!): Int!
+	}
+`, &wsResolver{})
+
+func newWSServer(t *testing.T, h *relay.WSHandler) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{relay.WSProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func wsSend(t *testing.T, conn *websocket.Conn, msg string) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wsExpect(t *testing.T, conn *websocket.Conn, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		_, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("expected message %s, got error %s", w, err)
+		}
+		if string(got) != w {
+			t.Fatalf("unexpected message\ngot:  %s\nwant: %s", got, w)
+		}
+	}
+}
+
+func wsExpectClose(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+	_, msg, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, code) {
+		t.Fatalf("expected close code %d, got message %s and error %v", code, msg, err)
+	}
+}
+
+func authenticate(ctx context.Context, payload map[string]interface{}) (context.Context, map[string]interface{}, error) {
+	if payload["token"] != "secret" {
+		return nil, nil, errors.New("invalid token")
+	}
+	return context.WithValue(ctx, userKey{}, "Alice"), map[string]interface{}{"user": "Alice"}, nil
+}
+
+func TestWSHandler(t *testing.T) {
+	t.Run("subscription", func(t *testing.T) {
+		conn := newWSServer(t, &relay.WSHandler{Schema: wsSchema})
+		wsSend(t, conn, `{"type":"connection_init"}`)
+		wsExpect(t, conn, `{"type":"connection_ack"}`)
+		wsSend(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription($to: Int!) { count(to: $to) }","variables":{"to":2}}}`)
+		wsExpect(t, conn,
+			`{"id":"1","type":"next","payload":{"data":{"count":1}}}`,
+			`{"id":"1","type":"next","payload":{"data":{"count":2}}}`,
+			`{"id":"1","type":"complete"}`,
+		)
+	})
+
+	t.Run("query with the context of the connection", func(t *testing.T) {
+		conn := newWSServer(t, &relay.WSHandler{Schema: wsSchema, OnConnect: authenticate})
+		wsSend(t, conn, `{"type":"connection_init","payload":{"token":"secret"}}`)
+		wsExpect(t, conn, `{"type":"connection_ack","payload":{"user":"Alice"}}`)
+		wsSend(t, conn, `{"id":"q","type":"subscribe","payload":{"query":"{ hello }"}}`)
+		wsExpect(t, conn,
+			`{"id":"q","type":"next","payload":{"data":{"hello":"Hello, Alice!"}}}`,
+			`{"id":"q","type":"complete"}`,
+		)
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		conn := newWSServer(t, &relay.WSHandler{Schema: wsSchema})
+		wsSend(t, conn, `{"type":"connection_init"}`)
+		wsExpect(t, conn, `{"type":"connection_ack"}`)
+		wsSend(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"{ unknown }"}}`)
+		wsExpect(t, conn, `{"id":"1","type":"error","payload":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`)
+	})
+
+	t.Run("subscribe error", func(t *testing.T) {
+		schema := graphql.MustParseSchema(`
+			type Query { hello: String! }
+			type Subscription { count(to: Int!): Int! }
+		`, nil)
+		conn := newWSServer(t, &relay.WSHandler{Schema: schema})
+		wsSend(t, conn, `{"type":"connection_init"}`)
+		wsExpect(t, conn, `{"type":"connection_ack"}`)
+		wsSend(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1) }"}}`)
+		wsExpect(t, conn, `{"id":"1","type":"error","payload":[{"message":"schema created without resolver, can not subscribe"}]}`)
+
+		// the error message is not followed by a complete message
+		wsSend(t, conn, `{"type":"ping"}`)
+		wsExpect(t, conn, `{"type":"pong"}`)
+	})
+
+	t.Run("complete by the client", func(t *testing.T) {
+		conn := newWSServer(t, &relay.WSHandler{Schema: wsSchema})
+		wsSend(t, conn, `{"type":"connection_init"}`)
+		wsExpect(t, conn, `{"type":"connection_ack"}`)
+		wsSend(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1000000) }"}}`)
+		wsExpect(t, conn, `{"id":"1","type":"next","payload":{"data":{"count":1}}}`)
+		wsSend(t, conn, `{"id":"1","type":"complete"}`)
+
+		// the id can be reused once the subscription is completed
+		wsSend(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"{ hello }"}}`)
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(msg), `"hello"`) {
+				break
+			}
+		}
+		wsExpect(t, conn, `{"id":"1","type":"complete"}`)
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		conn := newWSServer(t, &relay.WSHandler{Schema: wsSchema})
+		wsSend(t, conn, `{"type":"ping","payload":{"a":1}}`)
+		wsExpect(t, conn, `{"type":"pong","payload":{"a":1}}`)
+	})
+
+	t.Run("keep-alive", func(t *testing.T) {
+		conn := newWSServer(t, &relay.WSHandler{Schema: wsSchema, KeepAlive: 20 * time.Millisecond})
+		wsSend(t, conn, `{"type":"connection_init"}`)
+		wsExpect(t, conn, `{"type":"connection_ack"}`, `{"type":"ping"}`)
+		wsSend(t, conn, `{"type":"pong"}`)
+		wsExpect(t, conn, `{"type":"ping"}`)
+		wsExpectClose(t, conn, websocket.CloseGoingAway)
+	})
+
+	for _, test := range []struct {
+		name     string
+		handler  *relay.WSHandler
+		messages []string
+		code     int
+	}{
+		{
+			name:     "subscribe before connection_init",
+			handler:  &relay.WSHandler{Schema: wsSchema},
+			messages: []string{`{"id":"1","type":"subscribe","payload":{"query":"{ hello }"}}`},
+			code:     4401,
+		},
+		{
+			name:    "connection_init timeout",
+			handler: &relay.WSHandler{Schema: wsSchema, ConnectionInitTimeout: 10 * time.Millisecond},
+			code:    4408,
+		},
+		{
+			name:     "rejected connection_init",
+			handler:  &relay.WSHandler{Schema: wsSchema, OnConnect: authenticate},
+			messages: []string{`{"type":"connection_init","payload":{"token":"wrong"}}`},
+			code:     4403,
+		},
+		{
+			name:     "repeated connection_init",
+			handler:  &relay.WSHandler{Schema: wsSchema},
+			messages: []string{`{"type":"connection_init"}`, `{"type":"connection_init"}`},
+			code:     4429,
+		},
+		{
+			name:    "duplicate subscriber",
+			handler: &relay.WSHandler{Schema: wsSchema},
+			messages: []string{
+				`{"type":"connection_init"}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1000000) }"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1000000) }"}}`,
+			},
+			code: 4409,
+		},
+		{
+			name:     "invalid message",
+			handler:  &relay.WSHandler{Schema: wsSchema},
+			messages: []string{`{"type":"unknown"}`},
+			code:     4400,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			conn := newWSServer(t, test.handler)
+			for _, msg := range test.messages {
+				wsSend(t, conn, msg)
+			}
+			for {
+				_, _, err := conn.ReadMessage()
+				if err == nil {
+					continue // skip the messages before the close
+				}
+				if !websocket.IsCloseError(err, test.code) {
+					t.Fatalf("expected close code %d, got %v", test.code, err)
+				}
+				break
+			}
+		})
+	}
+}
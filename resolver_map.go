@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"github.com/graph-gophers/graphql-go/exec/resolvable"
+)
+
+// ResolverFunc resolves a field without reflection. The parent is the value the field is resolved
+// on: the root resolver for the fields of the root types, or the value returned by the resolver of
+// the parent field. The arguments include the defaults of the arguments which are not provided.
+type ResolverFunc = resolvable.FieldFunc
+
+// TypeResolverFunc returns the name of the object type of a value of an interface or union type.
+type TypeResolverFunc = resolvable.TypeFunc
+
+// ResolverMap binds fields to resolver functions by type name and field name.
+type ResolverMap map[string]map[string]ResolverFunc
+
+// Resolvers resolves the fields bound in the resolver map with functions instead of resolver
+// methods. Bound fields may be mixed with resolver methods on the same type, and the root resolver
+// passed to ParseSchema may be nil if all root fields are bound.
+//
+// The values returned by the functions are not inspected with reflection, so all fields of their
+// types must be bound as well. Their interface and union types must have a type resolver, see
+// TypeResolvers. The fields of an interface which are not bound are resolved with the functions
+// bound to the object types. ParseSchema returns an error if a binding is missing.
+func Resolvers(m ResolverMap) SchemaOpt {
+	return func(s *Schema) {
+		if s.funcs.Fields == nil {
+			s.funcs.Fields = make(map[string]map[string]ResolverFunc)
+		}
+		for typeName, fields := range m {
+			if s.funcs.Fields[typeName] == nil {
+				s.funcs.Fields[typeName] = make(map[string]ResolverFunc)
+			}
+			for fieldName, fn := range fields {
+				s.funcs.Fields[typeName][fieldName] = fn
+			}
+		}
+	}
+}
+
+// TypeResolvers registers the functions returning the object types of the values of interface and
+// union types, which are returned by functions of a ResolverMap.
+func TypeResolvers(m map[string]TypeResolverFunc) SchemaOpt {
+	return func(s *Schema) {
+		if s.funcs.Types == nil {
+			s.funcs.Types = make(map[string]TypeResolverFunc)
+		}
+		for typeName, fn := range m {
+			s.funcs.Types[typeName] = fn
+		}
+	}
+}
@@ -0,0 +1,476 @@
+// Package diff compares two schemas and classifies the changes by how they affect existing clients.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// Criticality classifies a change by its effect on existing clients.
+type Criticality int
+
+const (
+	// Safe changes do not affect existing clients.
+	Safe Criticality = iota
+	// Dangerous changes do not break existing operations, but may change the results or the
+	// behavior of clients, for example when they see a new enum value.
+	Dangerous
+	// Breaking changes make existing operations invalid or change the types of their results.
+	Breaking
+)
+
+func (c Criticality) String() string {
+	switch c {
+	case Safe:
+		return "SAFE"
+	case Dangerous:
+		return "DANGEROUS"
+	case Breaking:
+		return "BREAKING"
+	}
+	return fmt.Sprintf("Criticality(%d)", int(c))
+}
+
+// ChangeType identifies the kind of a change.
+type ChangeType string
+
+const (
+	TypeAdded                  ChangeType = "TYPE_ADDED"
+	TypeRemoved                ChangeType = "TYPE_REMOVED"
+	TypeKindChanged            ChangeType = "TYPE_KIND_CHANGED"
+	RootTypeChanged            ChangeType = "ROOT_TYPE_CHANGED"
+	FieldAdded                 ChangeType = "FIELD_ADDED"
+	FieldRemoved               ChangeType = "FIELD_REMOVED"
+	FieldTypeChanged           ChangeType = "FIELD_TYPE_CHANGED"
+	FieldDeprecated            ChangeType = "FIELD_DEPRECATED"
+	ArgumentAdded              ChangeType = "ARGUMENT_ADDED"
+	ArgumentRemoved            ChangeType = "ARGUMENT_REMOVED"
+	ArgumentTypeChanged        ChangeType = "ARGUMENT_TYPE_CHANGED"
+	ArgumentDefaultChanged     ChangeType = "ARGUMENT_DEFAULT_CHANGED"
+	InputFieldAdded            ChangeType = "INPUT_FIELD_ADDED"
+	InputFieldRemoved          ChangeType = "INPUT_FIELD_REMOVED"
+	InputFieldTypeChanged      ChangeType = "INPUT_FIELD_TYPE_CHANGED"
+	InputFieldDefaultChanged   ChangeType = "INPUT_FIELD_DEFAULT_CHANGED"
+	EnumValueAdded             ChangeType = "ENUM_VALUE_ADDED"
+	EnumValueRemoved           ChangeType = "ENUM_VALUE_REMOVED"
+	EnumValueDeprecated        ChangeType = "ENUM_VALUE_DEPRECATED"
+	UnionMemberAdded           ChangeType = "UNION_MEMBER_ADDED"
+	UnionMemberRemoved         ChangeType = "UNION_MEMBER_REMOVED"
+	InterfaceAdded             ChangeType = "INTERFACE_ADDED"
+	InterfaceRemoved           ChangeType = "INTERFACE_REMOVED"
+	DirectiveAdded             ChangeType = "DIRECTIVE_ADDED"
+	DirectiveRemoved           ChangeType = "DIRECTIVE_REMOVED"
+	DirectiveLocationAdded     ChangeType = "DIRECTIVE_LOCATION_ADDED"
+	DirectiveLocationRemoved   ChangeType = "DIRECTIVE_LOCATION_REMOVED"
+	DirectiveRepeatableRemoved ChangeType = "DIRECTIVE_REPEATABLE_REMOVED"
+)
+
+// Change is a single difference between two schemas.
+type Change struct {
+	Type        ChangeType
+	Criticality Criticality
+	// Path is the schema coordinate of the changed element, for example "Query.user(id:)".
+	Path    string
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Criticality, c.Message)
+}
+
+// Changes are the differences between two schemas.
+type Changes []Change
+
+// Breaking returns the breaking changes.
+func (cs Changes) Breaking() Changes {
+	return cs.filter(Breaking)
+}
+
+// Dangerous returns the dangerous changes.
+func (cs Changes) Dangerous() Changes {
+	return cs.filter(Dangerous)
+}
+
+func (cs Changes) filter(c Criticality) Changes {
+	var res Changes
+	for _, change := range cs {
+		if change.Criticality == c {
+			res = append(res, change)
+		}
+	}
+	return res
+}
+
+// Compare returns the changes from the old to the new schema, ordered by path. Descriptions are
+// not compared.
+func Compare(oldSchema, newSchema *types.Schema) Changes {
+	d := &differ{}
+	d.rootTypes(oldSchema, newSchema)
+	d.types(oldSchema, newSchema)
+	d.directives(oldSchema, newSchema)
+	sort.SliceStable(d.changes, func(i, j int) bool { return d.changes[i].Path < d.changes[j].Path })
+	return d.changes
+}
+
+type differ struct {
+	changes Changes
+}
+
+func (d *differ) add(t ChangeType, c Criticality, path string, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Type:        t,
+		Criticality: c,
+		Path:        path,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) rootTypes(oldSchema, newSchema *types.Schema) {
+	for _, op := range []string{"query", "mutation", "subscription"} {
+		oldName, newName := oldSchema.EntryPointNames[op], newSchema.EntryPointNames[op]
+		if oldName != "" && oldName != newName {
+			d.add(RootTypeChanged, Breaking, oldName, "The %s root type changed from %q to %q.", op, oldName, newName)
+		}
+	}
+}
+
+func (d *differ) types(oldSchema, newSchema *types.Schema) {
+	for _, name := range sortedKeys(oldSchema.Types) {
+		oldType := oldSchema.Types[name]
+		newType, ok := newSchema.Types[name]
+		if !ok {
+			d.add(TypeRemoved, Breaking, name, "Type %s was removed.", name)
+			continue
+		}
+		if oldType.Kind() != newType.Kind() {
+			d.add(TypeKindChanged, Breaking, name, "Type %s changed from %s to %s.", name, kindName(oldType), kindName(newType))
+			continue
+		}
+
+		switch oldType := oldType.(type) {
+		case *types.ObjectTypeDefinition:
+			newType := newType.(*types.ObjectTypeDefinition)
+			d.interfaces(name, oldType.Interfaces, newType.Interfaces)
+			d.fields(name, oldType.Fields, newType.Fields)
+		case *types.InterfaceTypeDefinition:
+			newType := newType.(*types.InterfaceTypeDefinition)
+			d.interfaces(name, oldType.Interfaces, newType.Interfaces)
+			d.fields(name, oldType.Fields, newType.Fields)
+		case *types.Union:
+			d.unionMembers(name, oldType.UnionMemberTypes, newType.(*types.Union).UnionMemberTypes)
+		case *types.EnumTypeDefinition:
+			d.enumValues(name, oldType.EnumValuesDefinition, newType.(*types.EnumTypeDefinition).EnumValuesDefinition)
+		case *types.InputObject:
+			d.inputFields(name, oldType.Values, newType.(*types.InputObject).Values)
+		}
+	}
+
+	for _, name := range sortedKeys(newSchema.Types) {
+		if _, ok := oldSchema.Types[name]; !ok {
+			d.add(TypeAdded, Safe, name, "Type %s was added.", name)
+		}
+	}
+}
+
+func (d *differ) interfaces(typeName string, oldInterfaces, newInterfaces []*types.InterfaceTypeDefinition) {
+	oldNames, newNames := interfaceNames(oldInterfaces), interfaceNames(newInterfaces)
+	for _, name := range oldNames {
+		if !contains(newNames, name) {
+			d.add(InterfaceRemoved, Breaking, typeName, "%s no longer implements interface %s.", typeName, name)
+		}
+	}
+	for _, name := range newNames {
+		if !contains(oldNames, name) {
+			d.add(InterfaceAdded, Dangerous, typeName, "%s implements the new interface %s.", typeName, name)
+		}
+	}
+}
+
+func (d *differ) fields(typeName string, oldFields, newFields types.FieldsDefinition) {
+	for _, oldField := range oldFields {
+		path := typeName + "." + oldField.Name
+		newField := newFields.Get(oldField.Name)
+		if newField == nil {
+			if isDeprecated(oldField.Directives) {
+				d.add(FieldRemoved, Breaking, path, "Deprecated field %s was removed.", path)
+			} else {
+				d.add(FieldRemoved, Breaking, path, "Field %s was removed.", path)
+			}
+			continue
+		}
+		if !safeOutputChange(oldField.Type, newField.Type) {
+			d.add(FieldTypeChanged, Breaking, path, "Field %s changed type from %s to %s.", path, oldField.Type, newField.Type)
+		} else if oldField.Type.String() != newField.Type.String() {
+			d.add(FieldTypeChanged, Safe, path, "Field %s changed type from %s to %s.", path, oldField.Type, newField.Type)
+		}
+		if !isDeprecated(oldField.Directives) && isDeprecated(newField.Directives) {
+			d.add(FieldDeprecated, Safe, path, "Field %s was deprecated.", path)
+		}
+		d.arguments(path, oldField.Arguments, newField.Arguments)
+	}
+	for _, newField := range newFields {
+		if oldFields.Get(newField.Name) == nil {
+			path := typeName + "." + newField.Name
+			d.add(FieldAdded, Safe, path, "Field %s was added.", path)
+		}
+	}
+}
+
+func (d *differ) arguments(fieldPath string, oldArgs, newArgs types.ArgumentsDefinition) {
+	for _, oldArg := range oldArgs {
+		path := fieldPath + "(" + oldArg.Name.Name + ":)"
+		newArg := newArgs.Get(oldArg.Name.Name)
+		if newArg == nil {
+			d.add(ArgumentRemoved, Breaking, path, "Argument %s was removed.", path)
+			continue
+		}
+		d.inputValue(path, "Argument", ArgumentTypeChanged, ArgumentDefaultChanged, oldArg, newArg)
+	}
+	for _, newArg := range newArgs {
+		if oldArgs.Get(newArg.Name.Name) != nil {
+			continue
+		}
+		path := fieldPath + "(" + newArg.Name.Name + ":)"
+		if isRequired(newArg) {
+			d.add(ArgumentAdded, Breaking, path, "Required argument %s was added.", path)
+		} else {
+			d.add(ArgumentAdded, Dangerous, path, "Optional argument %s was added.", path)
+		}
+	}
+}
+
+func (d *differ) inputFields(typeName string, oldFields, newFields types.ArgumentsDefinition) {
+	for _, oldField := range oldFields {
+		path := typeName + "." + oldField.Name.Name
+		newField := newFields.Get(oldField.Name.Name)
+		if newField == nil {
+			d.add(InputFieldRemoved, Breaking, path, "Input field %s was removed.", path)
+			continue
+		}
+		d.inputValue(path, "Input field", InputFieldTypeChanged, InputFieldDefaultChanged, oldField, newField)
+	}
+	for _, newField := range newFields {
+		if oldFields.Get(newField.Name.Name) != nil {
+			continue
+		}
+		path := typeName + "." + newField.Name.Name
+		if isRequired(newField) {
+			d.add(InputFieldAdded, Breaking, path, "Required input field %s was added.", path)
+		} else {
+			d.add(InputFieldAdded, Dangerous, path, "Optional input field %s was added.", path)
+		}
+	}
+}
+
+// inputValue compares the type and default value of an argument or input field.
+func (d *differ) inputValue(path, what string, typeChanged, defaultChanged ChangeType, oldValue, newValue *types.InputValueDefinition) {
+	if !safeInputChange(oldValue.Type, newValue.Type) {
+		d.add(typeChanged, Breaking, path, "%s %s changed type from %s to %s.", what, path, oldValue.Type, newValue.Type)
+	} else if oldValue.Type.String() != newValue.Type.String() {
+		d.add(typeChanged, Safe, path, "%s %s changed type from %s to %s.", what, path, oldValue.Type, newValue.Type)
+	}
+
+	oldDefault, newDefault := valueString(oldValue.Default), valueString(newValue.Default)
+	switch {
+	case oldDefault == newDefault:
+	case oldValue.Default == nil:
+		d.add(defaultChanged, Dangerous, path, "%s %s has the new default value %s.", what, path, newDefault)
+	case newValue.Default == nil:
+		d.add(defaultChanged, Dangerous, path, "%s %s no longer has the default value %s.", what, path, oldDefault)
+	default:
+		d.add(defaultChanged, Dangerous, path, "%s %s changed default value from %s to %s.", what, path, oldDefault, newDefault)
+	}
+}
+
+func (d *differ) enumValues(typeName string, oldValues, newValues []*types.EnumValueDefinition) {
+	for _, oldValue := range oldValues {
+		path := typeName + "." + oldValue.EnumValue
+		newValue := enumValue(newValues, oldValue.EnumValue)
+		if newValue == nil {
+			d.add(EnumValueRemoved, Breaking, path, "Enum value %s was removed.", path)
+			continue
+		}
+		if !isDeprecated(oldValue.Directives) && isDeprecated(newValue.Directives) {
+			d.add(EnumValueDeprecated, Safe, path, "Enum value %s was deprecated.", path)
+		}
+	}
+	for _, newValue := range newValues {
+		if enumValue(oldValues, newValue.EnumValue) == nil {
+			path := typeName + "." + newValue.EnumValue
+			d.add(EnumValueAdded, Dangerous, path, "Enum value %s was added.", path)
+		}
+	}
+}
+
+func (d *differ) unionMembers(typeName string, oldMembers, newMembers []*types.ObjectTypeDefinition) {
+	oldNames, newNames := objectNames(oldMembers), objectNames(newMembers)
+	for _, name := range oldNames {
+		if !contains(newNames, name) {
+			d.add(UnionMemberRemoved, Breaking, typeName, "%s was removed from union %s.", name, typeName)
+		}
+	}
+	for _, name := range newNames {
+		if !contains(oldNames, name) {
+			d.add(UnionMemberAdded, Dangerous, typeName, "%s was added to union %s.", name, typeName)
+		}
+	}
+}
+
+func (d *differ) directives(oldSchema, newSchema *types.Schema) {
+	for _, name := range sortedDirectives(oldSchema.Directives) {
+		oldDir := oldSchema.Directives[name]
+		path := "@" + name
+		newDir, ok := newSchema.Directives[name]
+		if !ok {
+			d.add(DirectiveRemoved, Breaking, path, "Directive %s was removed.", path)
+			continue
+		}
+		for _, loc := range oldDir.Locations {
+			if !contains(newDir.Locations, loc) {
+				d.add(DirectiveLocationRemoved, Breaking, path, "Location %s was removed from directive %s.", loc, path)
+			}
+		}
+		for _, loc := range newDir.Locations {
+			if !contains(oldDir.Locations, loc) {
+				d.add(DirectiveLocationAdded, Safe, path, "Location %s was added to directive %s.", loc, path)
+			}
+		}
+		if oldDir.Repeatable && !newDir.Repeatable {
+			d.add(DirectiveRepeatableRemoved, Breaking, path, "Directive %s is no longer repeatable.", path)
+		}
+		d.arguments(path, oldDir.Arguments, newDir.Arguments)
+	}
+	for _, name := range sortedDirectives(newSchema.Directives) {
+		if _, ok := oldSchema.Directives[name]; !ok {
+			path := "@" + name
+			d.add(DirectiveAdded, Safe, path, "Directive %s was added.", path)
+		}
+	}
+}
+
+// safeOutputChange reports whether the values of the new type are valid values of the old type.
+// An output type may become non-null, but not nullable.
+func safeOutputChange(oldType, newType types.Type) bool {
+	switch oldType := oldType.(type) {
+	case *types.NonNull:
+		if newType, ok := newType.(*types.NonNull); ok {
+			return safeOutputChange(oldType.OfType, newType.OfType)
+		}
+		return false
+	case *types.List:
+		switch newType := newType.(type) {
+		case *types.List:
+			return safeOutputChange(oldType.OfType, newType.OfType)
+		case *types.NonNull:
+			return safeOutputChange(oldType, newType.OfType)
+		}
+		return false
+	case types.NamedType:
+		switch newType := newType.(type) {
+		case types.NamedType:
+			return oldType.TypeName() == newType.TypeName()
+		case *types.NonNull:
+			return safeOutputChange(oldType, newType.OfType)
+		}
+		return false
+	}
+	return false
+}
+
+// safeInputChange reports whether the values of the old type are valid values of the new type.
+// An input type may become nullable, but not non-null.
+func safeInputChange(oldType, newType types.Type) bool {
+	switch oldType := oldType.(type) {
+	case *types.NonNull:
+		if newType, ok := newType.(*types.NonNull); ok {
+			return safeInputChange(oldType.OfType, newType.OfType)
+		}
+		return safeInputChange(oldType.OfType, newType)
+	case *types.List:
+		if newType, ok := newType.(*types.List); ok {
+			return safeInputChange(oldType.OfType, newType.OfType)
+		}
+		return false
+	case types.NamedType:
+		if newType, ok := newType.(types.NamedType); ok {
+			return oldType.TypeName() == newType.TypeName()
+		}
+		return false
+	}
+	return false
+}
+
+func isRequired(v *types.InputValueDefinition) bool {
+	_, nonNull := v.Type.(*types.NonNull)
+	return nonNull && v.Default == nil
+}
+
+func isDeprecated(directives types.DirectiveList) bool {
+	return directives.Get("deprecated") != nil
+}
+
+func valueString(v types.Value) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+func kindName(t types.NamedType) string {
+	return strings.ToLower(strings.Replace(t.Kind(), "_", " ", -1))
+}
+
+func enumValue(values []*types.EnumValueDefinition, name string) *types.EnumValueDefinition {
+	for _, v := range values {
+		if v.EnumValue == name {
+			return v
+		}
+	}
+	return nil
+}
+
+func interfaceNames(interfaces []*types.InterfaceTypeDefinition) []string {
+	names := make([]string, len(interfaces))
+	for i, iface := range interfaces {
+		names[i] = iface.Name
+	}
+	return names
+}
+
+func objectNames(objects []*types.ObjectTypeDefinition) []string {
+	names := make([]string, len(objects))
+	for i, obj := range objects {
+		names[i] = obj.Name
+	}
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]types.NamedType) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedDirectives(m map[string]*types.DirectiveDefinition) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,239 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/schema"
+	"github.com/graph-gophers/graphql-go/schema/diff"
+)
+
+func TestCompare(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{
+			name: "no changes",
+			old:  `type Query { a: Int }`,
+			new:  `type Query { a: Int }`,
+		},
+		{
+			name: "types",
+			old: `
+				type Query { a: Int }
+				type Removed { a: Int }
+				type Kind { a: Int }
+			`,
+			new: `
+				type Query { a: Int }
+				input Kind { a: Int }
+				scalar Added
+			`,
+			want: []string{
+				"SAFE Added: Type Added was added.",
+				"BREAKING Kind: Type Kind changed from object to input object.",
+				"BREAKING Removed: Type Removed was removed.",
+			},
+		},
+		{
+			name: "fields",
+			old: `
+				type Query {
+					removed: Int
+					nullable: Int
+					nonNull: Int!
+					list: [Int]
+					renamed: String
+					deprecated: Int
+				}
+			`,
+			new: `
+				type Query {
+					added: Int
+					nullable: Int!
+					nonNull: Int
+					list: [Int!]!
+					renamed: ID
+					deprecated: Int @deprecated
+				}
+			`,
+			want: []string{
+				"SAFE Query.added: Field Query.added was added.",
+				"SAFE Query.deprecated: Field Query.deprecated was deprecated.",
+				"SAFE Query.list: Field Query.list changed type from [Int] to [Int!]!.",
+				"BREAKING Query.nonNull: Field Query.nonNull changed type from Int! to Int.",
+				"SAFE Query.nullable: Field Query.nullable changed type from Int to Int!.",
+				"BREAKING Query.removed: Field Query.removed was removed.",
+				"BREAKING Query.renamed: Field Query.renamed changed type from String to ID.",
+			},
+		},
+		{
+			name: "arguments",
+			old: `
+				type Query {
+					a(removed: Int, nullable: Int, nonNull: Int!, def: Int = 1, list: [Int!]): Int
+				}
+			`,
+			new: `
+				type Query {
+					a(nullable: Int!, nonNull: Int, def: Int = 2, list: [Int], required: Int!, optional: Int, withDefault: Int! = 1): Int
+				}
+			`,
+			want: []string{
+				`DANGEROUS Query.a(def:): Argument Query.a(def:) changed default value from 1 to 2.`,
+				`SAFE Query.a(list:): Argument Query.a(list:) changed type from [Int!] to [Int].`,
+				`SAFE Query.a(nonNull:): Argument Query.a(nonNull:) changed type from Int! to Int.`,
+				`BREAKING Query.a(nullable:): Argument Query.a(nullable:) changed type from Int to Int!.`,
+				`DANGEROUS Query.a(optional:): Optional argument Query.a(optional:) was added.`,
+				`BREAKING Query.a(removed:): Argument Query.a(removed:) was removed.`,
+				`BREAKING Query.a(required:): Required argument Query.a(required:) was added.`,
+				`DANGEROUS Query.a(withDefault:): Optional argument Query.a(withDefault:) was added.`,
+			},
+		},
+		{
+			name: "input fields",
+			old: `
+				type Query { a(in: In): Int }
+				input In { removed: Int, nullable: Int, def: String = "x" }
+			`,
+			new: `
+				type Query { a(in: In): Int }
+				input In { nullable: Int!, def: String, required: Int!, optional: Int }
+			`,
+			want: []string{
+				`DANGEROUS In.def: Input field In.def no longer has the default value "x".`,
+				`BREAKING In.nullable: Input field In.nullable changed type from Int to Int!.`,
+				`DANGEROUS In.optional: Optional input field In.optional was added.`,
+				`BREAKING In.removed: Input field In.removed was removed.`,
+				`BREAKING In.required: Required input field In.required was added.`,
+			},
+		},
+		{
+			name: "enum values",
+			old: `
+				type Query { a: Color }
+				enum Color { RED GREEN BLUE }
+			`,
+			new: `
+				type Query { a: Color }
+				enum Color { RED GREEN @deprecated YELLOW }
+			`,
+			want: []string{
+				"BREAKING Color.BLUE: Enum value Color.BLUE was removed.",
+				"SAFE Color.GREEN: Enum value Color.GREEN was deprecated.",
+				"DANGEROUS Color.YELLOW: Enum value Color.YELLOW was added.",
+			},
+		},
+		{
+			name: "unions and interfaces",
+			old: `
+				type Query { a: Result }
+				union Result = A | B
+				interface Node { id: ID! }
+				interface Named { name: String }
+				type A implements Node { id: ID! name: String }
+				type B { id: ID! }
+			`,
+			new: `
+				type Query { a: Result }
+				union Result = A | C
+				interface Node { id: ID! }
+				interface Named { name: String }
+				type A implements Named { id: ID! name: String }
+				type B { id: ID! }
+				type C { id: ID! }
+			`,
+			want: []string{
+				"BREAKING A: A no longer implements interface Node.",
+				"DANGEROUS A: A implements the new interface Named.",
+				"SAFE C: Type C was added.",
+				"BREAKING Result: B was removed from union Result.",
+				"DANGEROUS Result: C was added to union Result.",
+			},
+		},
+		{
+			name: "root types",
+			old: `
+				schema { query: Query mutation: Mutation }
+				type Query { a: Int }
+				type Mutation { a: Int }
+				type OtherMutation { a: Int }
+			`,
+			new: `
+				schema { query: Query mutation: OtherMutation }
+				type Query { a: Int }
+				type Mutation { a: Int }
+				type OtherMutation { a: Int }
+			`,
+			want: []string{
+				`BREAKING Mutation: The mutation root type changed from "Mutation" to "OtherMutation".`,
+			},
+		},
+		{
+			name: "directives",
+			old: `
+				directive @removed on FIELD
+				directive @changed(a: Int, b: String) repeatable on FIELD | QUERY
+				type Query { a: Int }
+			`,
+			new: `
+				directive @changed(a: Int, c: String!) on FIELD | MUTATION
+				directive @added on FIELD
+				type Query { a: Int }
+			`,
+			want: []string{
+				"SAFE @added: Directive @added was added.",
+				"BREAKING @changed: Location QUERY was removed from directive @changed.",
+				"SAFE @changed: Location MUTATION was added to directive @changed.",
+				"BREAKING @changed: Directive @changed is no longer repeatable.",
+				"BREAKING @changed(b:): Argument @changed(b:) was removed.",
+				"BREAKING @changed(c:): Required argument @changed(c:) was added.",
+				"BREAKING @removed: Directive @removed was removed.",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldSchema, err := schema.ParseSchema(test.old, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newSchema, err := schema.ParseSchema(test.new, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, c := range diff.Compare(oldSchema, newSchema) {
+				got = append(got, c.Criticality.String()+" "+c.Path+": "+c.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	oldSchema, err := schema.ParseSchema(`type Query { a(x: Int): Int, b: Int }`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSchema, err := schema.ParseSchema(`type Query { a(x: Int!, y: Int): Int, c: Int }`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := diff.Compare(oldSchema, newSchema)
+	if got := len(changes.Breaking()); got != 2 {
+		t.Errorf("got %d breaking changes, want 2: %v", got, changes.Breaking())
+	}
+	if got := len(changes.Dangerous()); got != 1 {
+		t.Errorf("got %d dangerous changes, want 1: %v", got, changes.Dangerous())
+	}
+	if got, want := changes[0].String(), "BREAKING: Argument Query.a(x:) changed type from Int to Int!."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,319 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/common"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// AddFederation adds the scalars and directives of an Apollo Federation subgraph to the schema.
+// It must be called before Parse, which resolves the argument types. AddEntities must be called
+// after Parse.
+func AddFederation(s *types.Schema) {
+	l := common.NewLexer(federationSrc, false)
+	if err := l.CatchSyntaxError(func() { parseSchema(s, l) }); err != nil {
+		panic(err)
+	}
+}
+
+// AddEntities adds the _Entity union of the object types with a resolvable @key directive and the
+// _entities field resolving them to the query type. It does nothing if the schema has no entity
+// types.
+func AddEntities(s *types.Schema) error {
+	key := "key"
+	if link := federationLink(s); link != nil {
+		names, err := federationNames(link)
+		if err != nil {
+			return err
+		}
+		key = names["@key"]
+	}
+
+	var entities []*types.ObjectTypeDefinition
+	for _, obj := range s.Objects {
+		if s.Types[obj.Name] == obj && isEntity(obj, key) {
+			entities = append(entities, obj)
+		}
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+
+	query, ok := s.RootOperationTypes["query"].(*types.ObjectTypeDefinition)
+	if !ok {
+		return fmt.Errorf("the entity types require a query type")
+	}
+	if query.Fields.Get("_entities") != nil {
+		return fmt.Errorf("the query type %q must not define the _entities field", query.Name)
+	}
+
+	union := &types.Union{
+		Name:             "_Entity",
+		UnionMemberTypes: entities,
+	}
+	for _, obj := range entities {
+		union.TypeNames = append(union.TypeNames, obj.Name)
+	}
+	s.Types[union.Name] = union
+	s.Unions = append(s.Unions, union)
+
+	query.Fields = append(query.Fields, &types.FieldDefinition{
+		Name: "_entities",
+		Arguments: types.ArgumentsDefinition{{
+			Name: types.Ident{Name: "representations"},
+			Type: &types.NonNull{OfType: &types.List{OfType: &types.NonNull{OfType: s.Types["_Any"]}}},
+		}},
+		Type: &types.NonNull{OfType: &types.List{OfType: union}},
+	})
+	return nil
+}
+
+// isEntity reports whether the object has a key directive which is not marked with
+// `resolvable: false`.
+func isEntity(obj *types.ObjectTypeDefinition, key string) bool {
+	for _, d := range obj.Directives {
+		if d.Name.Name != key {
+			continue
+		}
+		if v, ok := d.Arguments.Get("resolvable"); ok && v != nil && v.Deserialize(nil) == false {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// federationV2URL is the prefix of the URLs of the federation v2 specifications.
+const federationV2URL = "https://specs.apollo.dev/federation/v2."
+
+// federationLink returns the @link of the schema to a federation v2 specification, or nil if the
+// schema is a federation v1 subgraph.
+func federationLink(s *types.Schema) *types.Directive {
+	if _, ok := s.Directives["link"]; !ok {
+		return nil
+	}
+	for _, d := range s.SchemaDirectives {
+		if d.Name.Name != "link" {
+			continue
+		}
+		if v, ok := d.Arguments.Get("url"); ok && v != nil {
+			if url, ok := v.Deserialize(nil).(string); ok && strings.HasPrefix(url, federationV2URL) {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// federationNames returns the names of the directives and types of the federation v2
+// specification in the schema, keyed by their names in the specification, e.g. "@key" or
+// "FieldSet". The imported names are used as is or renamed with "as", the others are prefixed with
+// the namespace of the link, e.g. "federation__key".
+func federationNames(link *types.Directive) (map[string]string, error) {
+	namespace := "federation"
+	if v, ok := link.Arguments.Get("as"); ok && v != nil {
+		if as, ok := v.Deserialize(nil).(string); ok {
+			namespace = as
+		}
+	}
+
+	names := make(map[string]string)
+	for _, name := range federationV2Elements {
+		if strings.HasPrefix(name, "@") {
+			names[name] = namespace + "__" + name[1:]
+		} else {
+			names[name] = namespace + "__" + name
+		}
+	}
+
+	v, ok := link.Arguments.Get("import")
+	if !ok || v == nil {
+		return names, nil
+	}
+	imports, _ := v.Deserialize(nil).([]interface{})
+	for _, imp := range imports {
+		var name, as string
+		switch imp := imp.(type) {
+		case string:
+			name, as = imp, imp
+		case map[string]interface{}:
+			name, _ = imp["name"].(string)
+			as, _ = imp["as"].(string)
+			if as == "" {
+				as = name
+			}
+		}
+		if _, ok := names[name]; !ok {
+			return nil, fmt.Errorf("%q is not an element of the federation specification %s", name, link.Arguments.MustGet("url"))
+		}
+		if strings.HasPrefix(name, "@") != strings.HasPrefix(as, "@") {
+			return nil, fmt.Errorf("%q cannot be imported as %q", name, as)
+		}
+		names[name] = strings.TrimPrefix(as, "@")
+	}
+	return names, nil
+}
+
+// applyLinks replaces the federation v1 directives with the federation v2 ones if the schema links
+// to the federation v2 specification. The directives and types are named after the imports of the
+// link.
+func applyLinks(s *types.Schema) error {
+	link := federationLink(s)
+	if link == nil {
+		return nil
+	}
+	names, err := federationNames(link)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{"key", "external", "requires", "provides", "extends"} {
+		delete(s.Directives, name)
+	}
+
+	v2 := &types.Schema{
+		EntryPointNames: make(map[string]string),
+		Types:           make(map[string]types.NamedType),
+		Directives:      make(map[string]*types.DirectiveDefinition),
+	}
+	l := common.NewLexer(federationV2Src, false)
+	if err := l.CatchSyntaxError(func() { parseSchema(v2, l) }); err != nil {
+		panic(err)
+	}
+	fieldSet := names["FieldSet"]
+	for _, d := range v2.Directives {
+		d.Name = names["@"+d.Name]
+		for _, arg := range d.Arguments {
+			arg.Type = renameType(arg.Type, "FieldSet", fieldSet)
+		}
+		if _, ok := s.Directives[d.Name]; ok {
+			return fmt.Errorf("directive %q is already defined", d.Name)
+		}
+		s.Directives[d.Name] = d
+	}
+	scalar := v2.Types["FieldSet"].(*types.ScalarTypeDefinition)
+	scalar.Name = fieldSet
+	if _, ok := s.Types[fieldSet]; ok {
+		return fmt.Errorf("type %q is already defined", fieldSet)
+	}
+	s.Types[fieldSet] = scalar
+	return nil
+}
+
+// renameType returns the type with the references to the named type from renamed to to.
+func renameType(t types.Type, from, to string) types.Type {
+	switch t := t.(type) {
+	case *types.NonNull:
+		return &types.NonNull{OfType: renameType(t.OfType, from, to)}
+	case *types.List:
+		return &types.List{OfType: renameType(t.OfType, from, to)}
+	case *types.TypeName:
+		if t.Name == from {
+			return &types.TypeName{Ident: types.Ident{Name: to, Loc: t.Loc}}
+		}
+	}
+	return t
+}
+
+// federationDefinitions returns the names of the directives and types added to the schema by
+// AddFederation, AddEntities and applyLinks.
+func federationDefinitions(s *types.Schema) (directives, typeNames map[string]bool) {
+	directives = map[string]bool{"link": true}
+	typeNames = map[string]bool{"_Any": true, "_FieldSet": true, "_Entity": true, "link__Import": true, "link__Purpose": true}
+	link := federationLink(s)
+	if link == nil {
+		for _, name := range []string{"key", "external", "requires", "provides", "extends"} {
+			directives[name] = true
+		}
+		return directives, typeNames
+	}
+	names, err := federationNames(link)
+	if err != nil {
+		return directives, typeNames
+	}
+	for name, schemaName := range names {
+		if strings.HasPrefix(name, "@") {
+			directives[schemaName] = true
+		} else {
+			typeNames[schemaName] = true
+		}
+	}
+	return directives, typeNames
+}
+
+var federationSrc = `
+	# The representation of an entity, an object with its "__typename" and the fields of a key.
+	scalar _Any
+
+	# A selection set of fields, e.g. "id" or "organization { id }".
+	scalar _FieldSet
+
+	# Marks an object type as an entity, which other subgraphs can reference and extend by its key fields.
+	directive @key(fields: _FieldSet!) repeatable on OBJECT | INTERFACE
+
+	# Marks a field as owned by another subgraph.
+	directive @external on FIELD_DEFINITION | OBJECT
+
+	# Marks the fields of the entity owned by other subgraphs which are required to resolve the field.
+	directive @requires(fields: _FieldSet!) on FIELD_DEFINITION
+
+	# Marks the fields of the returned entity which this subgraph can resolve as well.
+	directive @provides(fields: _FieldSet!) on FIELD_DEFINITION
+
+	# Marks an object type as an extension of an entity type defined in another subgraph.
+	directive @extends on OBJECT | INTERFACE
+
+	# Links the schema to a specification, e.g. the federation v2 specification. The imported
+	# definitions are used by name, the others with the namespace of the link, e.g. @federation__tag.
+	directive @link(url: String!, as: String, import: [link__Import], for: link__Purpose) repeatable on SCHEMA
+
+	# An element of a linked specification, e.g. "@key", or an object with its name and the name it is imported as.
+	scalar link__Import
+
+	# The purpose of a linked specification.
+	enum link__Purpose {
+		SECURITY
+		EXECUTION
+	}
+`
+
+// federationV2Elements are the directives and types of the federation v2 specification.
+var federationV2Elements = []string{
+	"@key", "@requires", "@provides", "@external", "@shareable", "@inaccessible", "@override",
+	"@tag", "@interfaceObject", "@extends", "@composeDirective", "FieldSet",
+}
+
+// federationV2Src is the federation v2 specification, applied with the names of the @link imports.
+var federationV2Src = `
+	scalar FieldSet
+
+	directive @key(fields: FieldSet!, resolvable: Boolean = true) repeatable on OBJECT | INTERFACE
+
+	directive @requires(fields: FieldSet!) on FIELD_DEFINITION
+
+	directive @provides(fields: FieldSet!) on FIELD_DEFINITION
+
+	directive @external on OBJECT | FIELD_DEFINITION
+
+	# Marks an object type or field as resolvable by more than one subgraph.
+	directive @shareable repeatable on OBJECT | FIELD_DEFINITION
+
+	# Hides an element from the public API of the supergraph.
+	directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+
+	# Moves the resolution of a field from the subgraph "from" to this subgraph.
+	directive @override(from: String!) on FIELD_DEFINITION
+
+	# Tags an element, e.g. for contracts.
+	directive @tag(name: String!) repeatable on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+
+	# Marks an object type as the local representation of an entity interface of the supergraph.
+	directive @interfaceObject on OBJECT
+
+	directive @extends on OBJECT | INTERFACE
+
+	# Preserves a custom directive in the supergraph.
+	directive @composeDirective(name: String!) repeatable on SCHEMA
+`
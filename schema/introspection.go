@@ -0,0 +1,384 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/scanner"
+
+	"github.com/graph-gophers/graphql-go/common"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// FromIntrospection builds a schema from the result of an introspection query, like the one of
+// Schema.ToJSON. The result may be the bare data or a full response with a "data" key. The schema
+// has no resolvers attached, it describes a remote service and can be used to validate operations
+// or print the schema.
+func FromIntrospection(data []byte) (*types.Schema, error) {
+	var result struct {
+		Data   *introspectionData   `json:"data"`
+		Schema *introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %s", err)
+	}
+	schema := result.Schema
+	if result.Data != nil {
+		schema = result.Data.Schema
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("invalid introspection result: missing __schema")
+	}
+
+	sdl, err := schema.sdl()
+	if err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %s", err)
+	}
+	s, err := ParseSchema(sdl, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %s", err)
+	}
+	return s, nil
+}
+
+type introspectionData struct {
+	Schema *introspectionSchema `json:"__schema"`
+}
+
+type introspectionSchema struct {
+	QueryType        *introspectionTypeRef     `json:"queryType"`
+	MutationType     *introspectionTypeRef     `json:"mutationType"`
+	SubscriptionType *introspectionTypeRef     `json:"subscriptionType"`
+	Types            []*introspectionType      `json:"types"`
+	Directives       []*introspectionDirective `json:"directives"`
+}
+
+type introspectionType struct {
+	Kind           string                     `json:"kind"`
+	Name           string                     `json:"name"`
+	Description    *string                    `json:"description"`
+	Fields         []*introspectionField      `json:"fields"`
+	InputFields    []*introspectionInputValue `json:"inputFields"`
+	Interfaces     []*introspectionTypeRef    `json:"interfaces"`
+	EnumValues     []*introspectionEnumValue  `json:"enumValues"`
+	PossibleTypes  []*introspectionTypeRef    `json:"possibleTypes"`
+	SpecifiedByURL *string                    `json:"specifiedByURL"`
+	IsOneOf        *bool                      `json:"isOneOf"`
+}
+
+type introspectionField struct {
+	Name              string                     `json:"name"`
+	Description       *string                    `json:"description"`
+	Args              []*introspectionInputValue `json:"args"`
+	Type              *introspectionTypeRef      `json:"type"`
+	IsDeprecated      bool                       `json:"isDeprecated"`
+	DeprecationReason *string                    `json:"deprecationReason"`
+}
+
+type introspectionInputValue struct {
+	Name         string                `json:"name"`
+	Description  *string               `json:"description"`
+	Type         *introspectionTypeRef `json:"type"`
+	DefaultValue *string               `json:"defaultValue"`
+}
+
+type introspectionEnumValue struct {
+	Name              string  `json:"name"`
+	Description       *string `json:"description"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+}
+
+type introspectionDirective struct {
+	Name         string                     `json:"name"`
+	Description  *string                    `json:"description"`
+	Locations    []string                   `json:"locations"`
+	Args         []*introspectionInputValue `json:"args"`
+	IsRepeatable bool                       `json:"isRepeatable"`
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   *string               `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+var namePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+func checkName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	return nil
+}
+
+func (t *introspectionTypeRef) String() (string, error) {
+	if t == nil {
+		return "", fmt.Errorf("missing type reference")
+	}
+	switch t.Kind {
+	case "LIST":
+		of, err := t.OfType.String()
+		if err != nil {
+			return "", err
+		}
+		return "[" + of + "]", nil
+	case "NON_NULL":
+		of, err := t.OfType.String()
+		if err != nil {
+			return "", err
+		}
+		return of + "!", nil
+	default:
+		if t.Name == nil {
+			return "", fmt.Errorf("missing name of type reference of kind %q", t.Kind)
+		}
+		if err := checkName(*t.Name); err != nil {
+			return "", err
+		}
+		return *t.Name, nil
+	}
+}
+
+// sdl returns the schema definition language equivalent of the introspection result. Only the
+// names and default values are inserted verbatim, both are checked before.
+func (s *introspectionSchema) sdl() (string, error) {
+	p := &printer{}
+
+	if s.QueryType == nil || s.QueryType.Name == nil {
+		return "", fmt.Errorf("missing query type")
+	}
+	p.buf.WriteString("schema {\n")
+	for _, root := range []struct {
+		op  string
+		ref *introspectionTypeRef
+	}{{"query", s.QueryType}, {"mutation", s.MutationType}, {"subscription", s.SubscriptionType}} {
+		if root.ref == nil {
+			continue
+		}
+		name, err := root.ref.String()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&p.buf, "  %s: %s\n", root.op, name)
+	}
+	p.buf.WriteString("}\n\n")
+
+	for _, d := range s.Directives {
+		if _, ok := builtins.Directives[d.Name]; ok {
+			continue
+		}
+		if err := p.introspectionDirective(d); err != nil {
+			return "", fmt.Errorf("directive %q: %s", d.Name, err)
+		}
+	}
+	for _, t := range s.Types {
+		if _, ok := builtins.Types[t.Name]; ok {
+			continue
+		}
+		if err := p.introspectionType(t); err != nil {
+			return "", fmt.Errorf("type %q: %s", t.Name, err)
+		}
+	}
+	return p.buf.String(), nil
+}
+
+func (p *printer) introspectionDirective(d *introspectionDirective) error {
+	if err := checkName(d.Name); err != nil {
+		return err
+	}
+	for _, loc := range d.Locations {
+		if err := checkName(loc); err != nil {
+			return err
+		}
+	}
+	if len(d.Locations) == 0 {
+		return fmt.Errorf("missing locations")
+	}
+
+	p.description(stringValue(d.Description), "")
+	fmt.Fprintf(&p.buf, "directive @%s", d.Name)
+	if err := p.introspectionArguments(d.Args, ""); err != nil {
+		return err
+	}
+	if d.IsRepeatable {
+		p.buf.WriteString(" repeatable")
+	}
+	fmt.Fprintf(&p.buf, " on %s\n\n", strings.Join(d.Locations, " | "))
+	return nil
+}
+
+func (p *printer) introspectionType(t *introspectionType) error {
+	if err := checkName(t.Name); err != nil {
+		return err
+	}
+	p.description(stringValue(t.Description), "")
+
+	switch t.Kind {
+	case "SCALAR":
+		fmt.Fprintf(&p.buf, "scalar %s", t.Name)
+		if t.SpecifiedByURL != nil {
+			fmt.Fprintf(&p.buf, " @specifiedBy(url: %s)", quote(*t.SpecifiedByURL))
+		}
+		p.buf.WriteString("\n")
+
+	case "OBJECT", "INTERFACE":
+		keyword := "type"
+		if t.Kind == "INTERFACE" {
+			keyword = "interface"
+		}
+		fmt.Fprintf(&p.buf, "%s %s", keyword, t.Name)
+		if len(t.Interfaces) != 0 {
+			names, err := typeRefs(t.Interfaces)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&p.buf, " implements %s", strings.Join(names, " & "))
+		}
+		if len(t.Fields) == 0 {
+			return fmt.Errorf("missing fields")
+		}
+		p.buf.WriteString(" {\n")
+		for _, f := range t.Fields {
+			if err := checkName(f.Name); err != nil {
+				return err
+			}
+			typ, err := f.Type.String()
+			if err != nil {
+				return fmt.Errorf("field %q: %s", f.Name, err)
+			}
+			p.description(stringValue(f.Description), "  ")
+			fmt.Fprintf(&p.buf, "  %s", f.Name)
+			if err := p.introspectionArguments(f.Args, "  "); err != nil {
+				return fmt.Errorf("field %q: %s", f.Name, err)
+			}
+			fmt.Fprintf(&p.buf, ": %s", typ)
+			p.deprecated(f.IsDeprecated, f.DeprecationReason)
+			p.buf.WriteString("\n")
+		}
+		p.buf.WriteString("}\n")
+
+	case "UNION":
+		names, err := typeRefs(t.PossibleTypes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&p.buf, "union %s", t.Name)
+		if len(names) != 0 {
+			fmt.Fprintf(&p.buf, " = %s", strings.Join(names, " | "))
+		}
+		p.buf.WriteString("\n")
+
+	case "ENUM":
+		fmt.Fprintf(&p.buf, "enum %s {\n", t.Name)
+		for _, v := range t.EnumValues {
+			if err := checkName(v.Name); err != nil {
+				return err
+			}
+			p.description(stringValue(v.Description), "  ")
+			fmt.Fprintf(&p.buf, "  %s", v.Name)
+			p.deprecated(v.IsDeprecated, v.DeprecationReason)
+			p.buf.WriteString("\n")
+		}
+		p.buf.WriteString("}\n")
+
+	case "INPUT_OBJECT":
+		fmt.Fprintf(&p.buf, "input %s", t.Name)
+		if t.IsOneOf != nil && *t.IsOneOf {
+			p.buf.WriteString(" @oneOf")
+		}
+		p.buf.WriteString(" {\n")
+		for _, v := range t.InputFields {
+			if err := p.introspectionInputValue(v, "  "); err != nil {
+				return err
+			}
+			p.buf.WriteString("\n")
+		}
+		p.buf.WriteString("}\n")
+
+	default:
+		return fmt.Errorf("invalid kind %q", t.Kind)
+	}
+	p.buf.WriteString("\n")
+	return nil
+}
+
+func (p *printer) introspectionArguments(args []*introspectionInputValue, indent string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	p.buf.WriteString("(\n")
+	for _, arg := range args {
+		if err := p.introspectionInputValue(arg, indent+"  "); err != nil {
+			return err
+		}
+		p.buf.WriteString("\n")
+	}
+	fmt.Fprintf(&p.buf, "%s)", indent)
+	return nil
+}
+
+func (p *printer) introspectionInputValue(v *introspectionInputValue, indent string) error {
+	if err := checkName(v.Name); err != nil {
+		return err
+	}
+	typ, err := v.Type.String()
+	if err != nil {
+		return fmt.Errorf("input value %q: %s", v.Name, err)
+	}
+	p.description(stringValue(v.Description), indent)
+	fmt.Fprintf(&p.buf, "%s%s: %s", indent, v.Name, typ)
+	if v.DefaultValue != nil {
+		if err := checkLiteral(*v.DefaultValue); err != nil {
+			return fmt.Errorf("default value of %q: %s", v.Name, err)
+		}
+		fmt.Fprintf(&p.buf, " = %s", *v.DefaultValue)
+	}
+	// the deprecation of arguments and input fields is not printed, @deprecated does not apply to them
+	return nil
+}
+
+// deprecated writes the @deprecated directive. The default reason is left out, like the
+// application of the directive without arguments it results from.
+func (p *printer) deprecated(isDeprecated bool, reason *string) {
+	if !isDeprecated {
+		return
+	}
+	p.buf.WriteString(" @deprecated")
+	if reason != nil && *reason != "No longer supported" {
+		fmt.Fprintf(&p.buf, "(reason: %s)", quote(*reason))
+	}
+}
+
+// checkLiteral reports whether s is a single constant GraphQL value.
+func checkLiteral(s string) error {
+	l := common.NewLexer(s, false)
+	if err := l.CatchSyntaxError(func() {
+		l.ConsumeWhitespace()
+		common.ParseLiteral(l, true)
+		l.ConsumeToken(scanner.EOF)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func typeRefs(refs []*introspectionTypeRef) ([]string, error) {
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		name, err := ref.String()
+		if err != nil {
+			return nil, err
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,156 @@
+package schema_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/example/starwars"
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+func TestFromIntrospection(t *testing.T) {
+	t.Run("rebuilds the schema of the introspection result", func(t *testing.T) {
+		data, err := ioutil.ReadFile("../example/starwars/introspect.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := schema.FromIntrospection(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := schema.ParseSchema(starwars.Schema, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts := schema.PrintOptions{Sort: true}
+		if g, w := schema.Print(got, opts), schema.Print(want, opts); g != w {
+			t.Fatalf("got:\n%s\nwant:\n%s", g, w)
+		}
+	})
+
+	t.Run("reads descriptions, deprecations, default values and specifiedByURL", func(t *testing.T) {
+		// deprecated arguments and input fields are left out, since @deprecated does not apply to them
+		s, err := schema.FromIntrospection([]byte(`{"data": {"__schema": {
+			"queryType": {"name": "Root"},
+			"directives": [{
+				"name": "tag",
+				"description": "Tags an element.",
+				"locations": ["FIELD_DEFINITION", "OBJECT"],
+				"args": [{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}],
+				"isRepeatable": true
+			}],
+			"types": [
+				{"kind": "OBJECT", "name": "Root", "description": "The root.\nQuery everything here.", "interfaces": [], "fields": [
+					{"name": "events", "args": [
+						{"name": "after", "type": {"kind": "SCALAR", "name": "DateTime"}, "defaultValue": null, "isDeprecated": true},
+						{"name": "order", "type": {"kind": "ENUM", "name": "Order"}, "defaultValue": "ASC"},
+						{"name": "filter", "type": {"kind": "INPUT_OBJECT", "name": "Filter"}, "defaultValue": "{tags: [\"a\"]}"}
+					], "type": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}},
+					{"name": "old", "args": [], "type": {"kind": "SCALAR", "name": "Int"}, "isDeprecated": true, "deprecationReason": "Use events."}
+				]},
+				{"kind": "SCALAR", "name": "DateTime", "specifiedByURL": "https://tools.ietf.org/html/rfc3339"},
+				{"kind": "ENUM", "name": "Order", "enumValues": [
+					{"name": "ASC", "description": "Ascending."},
+					{"name": "DESC", "isDeprecated": true, "deprecationReason": "No longer supported"}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "Filter", "inputFields": [
+					{"name": "tags", "type": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "String"}}, "isDeprecated": true, "deprecationReason": "Unused."}
+				]},
+				{"kind": "SCALAR", "name": "String"},
+				{"kind": "OBJECT", "name": "__Schema", "fields": []}
+			]
+		}}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := `schema {
+  query: Root
+}
+
+"Tags an element."
+directive @tag(name: String!) repeatable on FIELD_DEFINITION | OBJECT
+
+"""
+The root.
+Query everything here.
+"""
+type Root {
+  events(after: DateTime, order: Order = ASC, filter: Filter = {tags: ["a"]}): [String!]
+  old: Int @deprecated(reason: "Use events.")
+}
+
+scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+
+enum Order {
+  "Ascending."
+  ASC
+  DESC @deprecated
+}
+
+input Filter {
+  tags: [String]
+}
+`
+		if got := schema.Print(s, schema.PrintOptions{}); got != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	for _, test := range []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "invalid JSON",
+			data: `{`,
+			err:  "invalid introspection result: unexpected end of JSON input",
+		},
+		{
+			name: "missing __schema",
+			data: `{"data": {}}`,
+			err:  "invalid introspection result: missing __schema",
+		},
+		{
+			name: "missing query type",
+			data: `{"__schema": {"types": []}}`,
+			err:  "invalid introspection result: missing query type",
+		},
+		{
+			name: "invalid name",
+			data: `{"__schema": {"queryType": {"name": "Query"}, "types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [{"name": "a: Int } type B {", "type": {"kind": "SCALAR", "name": "Int"}}]}
+			]}}`,
+			err: `invalid introspection result: type "Query": invalid name "a: Int } type B {"`,
+		},
+		{
+			name: "invalid default value",
+			data: `{"__schema": {"queryType": {"name": "Query"}, "types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [{"name": "a", "args": [
+					{"name": "n", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "1) b: Int"}
+				], "type": {"kind": "SCALAR", "name": "Int"}}]}
+			]}}`,
+			err: `invalid introspection result: type "Query": field "a": default value of "n": graphql: syntax error: unexpected ")", expecting EOF`,
+		},
+		{
+			name: "unknown type",
+			data: `{"__schema": {"queryType": {"name": "Query"}, "types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [{"name": "a", "type": {"kind": "OBJECT", "name": "Missing"}}]}
+			]}}`,
+			err: `invalid introspection result: graphql: Unknown type "Missing".`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := schema.FromIntrospection([]byte(test.data))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), test.err) {
+				t.Fatalf("got error %q, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package schema
 
 import (
+	"text/scanner"
+
+	"github.com/graph-gophers/graphql-go/common"
 	"github.com/graph-gophers/graphql-go/types"
 )
 
@@ -65,6 +68,9 @@ var metaSrc = `
 		url: String!
 	) on SCALAR
 
+	# Indicates exactly one field must be supplied and this field must not be ` + "`" + `null` + "`" + `.
+	directive @oneOf on INPUT_OBJECT
+
 	# A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document.
 	#
 	# In some cases, you need to provide options to alter GraphQL's execution behavior
@@ -186,6 +192,7 @@ var metaSrc = `
 		inputFields: [__InputValue!]
 		ofType: __Type
 		specifiedByURL: String
+		isOneOf: Boolean
 	}
 
 	# An enum describing what kind of type a given ` + "`" + `__Type` + "`" + ` is.
@@ -212,3 +219,69 @@ var metaSrc = `
 		sdl: String!
 	}
 `
+
+// AddIncrementalDirectives adds the @defer and @stream directives of the incremental delivery RFC
+// to the schema. They are not part of the default meta schema since the RFC is not part of the
+// specification yet. It must be called before Parse, which resolves the argument types.
+func AddIncrementalDirectives(s *types.Schema) {
+	addDirectives(s, incrementalSrc)
+}
+
+// AddCostDirectives adds the @cost and @listSize directives used by the static cost analysis to
+// the schema. It must be called before Parse, which resolves the argument types.
+func AddCostDirectives(s *types.Schema) {
+	addDirectives(s, costSrc)
+}
+
+func addDirectives(s *types.Schema, src string) {
+	l := common.NewLexer(src, false)
+	err := l.CatchSyntaxError(func() {
+		l.ConsumeWhitespace()
+		for l.Peek() != scanner.EOF {
+			desc := l.DescComment()
+			l.ConsumeKeyword("directive")
+			d := parseDirectiveDef(l)
+			d.Desc = desc
+			s.Directives[d.Name] = d
+		}
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+var incrementalSrc = `
+	# Directs the executor to deliver this fragment after the initial response.
+	directive @defer(
+		# Deferred when true or undefined.
+		if: Boolean! = true
+		# Unique name identifying the deferred fragment in the subsequent responses.
+		label: String
+	) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+	# Directs the executor to deliver the items of this list field after the initial response.
+	directive @stream(
+		# Streamed when true or undefined.
+		if: Boolean! = true
+		# Unique name identifying the streamed list in the subsequent responses.
+		label: String
+		# Number of items to return in the initial response.
+		initialCount: Int = 0
+	) on FIELD
+`
+
+var costSrc = `
+	# The weight of a field, an argument or a type in the static cost analysis.
+	directive @cost(
+		# The cost added for each occurrence.
+		weight: Int!
+	) on FIELD_DEFINITION | ARGUMENT_DEFINITION | OBJECT | INTERFACE | UNION | ENUM | SCALAR
+
+	# The expected size of a list field in the static cost analysis.
+	directive @listSize(
+		# The size assumed if none of the slicing arguments is provided.
+		assumedSize: Int
+		# Names of the arguments that limit the size of the list.
+		slicingArguments: [String!]
+	) on FIELD_DEFINITION
+`
@@ -0,0 +1,399 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// PrintOptions configures Print.
+type PrintOptions struct {
+	// Sort prints the types, directives, fields, input fields and enum values sorted by name
+	// instead of in the order of their definition. Arguments keep their order.
+	Sort bool
+	// IncludeBuiltins prints the built-in scalars, directives and introspection types as well, and
+	// the directives added by options like @defer and @cost.
+	IncludeBuiltins bool
+	// Federation prints the SDL of an Apollo Federation subgraph as expected by the gateway. It
+	// omits the definitions added by the federation support, i.e. the federation directives, the
+	// _Any, _FieldSet and _Entity types and the _entities field, while their usages are kept.
+	Federation bool
+}
+
+// builtins is the schema with the built-in types and directives.
+var builtins = newMeta()
+
+// optionalBuiltins holds the directives which are added to the schema by options, e.g. @defer and
+// @cost. They are printed like built-ins, unless the schema defines its own version of them.
+var optionalBuiltins = func() *types.Schema {
+	s := &types.Schema{Directives: make(map[string]*types.DirectiveDefinition)}
+	AddIncrementalDirectives(s)
+	AddCostDirectives(s)
+	return s
+}()
+
+// isBuiltinDirective reports whether the directive definition is built in or added by an option.
+func isBuiltinDirective(d *types.DirectiveDefinition) bool {
+	if _, ok := builtins.Directives[d.Name]; ok {
+		return true
+	}
+	added, ok := optionalBuiltins.Directives[d.Name]
+	return ok && d.Loc == added.Loc && d.Desc == added.Desc
+}
+
+// Print returns the schema in the GraphQL schema definition language. Extensions are printed
+// merged into the types they extend. Descriptions are printed as strings, so the output must be
+// parsed with string descriptions enabled.
+func Print(s *types.Schema, opts PrintOptions) string {
+	p := &printer{schema: s, opts: opts}
+	if opts.Federation {
+		p.hiddenDirectives, p.hiddenTypes = federationDefinitions(s)
+	}
+
+	p.schemaDefinition(s)
+
+	var directives []*types.DirectiveDefinition
+	for name, d := range s.Directives {
+		if isBuiltinDirective(d) && !opts.IncludeBuiltins {
+			continue
+		}
+		if p.hiddenDirectives[name] {
+			continue
+		}
+		directives = append(directives, d)
+	}
+	sort.Slice(directives, func(i, j int) bool {
+		if !opts.Sort && directives[i].Loc != directives[j].Loc {
+			return directives[i].Loc.Before(directives[j].Loc)
+		}
+		return directives[i].Name < directives[j].Name
+	})
+	for _, d := range directives {
+		p.directiveDefinition(d)
+	}
+
+	var named []types.NamedType
+	for name, t := range s.Types {
+		if _, ok := builtins.Types[name]; ok && !opts.IncludeBuiltins {
+			continue
+		}
+		if p.hiddenTypes[name] {
+			continue
+		}
+		named = append(named, t)
+	}
+	sort.Slice(named, func(i, j int) bool {
+		if !opts.Sort {
+			li, lj := typeLoc(named[i]), typeLoc(named[j])
+			if li != lj {
+				return li.Before(lj)
+			}
+		}
+		return named[i].TypeName() < named[j].TypeName()
+	})
+	for _, t := range named {
+		p.namedType(t)
+	}
+
+	// the definitions are separated by blank lines
+	return strings.TrimSuffix(p.buf.String(), "\n")
+}
+
+type printer struct {
+	schema *types.Schema
+	opts   PrintOptions
+	buf    strings.Builder
+
+	// the federation definitions omitted with PrintOptions.Federation
+	hiddenDirectives map[string]bool
+	hiddenTypes      map[string]bool
+}
+
+func (p *printer) schemaDefinition(s *types.Schema) {
+	defaults := map[string]string{"query": "Query", "mutation": "Mutation", "subscription": "Subscription"}
+	custom := false
+	for op, t := range s.RootOperationTypes {
+		if t.TypeName() != defaults[op] {
+			custom = true
+		}
+	}
+	if !custom {
+		// the directives of a schema with the default root types are printed as an extension,
+		// e.g. the @link of a federation subgraph
+		if len(s.SchemaDirectives) != 0 {
+			p.buf.WriteString("extend schema")
+			p.directives(s.SchemaDirectives)
+			p.buf.WriteString("\n\n")
+		}
+		return
+	}
+
+	p.buf.WriteString("schema")
+	p.directives(s.SchemaDirectives)
+	p.buf.WriteString(" {\n")
+	for _, op := range []string{"query", "mutation", "subscription"} {
+		if t, ok := s.RootOperationTypes[op]; ok {
+			fmt.Fprintf(&p.buf, "  %s: %s\n", op, t.TypeName())
+		}
+	}
+	p.buf.WriteString("}\n\n")
+}
+
+func (p *printer) directiveDefinition(d *types.DirectiveDefinition) {
+	p.description(d.Desc, "")
+	fmt.Fprintf(&p.buf, "directive @%s", d.Name)
+	p.arguments(d.Arguments, "")
+	if d.Repeatable {
+		p.buf.WriteString(" repeatable")
+	}
+	fmt.Fprintf(&p.buf, " on %s\n\n", strings.Join(d.Locations, " | "))
+}
+
+func (p *printer) namedType(t types.NamedType) {
+	switch t := t.(type) {
+	case *types.ScalarTypeDefinition:
+		p.description(t.Desc, "")
+		fmt.Fprintf(&p.buf, "scalar %s", t.Name)
+		p.directives(t.Directives)
+		p.buf.WriteString("\n")
+
+	case *types.ObjectTypeDefinition:
+		p.description(t.Desc, "")
+		fmt.Fprintf(&p.buf, "type %s", t.Name)
+		p.implements(t.Interfaces)
+		p.directives(t.Directives)
+		p.fields(t.Fields)
+
+	case *types.InterfaceTypeDefinition:
+		p.description(t.Desc, "")
+		fmt.Fprintf(&p.buf, "interface %s", t.Name)
+		p.implements(t.Interfaces)
+		p.directives(t.Directives)
+		p.fields(t.Fields)
+
+	case *types.Union:
+		p.description(t.Desc, "")
+		fmt.Fprintf(&p.buf, "union %s", t.Name)
+		p.directives(t.Directives)
+		if len(t.UnionMemberTypes) != 0 {
+			names := make([]string, len(t.UnionMemberTypes))
+			for i, m := range t.UnionMemberTypes {
+				names[i] = m.Name
+			}
+			fmt.Fprintf(&p.buf, " = %s", strings.Join(names, " | "))
+		}
+		p.buf.WriteString("\n")
+
+	case *types.EnumTypeDefinition:
+		p.description(t.Desc, "")
+		fmt.Fprintf(&p.buf, "enum %s", t.Name)
+		p.directives(t.Directives)
+		values := append([]*types.EnumValueDefinition(nil), t.EnumValuesDefinition...)
+		if p.opts.Sort {
+			sort.Slice(values, func(i, j int) bool { return values[i].EnumValue < values[j].EnumValue })
+		}
+		p.block(len(values), func(i int) {
+			p.description(values[i].Desc, "  ")
+			fmt.Fprintf(&p.buf, "  %s", values[i].EnumValue)
+			p.directives(values[i].Directives)
+		})
+
+	case *types.InputObject:
+		p.description(t.Desc, "")
+		fmt.Fprintf(&p.buf, "input %s", t.Name)
+		p.directives(t.Directives)
+		values := append(types.ArgumentsDefinition(nil), t.Values...)
+		if p.opts.Sort {
+			sort.Slice(values, func(i, j int) bool { return values[i].Name.Name < values[j].Name.Name })
+		}
+		p.block(len(values), func(i int) {
+			p.inputValue(values[i], "  ")
+		})
+	}
+	p.buf.WriteString("\n")
+}
+
+func (p *printer) implements(interfaces []*types.InterfaceTypeDefinition) {
+	if len(interfaces) == 0 {
+		return
+	}
+	names := make([]string, len(interfaces))
+	for i, iface := range interfaces {
+		names[i] = iface.Name
+	}
+	fmt.Fprintf(&p.buf, " implements %s", strings.Join(names, " & "))
+}
+
+func (p *printer) fields(fields types.FieldsDefinition) {
+	fields = append(types.FieldsDefinition(nil), fields...)
+	if p.opts.Federation {
+		for i, f := range fields {
+			if f.Name == "_entities" && f.Type.String() == "[_Entity]!" {
+				fields = append(fields[:i], fields[i+1:]...)
+				break
+			}
+		}
+	}
+	if p.opts.Sort {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	}
+	p.block(len(fields), func(i int) {
+		f := fields[i]
+		p.description(f.Desc, "  ")
+		fmt.Fprintf(&p.buf, "  %s", f.Name)
+		p.arguments(f.Arguments, "  ")
+		fmt.Fprintf(&p.buf, ": %s", f.Type)
+		p.directives(f.Directives)
+	})
+}
+
+// block writes n lines in braces, or just ends the line if there are none.
+func (p *printer) block(n int, line func(i int)) {
+	if n == 0 {
+		p.buf.WriteString("\n")
+		return
+	}
+	p.buf.WriteString(" {\n")
+	for i := 0; i < n; i++ {
+		line(i)
+		p.buf.WriteString("\n")
+	}
+	p.buf.WriteString("}\n")
+}
+
+// arguments writes the arguments in a single line, or one per line if any of them has a description.
+func (p *printer) arguments(args types.ArgumentsDefinition, indent string) {
+	if len(args) == 0 {
+		return
+	}
+	multiline := false
+	for _, arg := range args {
+		if arg.Desc != "" {
+			multiline = true
+		}
+	}
+
+	if !multiline {
+		p.buf.WriteString("(")
+		for i, arg := range args {
+			if i > 0 {
+				p.buf.WriteString(", ")
+			}
+			p.inputValue(arg, "")
+		}
+		p.buf.WriteString(")")
+		return
+	}
+
+	p.buf.WriteString("(\n")
+	for _, arg := range args {
+		p.inputValue(arg, indent+"  ")
+		p.buf.WriteString("\n")
+	}
+	fmt.Fprintf(&p.buf, "%s)", indent)
+}
+
+func (p *printer) inputValue(v *types.InputValueDefinition, indent string) {
+	p.description(v.Desc, indent)
+	fmt.Fprintf(&p.buf, "%s%s: %s", indent, v.Name.Name, v.Type)
+	if v.Default != nil {
+		fmt.Fprintf(&p.buf, " = %s", v.Default)
+	}
+	p.directives(v.Directives)
+}
+
+func (p *printer) directives(directives types.DirectiveList) {
+	for _, d := range directives {
+		fmt.Fprintf(&p.buf, " @%s", d.Name.Name)
+		var args []string
+		for _, arg := range d.Arguments {
+			// skip the defaults filled in for arguments which are not provided, see resolveDirectives
+			if arg.Value == nil || p.isDefaultArgument(d, arg) {
+				continue
+			}
+			args = append(args, arg.Name.Name+": "+arg.Value.String())
+		}
+		if len(args) != 0 {
+			fmt.Fprintf(&p.buf, "(%s)", strings.Join(args, ", "))
+		}
+	}
+}
+
+func (p *printer) description(desc string, indent string) {
+	if desc == "" {
+		return
+	}
+	if !strings.Contains(desc, "\n") {
+		fmt.Fprintf(&p.buf, "%s%s\n", indent, quote(desc))
+		return
+	}
+	fmt.Fprintf(&p.buf, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(desc, "\n") {
+		if line == "" {
+			p.buf.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&p.buf, "%s%s\n", indent, strings.Replace(line, `"""`, `\"""`, -1))
+	}
+	fmt.Fprintf(&p.buf, "%s\"\"\"\n", indent)
+}
+
+// isDefaultArgument reports whether the argument of a directive is the default value of its
+// definition, which is shared by all applications of the directive.
+func (p *printer) isDefaultArgument(d *types.Directive, arg *types.Argument) bool {
+	def, ok := p.schema.Directives[d.Name.Name]
+	if !ok {
+		return false
+	}
+	argDef := def.Arguments.Get(arg.Name.Name)
+	return argDef != nil && argDef.Default == arg.Value
+}
+
+// quote returns the string as a GraphQL string value.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func typeLoc(t types.NamedType) errors.Location {
+	switch t := t.(type) {
+	case *types.ScalarTypeDefinition:
+		return t.Loc
+	case *types.ObjectTypeDefinition:
+		return t.Loc
+	case *types.InterfaceTypeDefinition:
+		return t.Loc
+	case *types.Union:
+		return t.Loc
+	case *types.EnumTypeDefinition:
+		return t.Loc
+	case *types.InputObject:
+		return t.Loc
+	}
+	return errors.Location{}
+}
@@ -0,0 +1,239 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+func TestPrint(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		sdl  string
+		opts schema.PrintOptions
+		want string
+	}{
+		{
+			name: "prints definitions in source order",
+			sdl: `
+				"""
+				The query type,
+				represents all of the "entry points".
+				"""
+				type Query {
+					"Returns the \"hero\"."
+					hero(episode: Episode = JEDI, first: Int = 10 @deprecated): Character @deprecated(reason: "Use search.")
+					search(
+						"The text to search for."
+						text: String!
+						filter: Filter = {kinds: [DROID], limit: 5}
+					): [SearchResult!]!
+					old: String @deprecated
+				}
+
+				enum Episode {
+					NEWHOPE
+					"Episode VI"
+					JEDI @deprecated(reason: "Never mind.")
+				}
+
+				interface Node { id: ID! }
+				interface Character implements Node { id: ID! name: String! }
+				type Droid implements Node & Character { id: ID! name: String! }
+				union SearchResult = Droid
+				input Filter { kinds: [Kind!] limit: Int = 10 }
+				enum Kind { DROID }
+				scalar Time @specifiedBy(url: "https://example.com/time")
+				directive @auth(role: String = "USER") repeatable on FIELD_DEFINITION | OBJECT
+			`,
+			want: `
+directive @auth(role: String = "USER") repeatable on FIELD_DEFINITION | OBJECT
+
+"""
+The query type,
+represents all of the "entry points".
+"""
+type Query {
+  "Returns the \"hero\"."
+  hero(episode: Episode = JEDI, first: Int = 10 @deprecated): Character @deprecated(reason: "Use search.")
+  search(
+    "The text to search for."
+    text: String!
+    filter: Filter = {kinds: [DROID], limit: 5}
+  ): [SearchResult!]!
+  old: String @deprecated
+}
+
+enum Episode {
+  NEWHOPE
+  "Episode VI"
+  JEDI @deprecated(reason: "Never mind.")
+}
+
+interface Node {
+  id: ID!
+}
+
+interface Character implements Node {
+  id: ID!
+  name: String!
+}
+
+type Droid implements Node & Character {
+  id: ID!
+  name: String!
+}
+
+union SearchResult = Droid
+
+input Filter {
+  kinds: [Kind!]
+  limit: Int = 10
+}
+
+enum Kind {
+  DROID
+}
+
+scalar Time @specifiedBy(url: "https://example.com/time")
+`,
+		},
+		{
+			name: "merges extensions and sorts",
+			sdl: `
+				schema { query: Root mutation: Mutations }
+				type Root { b: Int }
+				type Mutations { a: Int }
+				extend type Root { a: String }
+				enum Color { RED }
+				extend enum Color { BLUE }
+			`,
+			opts: schema.PrintOptions{Sort: true},
+			want: `
+schema {
+  query: Root
+  mutation: Mutations
+}
+
+enum Color {
+  BLUE
+  RED
+}
+
+type Mutations {
+  a: Int
+}
+
+type Root {
+  a: String
+  b: Int
+}
+`,
+		},
+		{
+			name: "prints schema directives",
+			sdl: `
+				directive @contact(name: String!) repeatable on SCHEMA
+				extend schema @contact(name: "team")
+				schema @contact(name: "other") { query: Root }
+				type Root { a: Int }
+			`,
+			want: `
+schema @contact(name: "team") @contact(name: "other") {
+  query: Root
+}
+
+directive @contact(name: String!) repeatable on SCHEMA
+
+type Root {
+  a: Int
+}
+`,
+		},
+		{
+			name: "prints schema directives of the default root types as extension",
+			sdl: `
+				directive @contact(name: String!) on SCHEMA
+				extend schema @contact(name: "team")
+				type Query { a: Int }
+			`,
+			want: `
+extend schema @contact(name: "team")
+
+directive @contact(name: String!) on SCHEMA
+
+type Query {
+  a: Int
+}
+`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := schema.New()
+			if err := schema.Parse(s, test.sdl, true); err != nil {
+				t.Fatal(err)
+			}
+			got := schema.Print(s, test.opts)
+			want := strings.TrimPrefix(test.want, "\n")
+			if got != want {
+				t.Fatalf("unexpected SDL\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			// the printed schema parses to the same schema
+			reparsed := schema.New()
+			if err := schema.Parse(reparsed, got, true); err != nil {
+				t.Fatalf("printed SDL does not parse: %s", err)
+			}
+			if again := schema.Print(reparsed, test.opts); again != got {
+				t.Fatalf("printed SDL is not stable\ngot:\n%s\nwant:\n%s", again, got)
+			}
+		})
+	}
+}
+
+func TestPrintBuiltins(t *testing.T) {
+	s := schema.New()
+	if err := schema.Parse(s, "type Query { a: Int }", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.Print(s, schema.PrintOptions{}); got != "type Query {\n  a: Int\n}\n" {
+		t.Errorf("unexpected SDL without built-ins:\n%s", got)
+	}
+
+	got := schema.Print(s, schema.PrintOptions{IncludeBuiltins: true, Sort: true})
+	for _, want := range []string{"scalar Boolean", "directive @skip(", "type __Schema {", "  types: [__Type!]!"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SDL with built-ins does not contain %q", want)
+		}
+	}
+}
+
+func TestPrintOptionalBuiltins(t *testing.T) {
+	s := schema.New()
+	schema.AddIncrementalDirectives(s)
+	schema.AddCostDirectives(s)
+	if err := schema.Parse(s, "type Query { a: [Int] @listSize(assumedSize: 10) }", false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := schema.Print(s, schema.PrintOptions{}), "type Query {\n  a: [Int] @listSize(assumedSize: 10)\n}\n"; got != want {
+		t.Errorf("unexpected SDL without built-ins\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got := schema.Print(s, schema.PrintOptions{IncludeBuiltins: true, Sort: true})
+	for _, want := range []string{"directive @defer(", "directive @stream(", "directive @cost(", "directive @listSize("} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SDL with built-ins does not contain %q", want)
+		}
+	}
+
+	// a directive of the schema replacing an added one is printed
+	s = schema.New()
+	schema.AddCostDirectives(s)
+	if err := schema.Parse(s, "directive @cost(weight: String!) on FIELD_DEFINITION\ntype Query { a: Int }", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.Print(s, schema.PrintOptions{}); !strings.Contains(got, "directive @cost(weight: String!) on FIELD_DEFINITION") {
+		t.Errorf("SDL does not contain the @cost directive of the schema:\n%s", got)
+	}
+}
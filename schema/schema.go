@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"strings"
 	"text/scanner"
 
 	"github.com/graph-gophers/graphql-go/common"
@@ -32,7 +33,88 @@ func Parse(s *types.Schema, schemaString string, useStringDescriptions bool) err
 	if err != nil {
 		return err
 	}
+	if err := resolveSchema(s); err != nil {
+		return err
+	}
+
+	s.SchemaString = schemaString
+
+	return nil
+}
+
+// Source is a named part of a schema definition, usually the contents of a file.
+type Source struct {
+	Name string
+	Body string
+}
+
+// ParseSources parses a schema which is split into several sources. Each source is parsed
+// separately, the types may be extended in any of the sources. The locations of the definitions
+// and of the errors include the name of the source.
+func ParseSources(s *types.Schema, sources []Source, useStringDescriptions bool) error {
+	bodies := make([]string, len(sources))
+	for i, src := range sources {
+		part := &types.Schema{
+			EntryPointNames: make(map[string]string),
+			Types:           make(map[string]types.NamedType),
+			Directives:      make(map[string]*types.DirectiveDefinition),
+		}
+		l := common.NewLexer(src.Body, useStringDescriptions)
+		l.SetFilename(src.Name)
+		if err := l.CatchSyntaxError(func() { parseSchema(part, l) }); err != nil {
+			return err
+		}
+		if err := addSource(s, part); err != nil {
+			return err
+		}
+		bodies[i] = src.Body
+	}
+	if err := resolveSchema(s); err != nil {
+		return err
+	}
+
+	s.SchemaString = strings.Join(bodies, "\n")
+
+	return nil
+}
 
+// addSource adds the definitions parsed from a single source to the schema. Definitions may not be
+// repeated in another source, but they may replace the built-in ones.
+func addSource(s *types.Schema, part *types.Schema) error {
+	for name, t := range part.Types {
+		if prev, ok := s.Types[name]; ok && typeLoc(prev).File != "" {
+			err := errors.Errorf("type %q is already defined in %s", name, typeLoc(prev).File)
+			err.Locations = []errors.Location{typeLoc(t)}
+			return err
+		}
+		s.Types[name] = t
+	}
+	for name, d := range part.Directives {
+		if prev, ok := s.Directives[name]; ok && prev.Loc.File != "" {
+			err := errors.Errorf("directive %q is already defined in %s", name, prev.Loc.File)
+			err.Locations = []errors.Location{d.Loc}
+			return err
+		}
+		s.Directives[name] = d
+	}
+	for op, name := range part.EntryPointNames {
+		if _, ok := s.EntryPointNames[op]; ok {
+			return errors.Errorf("the %s root type is defined in more than one source", op)
+		}
+		s.EntryPointNames[op] = name
+	}
+	s.Objects = append(s.Objects, part.Objects...)
+	s.Unions = append(s.Unions, part.Unions...)
+	s.Enums = append(s.Enums, part.Enums...)
+	s.Extensions = append(s.Extensions, part.Extensions...)
+	s.SchemaDirectives = append(s.SchemaDirectives, part.SchemaDirectives...)
+	return nil
+}
+
+func resolveSchema(s *types.Schema) error {
+	if err := applyLinks(s); err != nil {
+		return err
+	}
 	if err := mergeExtensions(s); err != nil {
 		return err
 	}
@@ -51,6 +133,9 @@ func Parse(s *types.Schema, schemaString string, useStringDescriptions bool) err
 			arg.Type = t
 		}
 	}
+	if err := resolveDirectives(s, s.SchemaDirectives, "SCHEMA"); err != nil {
+		return err
+	}
 
 	// https://graphql.github.io/graphql-spec/June2018/#sec-Root-Operation-Types
 	// > While any type can be the root operation type for a GraphQL operation, the type system definition language can
@@ -160,9 +245,6 @@ func Parse(s *types.Schema, schemaString string, useStringDescriptions bool) err
 			}
 		}
 	}
-
-	s.SchemaString = schemaString
-
 	return nil
 }
 
@@ -174,83 +256,93 @@ func ParseSchema(schemaString string, useStringDescriptions bool) (*types.Schema
 
 func mergeExtensions(s *types.Schema) error {
 	for _, ext := range s.Extensions {
-		typ := s.Types[ext.Type.TypeName()]
-		if typ == nil {
-			return fmt.Errorf("trying to extend unknown type %q", ext.Type.TypeName())
+		if err := mergeExtension(s, ext); err != nil {
+			// the extensions of a schema parsed from several sources are reported with their location
+			if ext.Loc.File != "" {
+				return &errors.QueryError{Err: err, Message: err.Error(), Locations: []errors.Location{ext.Loc}}
+			}
+			return err
 		}
+	}
+	return nil
+}
 
-		if typ.Kind() != ext.Type.Kind() {
-			return fmt.Errorf("trying to extend type %q with type %q", typ.Kind(), ext.Type.Kind())
-		}
+func mergeExtension(s *types.Schema, ext *types.Extension) error {
+	typ := s.Types[ext.Type.TypeName()]
+	if typ == nil {
+		return fmt.Errorf("trying to extend unknown type %q", ext.Type.TypeName())
+	}
 
-		switch og := typ.(type) {
-		case *types.ObjectTypeDefinition:
-			e := ext.Type.(*types.ObjectTypeDefinition)
+	if typ.Kind() != ext.Type.Kind() {
+		return fmt.Errorf("trying to extend type %q with type %q", typ.Kind(), ext.Type.Kind())
+	}
 
-			for _, field := range e.Fields {
-				if og.Fields.Get(field.Name) != nil {
-					return fmt.Errorf("extended field %q already exists", field.Name)
-				}
+	switch og := typ.(type) {
+	case *types.ObjectTypeDefinition:
+		e := ext.Type.(*types.ObjectTypeDefinition)
+
+		for _, field := range e.Fields {
+			if og.Fields.Get(field.Name) != nil {
+				return fmt.Errorf("extended field %q already exists", field.Name)
 			}
-			og.Fields = append(og.Fields, e.Fields...)
+		}
+		og.Fields = append(og.Fields, e.Fields...)
 
-			for _, en := range e.InterfaceNames {
-				for _, on := range og.InterfaceNames {
-					if on == en {
-						return fmt.Errorf("interface %q implemented in the extension is already implemented in %q", on, og.Name)
-					}
+		for _, en := range e.InterfaceNames {
+			for _, on := range og.InterfaceNames {
+				if on == en {
+					return fmt.Errorf("interface %q implemented in the extension is already implemented in %q", on, og.Name)
 				}
 			}
-			og.InterfaceNames = append(og.InterfaceNames, e.InterfaceNames...)
+		}
+		og.InterfaceNames = append(og.InterfaceNames, e.InterfaceNames...)
 
-		case *types.InputObject:
-			e := ext.Type.(*types.InputObject)
+	case *types.InputObject:
+		e := ext.Type.(*types.InputObject)
 
-			for _, field := range e.Values {
-				if og.Values.Get(field.Name.Name) != nil {
-					return fmt.Errorf("extended field %q already exists", field.Name)
-				}
+		for _, field := range e.Values {
+			if og.Values.Get(field.Name.Name) != nil {
+				return fmt.Errorf("extended field %q already exists", field.Name.Name)
 			}
-			og.Values = append(og.Values, e.Values...)
+		}
+		og.Values = append(og.Values, e.Values...)
 
-		case *types.InterfaceTypeDefinition:
-			e := ext.Type.(*types.InterfaceTypeDefinition)
+	case *types.InterfaceTypeDefinition:
+		e := ext.Type.(*types.InterfaceTypeDefinition)
 
-			for _, field := range e.Fields {
-				if og.Fields.Get(field.Name) != nil {
-					return fmt.Errorf("extended field %s already exists", field.Name)
-				}
+		for _, field := range e.Fields {
+			if og.Fields.Get(field.Name) != nil {
+				return fmt.Errorf("extended field %s already exists", field.Name)
 			}
-			og.Fields = append(og.Fields, e.Fields...)
+		}
+		og.Fields = append(og.Fields, e.Fields...)
 
-		case *types.Union:
-			e := ext.Type.(*types.Union)
+	case *types.Union:
+		e := ext.Type.(*types.Union)
 
-			for _, en := range e.TypeNames {
-				for _, on := range og.TypeNames {
-					if on == en {
-						return fmt.Errorf("union type %q already declared in %q", on, og.Name)
-					}
+		for _, en := range e.TypeNames {
+			for _, on := range og.TypeNames {
+				if on == en {
+					return fmt.Errorf("union type %q already declared in %q", on, og.Name)
 				}
 			}
-			og.TypeNames = append(og.TypeNames, e.TypeNames...)
+		}
+		og.TypeNames = append(og.TypeNames, e.TypeNames...)
 
-		case *types.EnumTypeDefinition:
-			e := ext.Type.(*types.EnumTypeDefinition)
+	case *types.EnumTypeDefinition:
+		e := ext.Type.(*types.EnumTypeDefinition)
 
-			for _, en := range e.EnumValuesDefinition {
-				for _, on := range og.EnumValuesDefinition {
-					if on.EnumValue == en.EnumValue {
-						return fmt.Errorf("enum value %q already declared in %q", on.EnumValue, og.Name)
-					}
+		for _, en := range e.EnumValuesDefinition {
+			for _, on := range og.EnumValuesDefinition {
+				if on.EnumValue == en.EnumValue {
+					return fmt.Errorf("enum value %q already declared in %q", on.EnumValue, og.Name)
 				}
 			}
-			og.EnumValuesDefinition = append(og.EnumValuesDefinition, e.EnumValuesDefinition...)
-		default:
-			return fmt.Errorf(`unexpected %q, expecting "schema", "type", "enum", "interface", "union" or "input"`, og.TypeName())
 		}
+		og.EnumValuesDefinition = append(og.EnumValuesDefinition, e.EnumValuesDefinition...)
+	default:
+		return fmt.Errorf(`unexpected %q, expecting "schema", "type", "enum", "interface", "union" or "input"`, og.TypeName())
 	}
-
 	return nil
 }
 
@@ -278,6 +370,16 @@ func resolveNamedType(s *types.Schema, t types.NamedType) error {
 		if err := resolveDirectives(s, t.Directives, "INPUT_OBJECT"); err != nil {
 			return err
 		}
+		if t.IsOneOf() {
+			for _, v := range t.Values {
+				if _, ok := v.Type.(*types.NonNull); ok {
+					return inputValueError(v, "field %q of oneOf input object %q must be nullable", v.Name.Name, t.Name)
+				}
+				if v.Default != nil {
+					return inputValueError(v, "field %q of oneOf input object %q can not have a default value", v.Name.Name, t.Name)
+				}
+			}
+		}
 	case *types.ScalarTypeDefinition:
 		if err := resolveDirectives(s, t.Directives, "SCALAR"); err != nil {
 			return err
@@ -304,7 +406,7 @@ func resolveDirectives(s *types.Schema, directives types.DirectiveList, loc stri
 		dirName := d.Name.Name
 		dd, ok := s.Directives[dirName]
 		if !ok {
-			return errors.Errorf("directive %q not found", dirName)
+			return directiveError(d, "directive %q not found", dirName)
 		}
 		validLoc := false
 		for _, l := range dd.Locations {
@@ -314,11 +416,11 @@ func resolveDirectives(s *types.Schema, directives types.DirectiveList, loc stri
 			}
 		}
 		if !validLoc {
-			return errors.Errorf("invalid location %q for directive %q (must be one of %v)", loc, dirName, dd.Locations)
+			return directiveError(d, "invalid location %q for directive %q (must be one of %v)", loc, dirName, dd.Locations)
 		}
 		for _, arg := range d.Arguments {
 			if dd.Arguments.Get(arg.Name.Name) == nil {
-				return errors.Errorf("invalid argument %q for directive %q", arg.Name.Name, dirName)
+				return directiveError(d, "invalid argument %q for directive %q", arg.Name.Name, dirName)
 			}
 		}
 		for _, arg := range dd.Arguments {
@@ -331,13 +433,25 @@ func resolveDirectives(s *types.Schema, directives types.DirectiveList, loc stri
 			continue
 		}
 		if _, seen := alreadySeenNonRepeatable[dirName]; seen {
-			return errors.Errorf(`non repeatable directive %q can not be repeated. Consider adding "repeatable".`, dirName)
+			return directiveError(d, `non repeatable directive %q can not be repeated. Consider adding "repeatable".`, dirName)
 		}
 		alreadySeenNonRepeatable[dirName] = struct{}{}
 	}
 	return nil
 }
 
+func directiveError(d *types.Directive, format string, a ...interface{}) error {
+	err := errors.Errorf(format, a...)
+	err.Locations = []errors.Location{d.Name.Loc}
+	return err
+}
+
+func inputValueError(v *types.InputValueDefinition, format string, a ...interface{}) error {
+	err := errors.Errorf(format, a...)
+	err.Locations = []errors.Location{v.Loc}
+	return err
+}
+
 func resolveInputObject(s *types.Schema, values types.ArgumentsDefinition) error {
 	for _, v := range values {
 		t, err := common.ResolveType(v.Type, s.Resolve)
@@ -362,6 +476,7 @@ func parseSchema(s *types.Schema, l *common.Lexer) {
 		switch x := l.ConsumeIdent(); x {
 
 		case "schema":
+			s.SchemaDirectives = append(s.SchemaDirectives, common.ParseDirectives(l)...)
 			l.ConsumeToken('{')
 			for l.Peek() != '}' {
 
@@ -565,6 +680,11 @@ func parseExtension(s *types.Schema, l *common.Lexer) {
 	loc := l.Location()
 	switch x := l.ConsumeIdent(); x {
 	case "schema":
+		// the extension may only add directives, e.g. "extend schema @link(...)"
+		s.SchemaDirectives = append(s.SchemaDirectives, common.ParseDirectives(l)...)
+		if l.Peek() != '{' {
+			return
+		}
 		l.ConsumeToken('{')
 		for l.Peek() != '}' {
 			name := l.ConsumeIdent()
@@ -2,9 +2,11 @@ package schema_test
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/schema"
 	"github.com/graph-gophers/graphql-go/types"
 )
@@ -707,7 +709,7 @@ Second line of the description.
 				name: String!
 			}`,
 			validateError: func(err error) error {
-				msg := `extended field {"name" {'\x06' '\x05'}} already exists`
+				msg := `extended field "name" already exists`
 				if err == nil || err.Error() != msg {
 					return fmt.Errorf("expected error %q, but got %q", msg, err)
 				}
@@ -990,6 +992,57 @@ Second line of the description.
 				return nil
 			},
 		},
+		{
+			name: "Parses oneOf input object",
+			sdl: `
+			input UserBy @oneOf {
+				id: ID
+				email: String
+			}
+			`,
+			validateSchema: func(s *types.Schema) error {
+				typ, ok := s.Types["UserBy"].(*types.InputObject)
+				if !ok {
+					return fmt.Errorf("input %q not found", "UserBy")
+				}
+				if !typ.IsOneOf() {
+					return fmt.Errorf("expected %q to be a oneOf input object", typ.Name)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Disallows non-null fields of oneOf input objects",
+			sdl: `
+			input UserBy @oneOf {
+				id: ID!
+				email: String
+			}
+			`,
+			validateError: func(err error) error {
+				msg := `graphql: field "id" of oneOf input object "UserBy" must be nullable (line 3, column 5)`
+				if err == nil || err.Error() != msg {
+					return fmt.Errorf("expected error %q, but got %q", msg, err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Disallows default values of oneOf input objects",
+			sdl: `
+			input UserBy @oneOf {
+				id: ID
+				email: String = "a@example.com"
+			}
+			`,
+			validateError: func(err error) error {
+				msg := `graphql: field "email" of oneOf input object "UserBy" can not have a default value (line 4, column 5)`
+				if err == nil || err.Error() != msg {
+					return fmt.Errorf("expected error %q, but got %q", msg, err)
+				}
+				return nil
+			},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			s, err := schema.ParseSchema(test.sdl, test.useStringDescriptions)
@@ -1143,3 +1196,108 @@ func TestInterfaceImplementsInterface(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSources(t *testing.T) {
+	t.Run("merges the definitions and extensions of all sources", func(t *testing.T) {
+		s := schema.New()
+		err := schema.ParseSources(s, []schema.Source{
+			{Name: "query.graphql", Body: `
+				type Query {
+					user(id: ID!): User
+				}
+			`},
+			{Name: "user.graphql", Body: `
+				type User {
+					id: ID!
+				}
+
+				extend type Query {
+					viewer: User
+				}
+			`},
+			{Name: "user_name.graphql", Body: `
+				extend type User {
+					name: String
+				}
+			`},
+		}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query := s.Types["Query"].(*types.ObjectTypeDefinition)
+		if got := query.Fields.Names(); !reflect.DeepEqual(got, []string{"user", "viewer"}) {
+			t.Errorf("got Query fields %v", got)
+		}
+		user := s.Types["User"].(*types.ObjectTypeDefinition)
+		if got := user.Fields.Names(); !reflect.DeepEqual(got, []string{"id", "name"}) {
+			t.Errorf("got User fields %v", got)
+		}
+		if got, want := user.Loc, (errors.Location{File: "user.graphql", Line: 2, Column: 10}); got != want {
+			t.Errorf("got location %v, want %v", got, want)
+		}
+		if s.RootOperationTypes["query"] != query {
+			t.Error("Query is not the query root type")
+		}
+	})
+
+	for _, test := range []struct {
+		name    string
+		sources []schema.Source
+		err     string
+	}{
+		{
+			name: "syntax error",
+			sources: []schema.Source{
+				{Name: "a.graphql", Body: `type Query { a: Int }`},
+				{Name: "b.graphql", Body: "type B {\n  b Int\n}"},
+			},
+			err: `graphql: syntax error: unexpected "Int", expecting ":" (b.graphql, line 2, column 5)`,
+		},
+		{
+			name: "unknown type",
+			sources: []schema.Source{
+				{Name: "a.graphql", Body: `type Query { a: Missing }`},
+			},
+			err: `graphql: Unknown type "Missing". (a.graphql, line 1, column 17)`,
+		},
+		{
+			name: "type defined twice",
+			sources: []schema.Source{
+				{Name: "a.graphql", Body: `type Query { a: Int }`},
+				{Name: "b.graphql", Body: "\ntype Query { b: Int }"},
+			},
+			err: `graphql: type "Query" is already defined in a.graphql (b.graphql, line 2, column 6)`,
+		},
+		{
+			name: "directive defined twice",
+			sources: []schema.Source{
+				{Name: "a.graphql", Body: `directive @d on FIELD type Query { a: Int }`},
+				{Name: "b.graphql", Body: `directive @d on FIELD`},
+			},
+			err: `graphql: directive "d" is already defined in a.graphql (b.graphql, line 1, column 12)`,
+		},
+		{
+			name: "invalid extension",
+			sources: []schema.Source{
+				{Name: "a.graphql", Body: `type Query { a: Int }`},
+				{Name: "b.graphql", Body: `extend type Query { a: Int }`},
+			},
+			err: `graphql: extended field "a" already exists (b.graphql, line 1, column 8)`,
+		},
+		{
+			name: "unknown directive",
+			sources: []schema.Source{
+				{Name: "a.graphql", Body: `type Query { a: Int @unknown }`},
+			},
+			err: `graphql: directive "unknown" not found (a.graphql, line 1, column 21)`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := schema.ParseSources(schema.New(), test.sources, false)
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("got error %q, want %q", err, test.err)
+			}
+		})
+	}
+}
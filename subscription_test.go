@@ -573,3 +573,35 @@ func TestSchemaSubscribe_PanicInResolver(t *testing.T) {
 		},
 	})
 }
+
+type subscriptionsComplexity struct{}
+
+func (r *subscriptionsComplexity) Users(args struct{ First *int32 }) <-chan []*helloWorldResolver1 {
+	c := make(chan []*helloWorldResolver1, 1)
+	c <- []*helloWorldResolver1{{}}
+	close(c)
+	return c
+}
+
+func TestSchemaSubscribe_MaxComplexityWithVariableDefault(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {}
+		type Subscription {
+			users(first: Int): [User!]! @listSize(slicingArguments: ["first"], assumedSize: 1)
+		}
+
+		type User @cost(weight: 2) {
+			hello: String!
+		}
+	`, &subscriptionsComplexity{}, graphql.MaxComplexity(50))
+
+	// the cost is computed with the default of the variable, not with the assumed size
+	c, err := schema.Subscribe(context.Background(), `subscription($n: Int = 1000) { users(first: $n) { hello } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := (<-c).(*graphql.Response)
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "The operation cost 2000 exceeds the maximum cost of 50." {
+		t.Fatalf("expected the operation to exceed the maximum cost, got %v", resp.Errors)
+	}
+}
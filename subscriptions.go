@@ -30,14 +30,7 @@ func (s *Schema) Subscribe(ctx context.Context, queryString string, operationNam
 }
 
 func (s *Schema) subscribe(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema) <-chan interface{} {
-	doc, qErr := query.Parse(queryString)
-	if qErr != nil {
-		return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{qErr}})
-	}
-
-	validationFinish := s.validationTracer.TraceValidation(ctx)
-	errs := validation.Validate(s.schema, doc, variables, s.maxDepth)
-	validationFinish(errs)
+	doc, errs := s.parseAndValidate(ctx, queryString, variables)
 	if len(errs) != 0 {
 		return sendAndReturnClosed(&Response{Errors: errs})
 	}
@@ -47,6 +40,14 @@ func (s *Schema) subscribe(ctx context.Context, queryString string, operationNam
 		return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
 	}
 
+	variables = applyVariableDefaults(op, variables)
+	if s.maxComplexity > 0 {
+		cost, errs := validation.ValidateComplexity(s.schema, doc, op, variables, s.maxComplexity)
+		if len(errs) != 0 {
+			return sendAndReturnClosed(&Response{Errors: errs, Extensions: s.costExtensions(cost)})
+		}
+	}
+
 	r := &exec.Request{
 		Request: selected.Request{
 			Doc:    doc,
@@ -58,6 +59,8 @@ func (s *Schema) subscribe(ctx context.Context, queryString string, operationNam
 		Logger:                   s.logger,
 		PanicHandler:             s.panicHandler,
 		SubscribeResolverTimeout: s.subscribeResolverTimeout,
+		FieldMiddleware:          s.fieldMiddleware,
+		MiddlewareFields:         s.middlewareFields,
 	}
 	varTypes := make(map[string]*introspection.Type)
 	for _, v := range op.Vars {
@@ -45,3 +45,7 @@ func (*InputObject) Kind() string          { return "INPUT_OBJECT" }
 func (t *InputObject) String() string      { return t.Name }
 func (t *InputObject) TypeName() string    { return t.Name }
 func (t *InputObject) Description() string { return t.Desc }
+
+// IsOneOf reports whether the input object has the @oneOf directive, i.e. exactly one of its fields
+// must be given a non-null value.
+func (t *InputObject) IsOneOf() bool { return t.Directives.Get("oneOf") != nil }
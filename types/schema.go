@@ -28,6 +28,11 @@ type Schema struct {
 	// http://spec.graphql.org/#sec-Type-System.Directives
 	Directives map[string]*DirectiveDefinition
 
+	// SchemaDirectives are the directives applied to the schema definition and its extensions.
+	//
+	// http://spec.graphql.org/draft/#sec-Schema
+	SchemaDirectives DirectiveList
+
 	UseFieldResolvers bool
 
 	EntryPointNames map[string]string
@@ -0,0 +1,44 @@
+package graphql
+
+import (
+	"fmt"
+	"io"
+)
+
+// Upload is a custom GraphQL type for a file uploaded with a multipart request, see
+// https://github.com/jaydenseric/graphql-multipart-request-spec. It has to be added to a schema via
+// "scalar Upload". The relay.Handler sets the uploaded files as the values of the variables, files
+// can not be passed as literals in the query.
+type Upload struct {
+	// Filename is the name of the file on the client.
+	Filename string
+	// ContentType is the content type of the file as sent by the client.
+	ContentType string
+	// Size is the size of the file in bytes.
+	Size int64
+	// File reads the content of the file. It is only valid during the request.
+	File io.Reader
+}
+
+// ImplementsGraphQLType maps this custom Go type
+// to the graphql scalar type in the schema.
+func (Upload) ImplementsGraphQLType(name string) bool {
+	return name == "Upload"
+}
+
+// UnmarshalGraphQL is a custom unmarshaler for Upload
+//
+// This function will be called whenever you use the
+// Upload scalar as an input
+func (u *Upload) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case Upload:
+		*u = input
+		return nil
+	case *Upload:
+		*u = *input
+		return nil
+	default:
+		return fmt.Errorf("wrong type for Upload: %T, files must be uploaded as variables of a multipart request", input)
+	}
+}
@@ -0,0 +1,41 @@
+package graphql_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/decode"
+)
+
+func TestUpload_ImplementsUnmarshaler(t *testing.T) {
+	// assert *Upload implements decode.Unmarshaler interface
+	var _ decode.Unmarshaler = (*Upload)(nil)
+
+	u := &Upload{}
+	if u.ImplementsGraphQLType("String") {
+		t.Error("Type *Upload must not claim to implement GraphQL type 'String'")
+	}
+	if !u.ImplementsGraphQLType("Upload") {
+		t.Error("Failed asserting *Upload implements GraphQL type Upload")
+	}
+}
+
+func TestUpload_UnmarshalGraphQL(t *testing.T) {
+	want := Upload{Filename: "a.txt", ContentType: "text/plain", Size: 5, File: strings.NewReader("hello")}
+
+	for _, input := range []interface{}{want, &want} {
+		var got Upload
+		if err := got.UnmarshalGraphQL(input); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+
+	var u Upload
+	if err := u.UnmarshalGraphQL("a.txt"); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// ValidateComplexity computes the static cost of the operation and reports an error if it exceeds
+// maxComplexity. A maxComplexity of 0 disables the check.
+func ValidateComplexity(s *types.Schema, doc *types.ExecutableDefinition, op *types.OperationDefinition, variables map[string]interface{}, maxComplexity int) (int, []*errors.QueryError) {
+	cost := Complexity(s, doc, op, variables)
+	if maxComplexity > 0 && cost > maxComplexity {
+		return cost, []*errors.QueryError{{
+			Message:   fmt.Sprintf("The operation cost %d exceeds the maximum cost of %d.", cost, maxComplexity),
+			Locations: []errors.Location{op.Loc},
+			Rule:      "MaxComplexity",
+		}}
+	}
+	return cost, nil
+}
+
+// Complexity computes the static cost of the operation as follows:
+//
+//   - Every object, interface or union value costs 1, leaf values cost 0. The @cost directive on
+//     a type overrides its weight.
+//   - The @cost directive on a field or on a provided argument adds its weight to the field.
+//   - The values of list fields are multiplied by the largest slicing argument of their @listSize
+//     directive, or by its assumedSize if none is provided and none has a default value.
+//
+// Negative weights and sizes count as 0.
+//
+// Fragments are counted as if all of them apply, so the result is an upper bound.
+func Complexity(s *types.Schema, doc *types.ExecutableDefinition, op *types.OperationDefinition, variables map[string]interface{}) int {
+	var entryPoint types.NamedType
+	switch op.Type {
+	case query.Query:
+		entryPoint = s.RootOperationTypes["query"]
+	case query.Mutation:
+		entryPoint = s.RootOperationTypes["mutation"]
+	case query.Subscription:
+		entryPoint = s.RootOperationTypes["subscription"]
+	}
+	c := &costContext{
+		schema:   s,
+		doc:      doc,
+		vars:     variables,
+		visiting: make(map[string]struct{}),
+	}
+	return c.selectionSetCost(op.Selections, entryPoint)
+}
+
+type costContext struct {
+	schema   *types.Schema
+	doc      *types.ExecutableDefinition
+	vars     map[string]interface{}
+	visiting map[string]struct{}
+}
+
+func (c *costContext) selectionSetCost(sels []types.Selection, t types.NamedType) int {
+	cost := 0
+	for _, sel := range sels {
+		cost = addCost(cost, c.selectionCost(sel, t))
+	}
+	return cost
+}
+
+func (c *costContext) selectionCost(sel types.Selection, t types.NamedType) int {
+	switch sel := sel.(type) {
+	case *types.Field:
+		f := fields(t).Get(sel.Name.Name)
+		if f == nil {
+			return 0 // meta fields and unknown fields
+		}
+		return c.fieldCost(sel, f)
+
+	case *types.InlineFragment:
+		if sel.On.Name != "" {
+			t = c.schema.Types[sel.On.Name]
+		}
+		return c.selectionSetCost(sel.Selections, t)
+
+	case *types.FragmentSpread:
+		frag := c.doc.Fragments.Get(sel.Name.Name)
+		if frag == nil {
+			return 0
+		}
+		if _, ok := c.visiting[frag.Name.Name]; ok {
+			return 0 // fragment cycles are reported by the validation
+		}
+		c.visiting[frag.Name.Name] = struct{}{}
+		defer delete(c.visiting, frag.Name.Name)
+		return c.selectionSetCost(frag.Selections, c.schema.Types[frag.On.Name])
+
+	default:
+		panic("unreachable")
+	}
+}
+
+func (c *costContext) fieldCost(sel *types.Field, f *types.FieldDefinition) int {
+	cost := directiveWeight(f.Directives, 0)
+	for _, arg := range sel.Arguments {
+		if decl := f.Arguments.Get(arg.Name.Name); decl != nil {
+			cost = addCost(cost, directiveWeight(decl.Directives, 0))
+		}
+	}
+
+	named := unwrapType(f.Type)
+	valueCost := addCost(typeWeight(named), c.selectionSetCost(sel.SelectionSet, named))
+	return addCost(cost, mulCost(c.listSize(sel, f), valueCost))
+}
+
+// listSize returns the number of values a field is expected to return.
+func (c *costContext) listSize(sel *types.Field, f *types.FieldDefinition) int {
+	t := f.Type
+	if nn, ok := t.(*types.NonNull); ok {
+		t = nn.OfType
+	}
+	if _, ok := t.(*types.List); !ok {
+		return 1
+	}
+
+	d := f.Directives.Get("listSize")
+	if d == nil {
+		return 1
+	}
+	size, found := 0, false
+	if v, ok := d.Arguments.Get("slicingArguments"); ok && v != nil {
+		names, _ := v.Deserialize(nil).([]interface{})
+		for _, name := range names {
+			name, _ := name.(string)
+			if n, ok := toInt(c.argumentValue(sel, f, name)); ok {
+				found = true
+				if n > size {
+					size = n
+				}
+			}
+		}
+	}
+	if found {
+		return size
+	}
+	if v, ok := d.Arguments.Get("assumedSize"); ok && v != nil {
+		if n, ok := toInt(v.Deserialize(nil)); ok {
+			return n
+		}
+	}
+	return 1
+}
+
+// argumentValue returns the value of the named argument of a field, falling back to the default
+// value of the argument if it is omitted or set to a variable which is not provided.
+func (c *costContext) argumentValue(sel *types.Field, f *types.FieldDefinition, name string) interface{} {
+	if arg, ok := sel.Arguments.Get(name); ok {
+		v, isVar := arg.(*types.Variable)
+		if !isVar {
+			return arg.Deserialize(c.vars)
+		}
+		if val, ok := c.vars[v.Name]; ok {
+			return val
+		}
+	}
+	if decl := f.Arguments.Get(name); decl != nil && decl.Default != nil {
+		return decl.Default.Deserialize(nil)
+	}
+	return nil
+}
+
+func typeWeight(t types.NamedType) int {
+	switch t := t.(type) {
+	case *types.ObjectTypeDefinition:
+		return directiveWeight(t.Directives, 1)
+	case *types.InterfaceTypeDefinition:
+		return directiveWeight(t.Directives, 1)
+	case *types.Union:
+		return directiveWeight(t.Directives, 1)
+	case *types.EnumTypeDefinition:
+		return directiveWeight(t.Directives, 0)
+	case *types.ScalarTypeDefinition:
+		return directiveWeight(t.Directives, 0)
+	default:
+		return 0
+	}
+}
+
+// directiveWeight returns the weight of the @cost directive, or def if there is none.
+func directiveWeight(directives types.DirectiveList, def int) int {
+	d := directives.Get("cost")
+	if d == nil {
+		return def
+	}
+	v, ok := d.Arguments.Get("weight")
+	if !ok || v == nil {
+		return def
+	}
+	if n, ok := toInt(v.Deserialize(nil)); ok {
+		return n
+	}
+	return def
+}
+
+// toInt converts a weight or size to an int. Negative values count as 0, so that they can not
+// lower the cost of the rest of the query.
+func toInt(v interface{}) (int, bool) {
+	var n int
+	switch v := v.(type) {
+	case int32:
+		n = int(v)
+	case int:
+		n = v
+	case int64:
+		n = int(v)
+	case float64:
+		n = int(v)
+	default:
+		return 0, false
+	}
+	if n < 0 {
+		return 0, true
+	}
+	return n, true
+}
+
+// addCost and mulCost saturate instead of overflowing.
+func addCost(a, b int) int {
+	if b > 0 && a > math.MaxInt32-b {
+		return math.MaxInt32
+	}
+	return a + b
+}
+
+func mulCost(a, b int) int {
+	if a != 0 && b > math.MaxInt32/a {
+		return math.MaxInt32
+	}
+	return a * b
+}
@@ -0,0 +1,153 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+const costSchema = `
+	type Query {
+		users(first: Int, last: Int): [User!]! @listSize(slicingArguments: ["first", "last"], assumedSize: 50)
+		search(term: String @cost(weight: 5)): [Result!]! @listSize(assumedSize: 10)
+		viewer: User
+		expensive: Int @cost(weight: 20)
+		posts(first: Int = 25): [Post!]! @listSize(slicingArguments: ["first"], assumedSize: 100)
+		discount: Int @cost(weight: -10)
+		broken: [User!]! @listSize(assumedSize: -5)
+	}
+
+	type Post {
+		title: String!
+	}
+
+	type User {
+		name: String!
+		friends: [User!]!
+		avatar: Image
+	}
+
+	type Image @cost(weight: 3) {
+		url: String!
+	}
+
+	union Result = User | Image
+`
+
+func TestComplexity(t *testing.T) {
+	s := schema.New()
+	schema.AddCostDirectives(s)
+	if err := schema.Parse(s, costSchema, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		cost  int
+	}{
+		{
+			name:  "scalar fields are free",
+			query: `{ viewer { name } }`,
+			cost:  1,
+		},
+		{
+			name:  "field and type weights",
+			query: `{ expensive viewer { avatar { url } } }`,
+			cost:  20 + 1 + 3,
+		},
+		{
+			name:  "slicing argument",
+			query: `{ users(first: 10) { name friends { name } } }`,
+			cost:  10 * (1 + 1),
+		},
+		{
+			name:  "largest slicing argument",
+			query: `{ users(first: 10, last: 20) { name } }`,
+			cost:  20,
+		},
+		{
+			name:  "slicing argument from a variable",
+			query: `query($n: Int) { users(first: $n) { name } }`,
+			vars:  map[string]interface{}{"n": float64(7)},
+			cost:  7,
+		},
+		{
+			name:  "assumed size",
+			query: `{ users { name } }`,
+			cost:  50,
+		},
+		{
+			name:  "default slicing argument",
+			query: `{ posts { title } }`,
+			cost:  25,
+		},
+		{
+			name:  "default slicing argument for an omitted variable",
+			query: `query($n: Int) { posts(first: $n) { title } }`,
+			cost:  25,
+		},
+		{
+			name:  "provided slicing argument over the default",
+			query: `{ posts(first: 3) { title } }`,
+			cost:  3,
+		},
+		{
+			name:  "negative weight",
+			query: `{ discount viewer { name } }`,
+			cost:  1,
+		},
+		{
+			name:  "negative assumed size",
+			query: `{ broken { name } viewer { name } }`,
+			cost:  1,
+		},
+		{
+			name:  "negative slicing argument",
+			query: `{ users(first: -10) { name } viewer { name } }`,
+			cost:  1,
+		},
+		{
+			name:  "argument weight and fragments",
+			query: `{ search(term: "x") { ... on User { name } ...img } } fragment img on Image { url }`,
+			cost:  5 + 10*1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cost := Complexity(s, doc, doc.Operations[0], tc.vars); cost != tc.cost {
+				t.Fatalf("expected cost %d, got %d", tc.cost, cost)
+			}
+		})
+	}
+}
+
+func TestValidateComplexity(t *testing.T) {
+	s := schema.New()
+	schema.AddCostDirectives(s)
+	if err := schema.Parse(s, costSchema, false); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, qErr := query.Parse(`{ users(first: 100) { name } }`)
+	if qErr != nil {
+		t.Fatal(qErr)
+	}
+	cost, errs := ValidateComplexity(s, doc, doc.Operations[0], nil, 99)
+	if cost != 100 {
+		t.Fatalf("expected cost 100, got %d", cost)
+	}
+	if len(errs) != 1 || errs[0].Rule != "MaxComplexity" {
+		t.Fatalf("expected a MaxComplexity error, got %v", errs)
+	}
+	if _, errs := ValidateComplexity(s, doc, doc.Operations[0], nil, 100); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+func TestProvidedNonNullArguments(t *testing.T) {
+	s := schema.New()
+	if err := schema.Parse(s, `
+		directive @limit(max: Int! = 10, label: String!) on FIELD
+
+		type Query {
+			users(first: Int! = 10, role: String!): [String!]!
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		messages []string
+	}{
+		{
+			name:  "non-null argument with a default omitted",
+			query: `{ users(role: "admin") }`,
+		},
+		{
+			name:     "non-null argument without a default omitted",
+			query:    `{ users(first: 1) }`,
+			messages: []string{`Field "users" argument "role" of type "String!" is required but not provided.`},
+		},
+		{
+			name:  "non-null directive argument with a default omitted",
+			query: `{ users(role: "admin") @limit(label: "a") }`,
+		},
+		{
+			name:     "non-null directive argument without a default omitted",
+			query:    `{ users(role: "admin") @limit(max: 1) }`,
+			messages: []string{`Directive "@limit" argument "label" of type "String!" is required but not provided.`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := Validate(s, doc, nil, 0)
+			if len(errs) != len(tc.messages) {
+				t.Fatalf("expected %d errors, got %v", len(tc.messages), errs)
+			}
+			for i, err := range errs {
+				if err.Message != tc.messages[i] {
+					t.Errorf("unexpected error\ngot:  %s\nwant: %s", err.Message, tc.messages[i])
+				}
+				if err.Rule != "ProvidedNonNullArguments" {
+					t.Errorf("expected rule ProvidedNonNullArguments, got %q", err.Rule)
+				}
+			}
+		})
+	}
+}
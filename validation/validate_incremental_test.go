@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+func TestDeferStreamDirectives(t *testing.T) {
+	s := schema.New()
+	schema.AddIncrementalDirectives(s)
+	if err := schema.Parse(s, simpleSchema, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		rules []string
+	}{
+		{
+			name:  "valid",
+			query: `{ characters @stream(initialCount: 1, label: "a") { id ... @defer(label: "b") { name } } }`,
+		},
+		{
+			name:  "stream on non-list field",
+			query: `{ characters { name @stream } }`,
+			rules: []string{"StreamDirectiveOnListField"},
+		},
+		{
+			name:  "duplicate labels",
+			query: `{ characters @stream(label: "a") { ... @defer(label: "a") { name } } }`,
+			rules: []string{"DeferStreamDirectiveLabel"},
+		},
+		{
+			name:  "variable label",
+			query: `query($l: String) { characters { ... @defer(label: $l) { name } } }`,
+			rules: []string{"DeferStreamDirectiveLabel"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := Validate(s, doc, nil, 0)
+			if len(errs) != len(tc.rules) {
+				t.Fatalf("expected %d errors, got %v", len(tc.rules), errs)
+			}
+			for i, err := range errs {
+				if err.Rule != tc.rules[i] {
+					t.Errorf("expected rule %q, got %q: %s", tc.rules[i], err.Rule, err.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/query"
+	"github.com/graph-gophers/graphql-go/schema"
+)
+
+const oneOfSchema = `
+	input UserBy @oneOf {
+		id: ID
+		email: String
+	}
+
+	type User {
+		name: String!
+	}
+
+	type Query {
+		user(by: UserBy!): User
+	}
+`
+
+func TestOneOfInputObjects(t *testing.T) {
+	s := schema.New()
+	if err := schema.Parse(s, oneOfSchema, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		messages  []string
+	}{
+		{
+			name:  "one field",
+			query: `{ user(by: {id: "1"}) { name } }`,
+		},
+		{
+			name:  "no field",
+			query: `{ user(by: {}) { name } }`,
+			messages: []string{"Argument \"by\" has invalid value {}.\n" +
+				"Exactly one field must be specified for oneOf input object \"UserBy\"."},
+		},
+		{
+			name:  "two fields",
+			query: `{ user(by: {id: "1", email: "a@example.com"}) { name } }`,
+			messages: []string{"Argument \"by\" has invalid value {id: \"1\", email: \"a@example.com\"}.\n" +
+				"Exactly one field must be specified for oneOf input object \"UserBy\"."},
+		},
+		{
+			name:  "null field",
+			query: `{ user(by: {id: null}) { name } }`,
+			messages: []string{"Argument \"by\" has invalid value {id: null}.\n" +
+				"In field \"id\": Expected a non-null value for a field of a oneOf input object."},
+		},
+		{
+			name:      "non-null variable field",
+			query:     `query($id: ID!) { user(by: {id: $id}) { name } }`,
+			variables: map[string]interface{}{"id": "1"},
+		},
+		{
+			name:      "nullable variable field",
+			query:     `query($id: ID) { user(by: {id: $id}) { name } }`,
+			variables: map[string]interface{}{"id": "1"},
+			messages:  []string{"Variable \"$id\" must be non-nullable to be used for a field of a oneOf input object."},
+		},
+		{
+			name: "nullable variable field of another operation",
+			query: `
+				query A($id: ID!) { user(by: {id: $id}) { name } }
+				query B($id: ID) { user(by: {email: "a@example.com"}) { name } other: user(by: {id: "1"}) { name } }
+			`,
+			variables: map[string]interface{}{"id": "1"},
+			messages:  []string{"Variable \"$id\" is never used in operation \"B\"."},
+		},
+		{
+			name: "nullable variable field in a fragment",
+			query: `
+				query A($id: ID!) { ...user }
+				query B($id: ID) { ...user }
+				fragment user on Query { user(by: {id: $id}) { name } }
+			`,
+			variables: map[string]interface{}{"id": "1"},
+			messages:  []string{"Variable \"$id\" must be non-nullable to be used for a field of a oneOf input object in operation \"B\"."},
+		},
+		{
+			name:      "variable",
+			query:     `query($by: UserBy!) { user(by: $by) { name } }`,
+			variables: map[string]interface{}{"by": map[string]interface{}{"email": "a@example.com"}},
+		},
+		{
+			name:      "variable with two fields",
+			query:     `query($by: UserBy!) { user(by: $by) { name } }`,
+			variables: map[string]interface{}{"by": map[string]interface{}{"id": "1", "email": "a@example.com"}},
+			messages: []string{"Variable \"by\" has invalid value map[email:a@example.com id:1].\n" +
+				"Exactly one field must be specified for oneOf input object \"UserBy\"."},
+		},
+		{
+			name:      "variable with a null field",
+			query:     `query($by: UserBy!) { user(by: $by) { name } }`,
+			variables: map[string]interface{}{"by": map[string]interface{}{"id": nil}},
+			messages: []string{"Variable \"by\" has invalid value map[id:<nil>].\n" +
+				"Field \"id\" of oneOf input object \"UserBy\" must be non-null."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := Validate(s, doc, tc.variables, 0)
+			if len(errs) != len(tc.messages) {
+				t.Fatalf("expected %d errors, got %v", len(tc.messages), errs)
+			}
+			for i, err := range errs {
+				if err.Message != tc.messages[i] {
+					t.Errorf("unexpected error\ngot:  %s\nwant: %s", err.Message, tc.messages[i])
+				}
+			}
+		})
+	}
+}
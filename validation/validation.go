@@ -34,6 +34,7 @@ type context struct {
 	fieldMap         map[*types.Field]fieldInfo
 	overlapValidated map[selectionPair]struct{}
 	maxDepth         int
+	labels           nameSet
 }
 
 func (c *context) addErr(loc errors.Location, rule string, format string, a ...interface{}) {
@@ -62,10 +63,49 @@ func newContext(s *types.Schema, doc *types.ExecutableDefinition, maxDepth int)
 		fieldMap:         make(map[*types.Field]fieldInfo),
 		overlapValidated: make(map[selectionPair]struct{}),
 		maxDepth:         maxDepth,
+		labels:           make(nameSet),
 	}
 }
 
 func Validate(s *types.Schema, doc *types.ExecutableDefinition, variables map[string]interface{}, maxDepth int) []*errors.QueryError {
+	return validate(s, doc, variables, maxDepth, true)
+}
+
+// ValidateDocument runs the validations which do not depend on the variable values. Together with
+// ValidateVariables, it validates the same as Validate.
+func ValidateDocument(s *types.Schema, doc *types.ExecutableDefinition, maxDepth int) []*errors.QueryError {
+	return validate(s, doc, nil, maxDepth, false)
+}
+
+// ValidateVariables validates the variable values against the variable types of the operations.
+func ValidateVariables(s *types.Schema, doc *types.ExecutableDefinition, variables map[string]interface{}) []*errors.QueryError {
+	c := newContext(s, doc, 0)
+	for _, op := range doc.Operations {
+		validateOperationVariables(c, op, variables)
+	}
+	return c.errs
+}
+
+// ValidateOperationVariables validates the variable values against the variable types of a single
+// operation of the document.
+func ValidateOperationVariables(s *types.Schema, doc *types.ExecutableDefinition, op *types.OperationDefinition, variables map[string]interface{}) []*errors.QueryError {
+	c := newContext(s, doc, 0)
+	validateOperationVariables(c, op, variables)
+	return c.errs
+}
+
+func validateOperationVariables(c *context, op *types.OperationDefinition, variables map[string]interface{}) {
+	opc := &opContext{c, []*types.OperationDefinition{op}}
+	for _, v := range op.Vars {
+		t, err := common.ResolveType(v.Type, c.schema.Resolve)
+		if err != nil {
+			continue // reported by ValidateDocument
+		}
+		validateValue(opc, v, variables[v.Name.Name], t)
+	}
+}
+
+func validate(s *types.Schema, doc *types.ExecutableDefinition, variables map[string]interface{}, maxDepth int, validateVariables bool) []*errors.QueryError {
 	c := newContext(s, doc, maxDepth)
 
 	opNames := make(nameSet)
@@ -97,7 +137,9 @@ func Validate(s *types.Schema, doc *types.ExecutableDefinition, variables map[st
 			if !canBeInput(t) {
 				c.addErr(v.TypeLoc, "VariablesAreInputTypes", "Variable %q cannot be non-input type %q.", "$"+v.Name.Name, t)
 			}
-			validateValue(opc, v, variables[v.Name.Name], t)
+			if validateVariables {
+				validateValue(opc, v, variables[v.Name.Name], t)
+			}
 
 			if v.Default != nil {
 				validateLiteral(opc, v.Default)
@@ -226,6 +268,18 @@ func validateValue(c *opContext, v *types.InputValueDefinition, val interface{},
 			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid type %T.\nExpected type \"%s\", found %s.", v.Name.Name, val, t, val)
 			return
 		}
+		if t.IsOneOf() {
+			if len(in) != 1 {
+				c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %v.\nExactly one field must be specified for oneOf input object \"%s\".", v.Name.Name, val, t)
+				return
+			}
+			for name, fieldVal := range in {
+				if fieldVal == nil {
+					c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %v.\nField \"%s\" of oneOf input object \"%s\" must be non-null.", v.Name.Name, val, name, t)
+					return
+				}
+			}
+		}
 		for _, f := range t.Values {
 			fieldVal := in[f.Name.Name]
 			validateValue(c, f, fieldVal, f.Type)
@@ -352,6 +406,15 @@ func validateSelection(c *opContext, sel types.Selection, t types.NamedType) {
 		var ft types.Type
 		if f != nil {
 			ft = f.Type
+			if d := sel.Directives.Get("stream"); d != nil {
+				lt := ft
+				if nn, ok := lt.(*types.NonNull); ok {
+					lt = nn.OfType
+				}
+				if _, ok := lt.(*types.List); !ok {
+					c.addErr(d.Name.Loc, "StreamDirectiveOnListField", "Stream directive cannot be used on non-list field %q on type %q.", fieldName, t)
+				}
+			}
 			sf := hasSubfields(ft)
 			if sf && sel.SelectionSet == nil {
 				c.addErr(sel.Alias.Loc, "ScalarLeafs", "Field %q of type %q must have a selection of subfields. Did you mean \"%s { ... }\"?", fieldName, ft, fieldName)
@@ -686,9 +749,29 @@ func validateDirectives(c *opContext, loc string, directives types.DirectiveList
 			func() string { return fmt.Sprintf("directive %q", "@"+dirName) },
 			func() string { return fmt.Sprintf("Directive %q", "@"+dirName) },
 		)
+
+		if dirName == "defer" || dirName == "stream" {
+			validateDeferStreamLabel(c, d)
+		}
 	}
 }
 
+func validateDeferStreamLabel(c *opContext, d *types.Directive) {
+	arg, ok := d.Arguments.Get("label")
+	if !ok {
+		return
+	}
+	label, ok := arg.(*types.PrimitiveValue)
+	if !ok || label.Type != scanner.String {
+		c.addErr(arg.Location(), "DeferStreamDirectiveLabel", "Directive %q's label argument must be a static string.", "@"+d.Name.Name)
+		return
+	}
+	name := types.Ident{Name: label.Deserialize(nil).(string), Loc: label.Loc}
+	validateNameCustomMsg(c.context, c.labels, name, "DeferStreamDirectiveLabel", func() string {
+		return "Defer/Stream directive label argument must be unique."
+	})
+}
+
 func validateName(c *context, set nameSet, name types.Ident, rule string, kind string) {
 	validateNameCustomMsg(c, set, name, rule, func() string {
 		return fmt.Sprintf("There can be only one %s named %q.", kind, name.Name)
@@ -716,7 +799,7 @@ func validateArgumentTypes(c *opContext, args types.ArgumentList, argDecls types
 		}
 	}
 	for _, decl := range argDecls {
-		if _, ok := decl.Type.(*types.NonNull); ok {
+		if _, ok := decl.Type.(*types.NonNull); ok && decl.Default == nil {
 			if _, ok := args.Get(decl.Name.Name); !ok {
 				c.addErr(loc, "ProvidedNonNullArguments", "%s argument %q of type %q is required but not provided.", owner2(), decl.Name.Name, decl.Type)
 			}
@@ -759,7 +842,7 @@ func validateLiteral(c *opContext, l types.Value) {
 				})
 				continue
 			}
-			validateValueType(c, l, resolveType(c.context, v.Type))
+			validateValueType(&opContext{c.context, []*types.OperationDefinition{op}}, l, resolveType(c.context, v.Type))
 			c.usedVars[op][v] = struct{}{}
 		}
 	}
@@ -828,6 +911,14 @@ func validateValueType(c *opContext, v types.Value, t types.Type) (bool, string)
 				return false, fmt.Sprintf("In field %q: %s", name, reason)
 			}
 		}
+		if t.IsOneOf() {
+			if len(v.Fields) != 1 {
+				return false, fmt.Sprintf("Exactly one field must be specified for oneOf input object %q.", t)
+			}
+			if ok, reason := validateOneOfField(c, v.Fields[0].Value); !ok {
+				return false, fmt.Sprintf("In field %q: %s", v.Fields[0].Name.Name, reason)
+			}
+		}
 		for _, iv := range t.Values {
 			found := false
 			for _, f := range v.Fields {
@@ -848,6 +939,36 @@ func validateValueType(c *opContext, v types.Value, t types.Type) (bool, string)
 	return false, fmt.Sprintf("Expected type %q, found %s.", t, v)
 }
 
+// validateOneOfField checks that the value of the field of a oneOf input object is not null. A
+// variable used as the value must be non-null in the operation, it is checked against the variable
+// definitions of each operation using it and reported with the errors of that operation.
+func validateOneOfField(c *opContext, v types.Value) (bool, string) {
+	if isNull(v) {
+		return false, "Expected a non-null value for a field of a oneOf input object."
+	}
+	if v, ok := v.(*types.Variable); ok {
+		for _, op := range c.ops {
+			v2 := op.Vars.Get(v.Name)
+			if v2 == nil {
+				continue // reported by NoUndefinedVariables
+			}
+			if _, ok := v2.Type.(*types.NonNull); ok {
+				continue
+			}
+			byOp := ""
+			if op.Name.Name != "" {
+				byOp = fmt.Sprintf(" in operation %q", op.Name.Name)
+			}
+			c.opErrs[op] = append(c.opErrs[op], &errors.QueryError{
+				Message:   fmt.Sprintf("Variable %q must be non-nullable to be used for a field of a oneOf input object%s.", "$"+v.Name, byOp),
+				Locations: []errors.Location{v2.Loc, v.Loc},
+				Rule:      "VariablesInAllowedPosition",
+			})
+		}
+	}
+	return true, ""
+}
+
 func validateBasicLit(v *types.PrimitiveValue, t types.Type) bool {
 	switch t := t.(type) {
 	case *types.ScalarTypeDefinition:
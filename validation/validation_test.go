@@ -54,6 +54,10 @@ func TestValidate(t *testing.T) {
 				t.Fatal(err)
 			}
 			errs := validation.Validate(schemas[test.Schema], d, test.Vars, 0)
+			split := append(validation.ValidateDocument(schemas[test.Schema], d, 0), validation.ValidateVariables(schemas[test.Schema], d, test.Vars)...)
+			if len(split) != len(errs) {
+				t.Errorf("ValidateDocument and ValidateVariables found %d errors, Validate found %d", len(split), len(errs))
+			}
 			got := []*errors.QueryError{}
 			for _, err := range errs {
 				if err.Rule == test.Rule {